@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +15,56 @@ type Config struct {
 	Port        string
 	Environment string
 	JWTSecret   string
+
+	// Token lifetimes
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Password reset
+	PasswordResetTTL time.Duration
+
+	// How long a patient account claim code stays valid
+	ClaimCodeTTL time.Duration
+
+	// Multi-factor authentication: issuer shown in authenticator apps and how
+	// long a login has to answer the MFA challenge
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+
+	// Account lockout after repeated failed sign-ins; the duration doubles
+	// with each further lockout up to the maximum
+	LoginMaxAttempts    int
+	LoginLockout        time.Duration
+	LoginLockoutMax     time.Duration
+	AuthRateLimit       int // Requests per IP to the credential endpoints per window
+	AuthRateLimitWindow time.Duration
+
+	// Reverse proxies whose ProxyHeader is believed for the client IP; with
+	// none, the connection's address is used
+	TrustedProxies []string
+	ProxyHeader    string
+
+	// How long resolved role permissions are cached
+	PermissionCacheTTL time.Duration
+
+	// Outbound notifications ("log" or "file")
+	Notifier         string
+	NotifierFilePath string
+
+	// Drug-drug interaction rules (.csv or .json) imported at startup
+	DrugInteractionsFile string
+
+	// Vaccines and dose schedule (.json) imported at startup
+	ImmunizationScheduleFile string
+
+	// WHO child growth standard tables (.json) loaded at startup
+	GrowthStandardsFile string
+
+	// Lab test catalog with reference ranges (.json) imported at startup
+	LabCatalogFile string
+
+	// ICD-10 codes (.csv) that diagnoses are validated against, imported at startup
+	ICD10CodesFile string
 }
 
 func LoadConfig() *Config {
@@ -26,6 +79,40 @@ func LoadConfig() *Config {
 		Port:        getEnv("PORT", "3000"),
 		Environment: getEnv("ENVIRONMENT", "development"),
 		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key-change-this-in-production"),
+
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		ClaimCodeTTL: getEnvDuration("CLAIM_CODE_TTL", 7*24*time.Hour),
+
+		MFAIssuer:       getEnv("MFA_ISSUER", "Rural Health"),
+		MFAChallengeTTL: getEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+
+		LoginMaxAttempts:    getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginLockout:        getEnvDuration("LOGIN_LOCKOUT", 15*time.Minute),
+		LoginLockoutMax:     getEnvDuration("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+		AuthRateLimit:       getEnvInt("AUTH_RATE_LIMIT", 30),
+		AuthRateLimitWindow: getEnvDuration("AUTH_RATE_LIMIT_WINDOW", time.Minute),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+		ProxyHeader:    getEnv("PROXY_HEADER", "X-Real-IP"),
+
+		PermissionCacheTTL: getEnvDuration("PERMISSION_CACHE_TTL", 5*time.Minute),
+
+		Notifier:         getEnv("NOTIFIER", "log"),
+		NotifierFilePath: getEnv("NOTIFIER_FILE_PATH", "notifications.log"),
+
+		DrugInteractionsFile: getEnv("DRUG_INTERACTIONS_FILE", "data/drug_interactions.csv"),
+
+		ImmunizationScheduleFile: getEnv("IMMUNIZATION_SCHEDULE_FILE", "data/immunization_schedule.json"),
+
+		GrowthStandardsFile: getEnv("GROWTH_STANDARDS_FILE", "data/who_growth_standards.json"),
+
+		LabCatalogFile: getEnv("LAB_CATALOG_FILE", "data/lab_tests.json"),
+
+		ICD10CodesFile: getEnv("ICD10_CODES_FILE", "data/icd10_codes.csv"),
 	}
 
 	return config
@@ -37,3 +124,34 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Warning: invalid number for %s, using default %d", key, defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+		log.Printf("Warning: invalid duration for %s, using default %s", key, defaultValue)
+	}
+	return defaultValue
+}
@@ -3,11 +3,13 @@ package database
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"rural_health_management_system/internal/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -18,6 +20,8 @@ type Database struct {
 func NewDatabase(dsn string) (*Database, error) {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// Report unique violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -31,12 +35,68 @@ func NewDatabase(dsn string) (*Database, error) {
 		&models.Staff{},
 		&models.Visit{},
 		&models.Diagnosis{},
+		&models.FormularyItem{},
 		&models.Prescription{},
+		&models.AuthSession{},
+		&models.PasswordResetToken{},
+		&models.Role{},
+		&models.RolePermission{},
+		&models.ClinicPermissionOverride{},
+		&models.RoleGrantMigration{},
+		&models.AuditLog{},
+		&models.Appointment{},
+		&models.StaffWorkingHours{},
+		&models.VitalSigns{},
+		&models.PatientAllergy{},
+		&models.PatientProblem{},
+		&models.DrugInteraction{},
+		&models.StockItem{},
+		&models.StockBatch{},
+		&models.StockReceipt{},
+		&models.StockReceiptLine{},
+		&models.StockTransaction{},
+		&models.Dispensation{},
+		&models.Referral{}, &models.PatientTransfer{}, &models.MRNSequence{}, &models.PatientClaimCode{}, &models.PatientDelegation{},
+		&models.UserMFA{}, &models.MFARecoveryCode{}, &models.MFAChallenge{}, &models.AuthEvent{},
+		&models.Vaccine{}, &models.VaccineScheduleDose{}, &models.Immunization{},
+		&models.Pregnancy{}, &models.AntenatalContact{}, &models.PostnatalVisit{}, &models.GrowthMeasurement{},
+		&models.LabTest{}, &models.LabTestComponent{}, &models.LabReferenceRange{}, &models.LabOrder{}, &models.LabResult{},
+		&models.ICD10Code{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Doses recorded twice are left for an admin to reconcile before the
+	// unique index goes on
+	indexed, err := models.EnsureDoseIndex(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create immunization dose index: %w", err)
+	}
+	if !indexed {
+		log.Println("Warning: some immunization doses are recorded more than once; reconcile them through GET /api/v1/immunizations/duplicates to enable the unique dose index")
+	}
+
+	// Seed default roles and permissions
+	if err := seedDefaultRoles(db); err != nil {
+		return nil, fmt.Errorf("failed to seed roles: %w", err)
+	}
+
+	// Existing roles get the default permissions added since they were seeded
+	if err := grantAddedRolePermissions(db); err != nil {
+		return nil, fmt.Errorf("failed to grant new default permissions: %w", err)
+	}
+
+	// Patients registered before record numbers existed get one now
+	if err := backfillMRNs(db); err != nil {
+		return nil, fmt.Errorf("failed to assign medical record numbers: %w", err)
+	}
+
+	// Audit entries can only ever be appended
+	if err := protectAuditLog(db); err != nil {
+		return nil, fmt.Errorf("failed to protect audit log: %w", err)
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -51,6 +111,114 @@ func NewDatabase(dsn string) (*Database, error) {
 	return &Database{DB: db}, nil
 }
 
+// seedDefaultRoles creates any role missing from the database with its default
+// permissions from models.RolePermissions. Existing roles are left untouched so
+// that changes made through the admin API are preserved; grants added to the
+// defaults later reach them through grantAddedRolePermissions.
+func seedDefaultRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for name, permissions := range models.RolePermissions {
+			var role models.Role
+			result := tx.Where("name = ?", name).Limit(1).Find(&role)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				continue
+			}
+
+			role = models.Role{Name: name}
+			if err := tx.Create(&role).Error; err != nil {
+				return err
+			}
+
+			for _, p := range permissions {
+				if err := tx.Create(&models.RolePermission{RoleID: role.ID, Permission: p}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// grantAddedRolePermissions gives existing roles each grant in
+// models.AddedRolePermissions once. The grant is recorded as applied even when
+// the role already had it, so a later revocation is not undone on restart.
+func grantAddedRolePermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, grant := range models.AddedRolePermissions {
+			applied := models.RoleGrantMigration{RoleName: grant.RoleName, Permission: grant.Permission, AppliedAt: time.Now()}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&applied)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			var role models.Role
+			result = tx.Where("name = ?", grant.RoleName).Limit(1).Find(&role)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.RolePermission{RoleID: role.ID, Permission: grant.Permission})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("Granted %s to existing role %s", grant.Permission, grant.RoleName)
+			}
+		}
+		return nil
+	})
+}
+
+// backfillMRNs assigns medical record numbers to patients that have none, in
+// registration order
+func backfillMRNs(db *gorm.DB) error {
+	var patients []models.Patient
+	if err := db.Unscoped().Where("mrn IS NULL").Order("id").Find(&patients).Error; err != nil {
+		return err
+	}
+	for _, patient := range patients {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			mrn, err := models.NextMRN(tx, patient.ClinicID)
+			if err != nil {
+				return err
+			}
+			return tx.Unscoped().Model(&models.Patient{}).Where("id = ?", patient.ID).Update("mrn", mrn).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(patients) > 0 {
+		log.Printf("Assigned medical record numbers to %d patients", len(patients))
+	}
+	return nil
+}
+
+// protectAuditLog installs rules that silently discard UPDATE and DELETE
+// statements against the audit log table
+func protectAuditLog(db *gorm.DB) error {
+	statements := []string{
+		"CREATE OR REPLACE RULE audit_logs_no_update AS ON UPDATE TO audit_logs DO INSTEAD NOTHING",
+		"CREATE OR REPLACE RULE audit_logs_no_delete AS ON DELETE TO audit_logs DO INSTEAD NOTHING",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
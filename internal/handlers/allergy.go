@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// patientAllergyMatches checks a medication against the patient's active allergies
+func patientAllergyMatches(db *gorm.DB, patientID uint, medicationName string) ([]models.AllergyMatch, error) {
+	var allergies []models.PatientAllergy
+	if err := db.Where("patient_id = ? AND status = ?", patientID, models.ClinicalStatusActive).
+		Find(&allergies).Error; err != nil {
+		return nil, err
+	}
+	return models.MatchAllergies(medicationName, allergies), nil
+}
+
+// checkPrescriptionAllergies runs the allergy check for a new prescription. It
+// writes the error response itself and returns ok=false when the prescription
+// must not be created.
+func checkPrescriptionAllergies(db *gorm.DB, c *fiber.Ctx, patientID uint, req *models.CreatePrescriptionRequest) ([]models.AllergyMatch, bool, error) {
+	matches, err := patientAllergyMatches(db, patientID, req.MedicationName)
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check patient allergies",
+		})
+	}
+
+	req.AllergyOverrideReason = strings.TrimSpace(req.AllergyOverrideReason)
+	if len(matches) == 0 {
+		req.AllergyOverrideReason = ""
+		return nil, true, nil
+	}
+
+	if models.RequiresAllergyOverride(matches) && req.AllergyOverrideReason == "" {
+		return matches, false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":            "Medication matches a recorded allergy. Provide allergy_override_reason to prescribe anyway.",
+			"allergy_warnings": matches,
+		})
+	}
+	if len(req.AllergyOverrideReason) > 500 {
+		return matches, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "allergy_override_reason must be at most 500 characters",
+		})
+	}
+
+	return matches, true, nil
+}
+
+// findClinicPatient loads a patient registered at the caller's clinic
+func findClinicPatient(db *gorm.DB, c *fiber.Ctx) (*models.Patient, error) {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var patient models.Patient
+	if err := db.Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// GetPatientAllergies lists a patient's allergies (inactive ones only when include_inactive=true)
+func (h *MedicalPortalHandler) GetPatientAllergies(c *fiber.Ctx) error {
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	query := h.db.Where("patient_id = ?", patient.ID)
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("status = ?", models.ClinicalStatusActive)
+	}
+
+	var allergies []models.PatientAllergy
+	if err := query.Preload("NotedBy").Order("created_at DESC").Find(&allergies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch allergies",
+		})
+	}
+
+	return c.JSON(allergies)
+}
+
+// CreatePatientAllergy records a new allergy for a patient
+func (h *MedicalPortalHandler) CreatePatientAllergy(c *fiber.Ctx) error {
+	staffID := c.Locals("staff_id").(uint)
+
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var req models.CreateAllergyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Substance = strings.TrimSpace(req.Substance)
+	if len(req.Substance) < 2 || len(req.Substance) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Substance must be between 2 and 255 characters",
+		})
+	}
+	if !validAllergySeverity(req.Severity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Severity must be mild, moderate or severe",
+		})
+	}
+
+	allergy := models.PatientAllergy{
+		PatientID:      patient.ID,
+		Substance:      req.Substance,
+		Reaction:       truncate(req.Reaction, 500),
+		Severity:       req.Severity,
+		Status:         models.ClinicalStatusActive,
+		NotedByStaffID: &staffID,
+	}
+
+	if err := h.db.Create(&allergy).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record allergy",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityAllergy, allergy.ID, patient.ID, nil, &allergy)
+
+	return c.Status(fiber.StatusCreated).JSON(allergy)
+}
+
+// UpdatePatientAllergy changes an allergy's reaction, severity or status
+func (h *MedicalPortalHandler) UpdatePatientAllergy(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var allergy models.PatientAllergy
+	if err := h.db.Joins("JOIN patients ON patients.id = patient_allergies.patient_id").
+		Where("patient_allergies.id = ? AND patients.clinic_id = ?", c.Params("id"), clinicID).
+		First(&allergy).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Allergy not found",
+		})
+	}
+
+	var req models.UpdateAllergyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := allergy
+	if req.Reaction != nil {
+		allergy.Reaction = truncate(*req.Reaction, 500)
+	}
+	if req.Severity != nil {
+		if !validAllergySeverity(*req.Severity) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Severity must be mild, moderate or severe",
+			})
+		}
+		allergy.Severity = *req.Severity
+	}
+	if req.Status != nil {
+		if *req.Status != models.ClinicalStatusActive && *req.Status != models.ClinicalStatusInactive {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Status must be active or inactive",
+			})
+		}
+		allergy.Status = *req.Status
+	}
+
+	if err := h.db.Save(&allergy).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update allergy",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityAllergy, allergy.ID, allergy.PatientID, &before, &allergy)
+
+	return c.JSON(allergy)
+}
+
+// GetPatientProblems lists a patient's problem list (status=active|resolved filters)
+func (h *MedicalPortalHandler) GetPatientProblems(c *fiber.Ctx) error {
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	query := h.db.Where("patient_id = ?", patient.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var problems []models.PatientProblem
+	if err := query.Preload("NotedBy").Order("status, created_at DESC").Find(&problems).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch problem list",
+		})
+	}
+
+	return c.JSON(problems)
+}
+
+// CreatePatientProblem adds an entry to a patient's problem list
+func (h *MedicalPortalHandler) CreatePatientProblem(c *fiber.Ctx) error {
+	staffID := c.Locals("staff_id").(uint)
+
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var req models.CreateProblemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Description = strings.TrimSpace(req.Description)
+	if len(req.Description) < 2 || len(req.Description) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Description must be between 2 and 500 characters",
+		})
+	}
+	if len(req.Code) > 20 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Code must be at most 20 characters",
+		})
+	}
+
+	problem := models.PatientProblem{
+		PatientID:      patient.ID,
+		Description:    req.Description,
+		Code:           strings.ToUpper(strings.TrimSpace(req.Code)),
+		Status:         models.ClinicalStatusActive,
+		Notes:          truncate(req.Notes, 1000),
+		NotedByStaffID: &staffID,
+	}
+
+	if req.OnsetDate != "" {
+		onset, err := time.Parse("2006-01-02", req.OnsetDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date format. Use YYYY-MM-DD",
+			})
+		}
+		problem.OnsetDate = &onset
+	}
+
+	if err := h.db.Create(&problem).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add problem",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityProblem, problem.ID, patient.ID, nil, &problem)
+
+	return c.Status(fiber.StatusCreated).JSON(problem)
+}
+
+// UpdatePatientProblem edits a problem or marks it resolved
+func (h *MedicalPortalHandler) UpdatePatientProblem(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var problem models.PatientProblem
+	if err := h.db.Joins("JOIN patients ON patients.id = patient_problems.patient_id").
+		Where("patient_problems.id = ? AND patients.clinic_id = ?", c.Params("id"), clinicID).
+		First(&problem).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Problem not found",
+		})
+	}
+
+	var req models.UpdateProblemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := problem
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if len(description) < 2 || len(description) > 500 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Description must be between 2 and 500 characters",
+			})
+		}
+		problem.Description = description
+	}
+	if req.Code != nil {
+		if len(*req.Code) > 20 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Code must be at most 20 characters",
+			})
+		}
+		problem.Code = strings.ToUpper(strings.TrimSpace(*req.Code))
+	}
+	if req.Notes != nil {
+		problem.Notes = truncate(*req.Notes, 1000)
+	}
+	if req.Status != nil {
+		switch *req.Status {
+		case models.ClinicalStatusResolved:
+			if problem.Status != models.ClinicalStatusResolved {
+				now := time.Now()
+				problem.ResolvedDate = &now
+			}
+		case models.ClinicalStatusActive:
+			problem.ResolvedDate = nil
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Status must be active or resolved",
+			})
+		}
+		problem.Status = *req.Status
+	}
+
+	if err := h.db.Save(&problem).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update problem",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityProblem, problem.ID, problem.PatientID, &before, &problem)
+
+	return c.JSON(problem)
+}
+
+func validAllergySeverity(severity string) bool {
+	return severity == models.AllergySeverityMild ||
+		severity == models.AllergySeverityModerate ||
+		severity == models.AllergySeveritySevere
+}
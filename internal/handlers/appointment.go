@@ -0,0 +1,741 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errOutsideWorkingHours   = errors.New("outside working hours")
+	errSlotUnavailable       = errors.New("slot already booked")
+	errPatientDoubleBooked   = errors.New("patient already has an appointment at this time")
+	errAppointmentNotPending = errors.New("appointment is no longer booked")
+)
+
+type AppointmentHandler struct {
+	db *gorm.DB
+}
+
+func NewAppointmentHandler(db *gorm.DB) *AppointmentHandler {
+	return &AppointmentHandler{db: db}
+}
+
+// parseScheduleDay reads the date query parameter (YYYY-MM-DD), defaulting to today
+func parseScheduleDay(c *fiber.Ctx) (time.Time, error) {
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	return time.ParseInLocation("2006-01-02", dateStr, time.Local)
+}
+
+// isMedicalStaff reports whether the caller only manages their own schedule
+func isMedicalStaff(c *fiber.Ctx) bool {
+	userType, _ := c.Locals("user_type").(string)
+	return userType == "doctor" || userType == "nurse"
+}
+
+func (h *AppointmentHandler) workingHours(db *gorm.DB, staffID uint) ([]models.StaffWorkingHours, error) {
+	var hours []models.StaffWorkingHours
+	err := db.Where("staff_id = ?", staffID).Order("weekday, start_time").Find(&hours).Error
+	return hours, err
+}
+
+// activeClinicStaff loads an active staff member belonging to the clinic
+func (h *AppointmentHandler) activeClinicStaff(staffID, clinicID uint) (*models.Staff, error) {
+	var staff models.Staff
+	if err := h.db.Where("id = ? AND clinic_id = ? AND is_active = true", staffID, clinicID).First(&staff).Error; err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// slotLength picks the template slot length for the working window containing start
+func slotLength(hours []models.StaffWorkingHours, start time.Time) time.Duration {
+	for _, w := range hours {
+		if w.Weekday != int(start.Weekday()) {
+			continue
+		}
+		windowStart, windowEnd, err := w.Window(start)
+		if err == nil && !start.Before(windowStart) && start.Before(windowEnd) {
+			return w.SlotLength()
+		}
+	}
+	return models.DefaultSlotMinutes * time.Minute
+}
+
+// book creates an appointment after re-checking availability. The staff row is
+// locked for the duration of the transaction so two concurrent bookings for the
+// same staff member cannot both pass the overlap check.
+func (h *AppointmentHandler) book(appointment *models.Appointment) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		var staff models.Staff
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&staff, appointment.StaffID).Error; err != nil {
+			return err
+		}
+
+		hours, err := h.workingHours(tx, appointment.StaffID)
+		if err != nil {
+			return err
+		}
+		if !models.WithinWorkingHours(hours, appointment.StartTime, appointment.EndTime) {
+			return errOutsideWorkingHours
+		}
+
+		activeStatuses := []string{models.AppointmentStatusBooked, models.AppointmentStatusCheckedIn}
+
+		var staffConflicts int64
+		if err := tx.Model(&models.Appointment{}).
+			Where("staff_id = ? AND status IN ? AND start_time < ? AND end_time > ?",
+				appointment.StaffID, activeStatuses, appointment.EndTime, appointment.StartTime).
+			Count(&staffConflicts).Error; err != nil {
+			return err
+		}
+		if staffConflicts > 0 {
+			return errSlotUnavailable
+		}
+
+		var patientConflicts int64
+		if err := tx.Model(&models.Appointment{}).
+			Where("patient_id = ? AND status IN ? AND start_time < ? AND end_time > ?",
+				appointment.PatientID, activeStatuses, appointment.EndTime, appointment.StartTime).
+			Count(&patientConflicts).Error; err != nil {
+			return err
+		}
+		if patientConflicts > 0 {
+			return errPatientDoubleBooked
+		}
+
+		return tx.Create(appointment).Error
+	})
+}
+
+// bookingErrorResponse maps booking errors to an HTTP response
+func bookingErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case errOutsideWorkingHours:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Requested time is outside the staff member's working hours",
+		})
+	case errSlotUnavailable:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The requested slot is already booked",
+		})
+	case errPatientDoubleBooked:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Patient already has an appointment at this time",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to book appointment",
+	})
+}
+
+// findClinicAppointment loads an appointment in the caller's clinic. Doctors
+// and nurses can only reach their own appointments.
+func (h *AppointmentHandler) findClinicAppointment(c *fiber.Ctx) (*models.Appointment, error) {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	query := h.db.Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID)
+	if isMedicalStaff(c) {
+		query = query.Where("staff_id = ?", c.Locals("staff_id").(uint))
+	}
+
+	var appointment models.Appointment
+	if err := query.First(&appointment).Error; err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// transition moves a booked appointment to a new status. The status condition
+// guards against two staff members acting on the same appointment at once.
+func (h *AppointmentHandler) transition(tx *gorm.DB, appointmentID uint, updates map[string]interface{}) error {
+	result := tx.Model(&models.Appointment{}).
+		Where("id = ? AND status = ?", appointmentID, models.AppointmentStatusBooked).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errAppointmentNotPending
+	}
+	return nil
+}
+
+// GetSchedule returns the clinic's appointments for a day (doctors and nurses see their own)
+func (h *AppointmentHandler) GetSchedule(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	day, err := parseScheduleDay(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	query := h.db.Model(&models.Appointment{}).
+		Where("clinic_id = ? AND start_time >= ? AND start_time < ?", clinicID, day, day.AddDate(0, 0, 1))
+
+	if isMedicalStaff(c) {
+		query = query.Where("staff_id = ?", c.Locals("staff_id").(uint))
+	} else if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var appointments []models.Appointment
+	if err := query.Preload("Patient").Preload("Staff").Order("start_time").Find(&appointments).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch schedule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"date":         day.Format("2006-01-02"),
+		"appointments": appointments,
+	})
+}
+
+// GetAvailability lists free slots for a staff member on a day. Patients see
+// staff from their registered clinic, or that of the patient selected by
+// patient_id when booking on someone's behalf.
+func (h *AppointmentHandler) GetAvailability(c *fiber.Ctx) error {
+	var clinicID uint
+	if _, ok := c.Locals("patient_id").(uint); ok {
+		patientID, err := portalPatientID(h.db, c, models.DelegationScopeBookAppointments)
+		if err != nil {
+			return delegationErrorResponse(c, err)
+		}
+		var patient models.Patient
+		if err := h.db.First(&patient, patientID).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Patient not found",
+			})
+		}
+		clinicID = patient.ClinicID
+	} else {
+		clinicID = c.Locals("clinic_id").(uint)
+	}
+
+	// Doctors and nurses default to their own availability
+	var staffID uint
+	if id, err := strconv.ParseUint(c.Query("staff_id"), 10, 32); err == nil {
+		staffID = uint(id)
+	} else if isMedicalStaff(c) {
+		staffID = c.Locals("staff_id").(uint)
+	} else {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "staff_id is required",
+		})
+	}
+
+	day, err := parseScheduleDay(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	if _, err := h.activeClinicStaff(staffID, clinicID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Staff not found in this clinic",
+		})
+	}
+
+	hours, err := h.workingHours(h.db, staffID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch working hours",
+		})
+	}
+
+	var appointments []models.Appointment
+	if err := h.db.Where("staff_id = ? AND start_time < ? AND end_time > ?", staffID, day.AddDate(0, 0, 1), day).
+		Find(&appointments).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch appointments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"staff_id": staffID,
+		"date":     day.Format("2006-01-02"),
+		"slots":    models.AvailableSlots(hours, appointments, day, time.Now()),
+	})
+}
+
+// CreateAppointment books an appointment for a clinic patient
+func (h *AppointmentHandler) CreateAppointment(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	userID := c.Locals("user_id").(uint)
+
+	var req models.CreateAppointmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	// Doctors and nurses book into their own schedule unless another staff member is named
+	if req.StaffID == 0 && isMedicalStaff(c) {
+		req.StaffID = c.Locals("staff_id").(uint)
+	}
+
+	if req.PatientID == 0 || req.StaffID == 0 || req.StartTime.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "patient_id, staff_id and start_time are required",
+		})
+	}
+	if len(req.Reason) < 5 || len(req.Reason) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reason must be between 5 and 500 characters",
+		})
+	}
+	if req.DurationMinutes != 0 && (req.DurationMinutes < 5 || req.DurationMinutes > 240) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "duration_minutes must be between 5 and 240",
+		})
+	}
+
+	var patient models.Patient
+	if err := h.db.Where("id = ? AND clinic_id = ?", req.PatientID, clinicID).First(&patient).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Patient not found in this clinic",
+		})
+	}
+
+	if _, err := h.activeClinicStaff(req.StaffID, clinicID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Staff not found in this clinic",
+		})
+	}
+
+	start := req.StartTime.In(time.Local)
+	length := time.Duration(req.DurationMinutes) * time.Minute
+	if length == 0 {
+		hours, err := h.workingHours(h.db, req.StaffID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch working hours",
+			})
+		}
+		length = slotLength(hours, start)
+	}
+
+	appointment := models.Appointment{
+		PatientID:      req.PatientID,
+		ClinicID:       clinicID,
+		StaffID:        req.StaffID,
+		StartTime:      start,
+		EndTime:        start.Add(length),
+		Status:         models.AppointmentStatusBooked,
+		Reason:         req.Reason,
+		Notes:          req.Notes,
+		BookedByUserID: &userID,
+	}
+
+	if err := h.book(&appointment); err != nil {
+		return bookingErrorResponse(c, err)
+	}
+
+	h.db.Preload("Patient").Preload("Staff").First(&appointment, appointment.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(appointment)
+}
+
+// CheckInAppointment marks the patient as arrived and opens the visit
+func (h *AppointmentHandler) CheckInAppointment(c *fiber.Ctx) error {
+	appointment, err := h.findClinicAppointment(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Appointment not found",
+		})
+	}
+
+	now := time.Now()
+	visit := models.Visit{
+		PatientID: appointment.PatientID,
+		ClinicID:  appointment.ClinicID,
+		StaffID:   appointment.StaffID,
+		VisitDate: now,
+		Reason:    appointment.Reason,
+		Notes:     appointment.Notes,
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&visit).Error; err != nil {
+			return err
+		}
+		return h.transition(tx, appointment.ID, map[string]interface{}{
+			"status":        models.AppointmentStatusCheckedIn,
+			"checked_in_at": now,
+			"visit_id":      visit.ID,
+		})
+	})
+	if err == errAppointmentNotPending {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only booked appointments can be checked in",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check in appointment",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, &visit)
+
+	h.db.Preload("Patient").Preload("Staff").Preload("Visit").First(appointment, appointment.ID)
+
+	return c.JSON(appointment)
+}
+
+// CancelAppointment cancels a booked appointment on behalf of the clinic
+func (h *AppointmentHandler) CancelAppointment(c *fiber.Ctx) error {
+	appointment, err := h.findClinicAppointment(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Appointment not found",
+		})
+	}
+
+	return h.cancel(c, appointment)
+}
+
+// MarkNoShow records that the patient did not attend
+func (h *AppointmentHandler) MarkNoShow(c *fiber.Ctx) error {
+	appointment, err := h.findClinicAppointment(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Appointment not found",
+		})
+	}
+
+	if time.Now().Before(appointment.StartTime) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot mark a future appointment as no-show",
+		})
+	}
+
+	err = h.transition(h.db, appointment.ID, map[string]interface{}{
+		"status": models.AppointmentStatusNoShow,
+	})
+	if err == errAppointmentNotPending {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only booked appointments can be marked as no-show",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update appointment",
+		})
+	}
+
+	h.db.Preload("Patient").Preload("Staff").First(appointment, appointment.ID)
+	return c.JSON(appointment)
+}
+
+func (h *AppointmentHandler) cancel(c *fiber.Ctx, appointment *models.Appointment) error {
+	var req models.CancelAppointmentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	err := h.transition(h.db, appointment.ID, map[string]interface{}{
+		"status":              models.AppointmentStatusCancelled,
+		"cancelled_at":        time.Now(),
+		"cancellation_reason": truncate(req.Reason, 500),
+	})
+	if err == errAppointmentNotPending {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only booked appointments can be cancelled",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel appointment",
+		})
+	}
+
+	h.db.Preload("Patient").Preload("Staff").First(appointment, appointment.ID)
+	return c.JSON(appointment)
+}
+
+// GetStaffWorkingHours returns a staff member's weekly working-hours template
+func (h *AppointmentHandler) GetStaffWorkingHours(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	// Doctors and nurses can only see their own template
+	var staffID uint
+	if isMedicalStaff(c) {
+		staffID = c.Locals("staff_id").(uint)
+	} else {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid staff ID",
+			})
+		}
+		staffID = uint(id)
+	}
+
+	var staff models.Staff
+	if err := h.db.Where("id = ? AND clinic_id = ?", staffID, clinicID).First(&staff).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Staff not found in this clinic",
+		})
+	}
+
+	hours, err := h.workingHours(h.db, staff.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch working hours",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"staff_id": staff.ID,
+		"hours":    hours,
+	})
+}
+
+// SetStaffWorkingHours replaces a staff member's weekly working-hours template
+func (h *AppointmentHandler) SetStaffWorkingHours(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var staff models.Staff
+	if err := h.db.Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID).First(&staff).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Staff not found in this clinic",
+		})
+	}
+
+	var req models.SetWorkingHoursRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	hours := make([]models.StaffWorkingHours, 0, len(req.Hours))
+	for _, entry := range req.Hours {
+		if entry.Weekday < 0 || entry.Weekday > 6 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "weekday must be between 0 (Sunday) and 6 (Saturday)",
+			})
+		}
+		start, err := models.ParseClock(entry.StartTime)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		end, err := models.ParseClock(entry.EndTime)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if end <= start {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "end_time must be after start_time",
+			})
+		}
+		if entry.SlotMinutes == 0 {
+			entry.SlotMinutes = models.DefaultSlotMinutes
+		}
+		if entry.SlotMinutes < 5 || entry.SlotMinutes > 240 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "slot_minutes must be between 5 and 240",
+			})
+		}
+
+		hours = append(hours, models.StaffWorkingHours{
+			StaffID:     staff.ID,
+			ClinicID:    clinicID,
+			Weekday:     entry.Weekday,
+			StartTime:   entry.StartTime,
+			EndTime:     entry.EndTime,
+			SlotMinutes: entry.SlotMinutes,
+		})
+	}
+
+	// Reject overlapping windows on the same day
+	for i := range hours {
+		for j := i + 1; j < len(hours); j++ {
+			if hours[i].Weekday != hours[j].Weekday {
+				continue
+			}
+			aStart, _ := models.ParseClock(hours[i].StartTime)
+			aEnd, _ := models.ParseClock(hours[i].EndTime)
+			bStart, _ := models.ParseClock(hours[j].StartTime)
+			bEnd, _ := models.ParseClock(hours[j].EndTime)
+			if aStart < bEnd && bStart < aEnd {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Working hours overlap on the same day",
+				})
+			}
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("staff_id = ?", staff.ID).Delete(&models.StaffWorkingHours{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save working hours",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"staff_id": staff.ID,
+		"hours":    hours,
+	})
+}
+
+// GetMyAppointments returns the patient's appointments, optionally only upcoming ones
+func (h *AppointmentHandler) GetMyAppointments(c *fiber.Ctx) error {
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeBookAppointments)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+
+	query := h.db.Model(&models.Appointment{}).Where("patient_id = ?", patientID)
+	if c.Query("upcoming") == "true" {
+		query = query.Where("start_time >= ? AND status = ?", time.Now(), models.AppointmentStatusBooked)
+	}
+
+	var appointments []models.Appointment
+	if err := query.Preload("Clinic").Preload("Staff").Order("start_time DESC").Find(&appointments).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch appointments",
+		})
+	}
+
+	return c.JSON(appointments)
+}
+
+// RequestAppointment lets a patient book an open slot at their clinic
+func (h *AppointmentHandler) RequestAppointment(c *fiber.Ctx) error {
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeBookAppointments)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+	userID := c.Locals("user_id").(uint)
+
+	var req models.RequestAppointmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.StaffID == 0 || req.StartTime.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "staff_id and start_time are required",
+		})
+	}
+	if len(req.Reason) < 5 || len(req.Reason) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reason must be between 5 and 500 characters",
+		})
+	}
+
+	var patient models.Patient
+	if err := h.db.First(&patient, patientID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	if _, err := h.activeClinicStaff(req.StaffID, patient.ClinicID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Staff not found in your clinic",
+		})
+	}
+
+	hours, err := h.workingHours(h.db, req.StaffID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch working hours",
+		})
+	}
+
+	// Patients may only pick one of the offered slots
+	start := req.StartTime.In(time.Local)
+	var slot *models.TimeSlot
+	for _, s := range models.AvailableSlots(hours, nil, start, time.Now()) {
+		if s.StartTime.Equal(start) {
+			slot = &s
+			break
+		}
+	}
+	if slot == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Requested time is not an available slot",
+		})
+	}
+
+	appointment := models.Appointment{
+		PatientID:      patient.ID,
+		ClinicID:       patient.ClinicID,
+		StaffID:        req.StaffID,
+		StartTime:      slot.StartTime,
+		EndTime:        slot.EndTime,
+		Status:         models.AppointmentStatusBooked,
+		Reason:         req.Reason,
+		BookedByUserID: &userID,
+	}
+
+	if err := h.book(&appointment); err != nil {
+		return bookingErrorResponse(c, err)
+	}
+
+	h.db.Preload("Clinic").Preload("Staff").First(&appointment, appointment.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(appointment)
+}
+
+// CancelMyAppointment lets a patient cancel one of their upcoming appointments
+func (h *AppointmentHandler) CancelMyAppointment(c *fiber.Ctx) error {
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeBookAppointments)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+
+	var appointment models.Appointment
+	if err := h.db.Where("id = ? AND patient_id = ?", c.Params("id"), patientID).First(&appointment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Appointment not found",
+		})
+	}
+
+	if !appointment.StartTime.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Past appointments cannot be cancelled",
+		})
+	}
+
+	return h.cancel(c, &appointment)
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// newAuditEntry fills in who made the request and from where
+func newAuditEntry(c *fiber.Ctx, action, entityType string, entityID, patientID uint) models.AuditLog {
+	entry := models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Method:     c.Method(),
+		Path:       truncate(c.Path(), 255),
+		IPAddress:  c.IP(),
+	}
+
+	if userID, ok := c.Locals("user_id").(uint); ok {
+		entry.UserID = &userID
+	}
+	if userType, ok := c.Locals("user_type").(string); ok {
+		entry.UserType = userType
+	}
+	if clinicID, ok := c.Locals("clinic_id").(uint); ok {
+		entry.ClinicID = &clinicID
+	}
+	if patientID != 0 {
+		entry.PatientID = &patientID
+	}
+
+	return entry
+}
+
+// writeAudit persists audit entries. Failures are logged rather than
+// returned so that an audit outage does not block patient care.
+func writeAudit(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).CreateInBatches(&entries, 100).Error; err != nil {
+		log.Printf("Failed to write %d audit log entries: %v", len(entries), err)
+	}
+}
+
+// recordAudit logs a single read or write. For writes, before and after are
+// the record's previous and new state (nil for create and delete respectively).
+func recordAudit(db *gorm.DB, c *fiber.Ctx, action, entityType string, entityID, patientID uint, before, after interface{}) {
+	entry := newAuditEntry(c, action, entityType, entityID, patientID)
+	if action == models.AuditActionCreate || action == models.AuditActionUpdate || action == models.AuditActionDelete {
+		entry.Changes = models.ComputeChanges(before, after)
+	}
+	writeAudit(db, []models.AuditLog{entry})
+}
+
+// auditPatients logs access to each patient in a result set
+func auditPatients(db *gorm.DB, c *fiber.Ctx, action string, patients []models.Patient) {
+	entries := make([]models.AuditLog, 0, len(patients))
+	for _, p := range patients {
+		entries = append(entries, newAuditEntry(c, action, models.AuditEntityPatient, p.ID, p.ID))
+	}
+	writeAudit(db, entries)
+}
+
+// auditVisits logs access to each visit in a result set
+func auditVisits(db *gorm.DB, c *fiber.Ctx, action string, visits []models.Visit) {
+	entries := make([]models.AuditLog, 0, len(visits))
+	for _, v := range visits {
+		entries = append(entries, newAuditEntry(c, action, models.AuditEntityVisit, v.ID, v.PatientID))
+	}
+	writeAudit(db, entries)
+}
+
+// auditDiagnoses logs access to each diagnosis in a result set
+func auditDiagnoses(db *gorm.DB, c *fiber.Ctx, action string, diagnoses []models.Diagnosis) {
+	var missing []uint
+	for _, d := range diagnoses {
+		if d.Visit == nil || d.Visit.PatientID == 0 {
+			missing = append(missing, d.VisitID)
+		}
+	}
+	patientIDs := visitPatientIDs(db, missing)
+
+	entries := make([]models.AuditLog, 0, len(diagnoses))
+	for _, d := range diagnoses {
+		patientID := patientIDs[d.VisitID]
+		if d.Visit != nil && d.Visit.PatientID != 0 {
+			patientID = d.Visit.PatientID
+		}
+		entries = append(entries, newAuditEntry(c, action, models.AuditEntityDiagnosis, d.ID, patientID))
+	}
+	writeAudit(db, entries)
+}
+
+// auditPrescriptions logs access to each prescription in a result set
+func auditPrescriptions(db *gorm.DB, c *fiber.Ctx, action string, prescriptions []models.Prescription) {
+	var missing []uint
+	for _, p := range prescriptions {
+		if p.Visit == nil || p.Visit.PatientID == 0 {
+			missing = append(missing, p.VisitID)
+		}
+	}
+	patientIDs := visitPatientIDs(db, missing)
+
+	entries := make([]models.AuditLog, 0, len(prescriptions))
+	for _, p := range prescriptions {
+		patientID := patientIDs[p.VisitID]
+		if p.Visit != nil && p.Visit.PatientID != 0 {
+			patientID = p.Visit.PatientID
+		}
+		entries = append(entries, newAuditEntry(c, action, models.AuditEntityPrescription, p.ID, patientID))
+	}
+	writeAudit(db, entries)
+}
+
+// auditVitalSigns logs access to each vital signs reading in a result set
+func auditVitalSigns(db *gorm.DB, c *fiber.Ctx, action string, readings []models.VitalSigns) {
+	entries := make([]models.AuditLog, 0, len(readings))
+	for _, v := range readings {
+		entries = append(entries, newAuditEntry(c, action, models.AuditEntityVitalSigns, v.ID, v.PatientID))
+	}
+	writeAudit(db, entries)
+}
+
+// visitPatientID returns the patient of a visit, loading it when not preloaded
+func visitPatientID(db *gorm.DB, visit *models.Visit, visitID uint) uint {
+	if visit != nil && visit.PatientID != 0 {
+		return visit.PatientID
+	}
+	var patientID uint
+	db.Model(&models.Visit{}).Where("id = ?", visitID).Select("patient_id").Scan(&patientID)
+	return patientID
+}
+
+// visitPatientIDs maps each of the given visits to its patient in one query
+func visitPatientIDs(db *gorm.DB, visitIDs []uint) map[uint]uint {
+	patientIDs := make(map[uint]uint, len(visitIDs))
+	if len(visitIDs) == 0 {
+		return patientIDs
+	}
+	var rows []struct {
+		ID        uint
+		PatientID uint
+	}
+	db.Model(&models.Visit{}).Where("id IN ?", visitIDs).Select("id, patient_id").Scan(&rows)
+	for _, row := range rows {
+		patientIDs[row.ID] = row.PatientID
+	}
+	return patientIDs
+}
+
+type AuditHandler struct {
+	db *gorm.DB
+}
+
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{db: db}
+}
+
+// filteredAuditQuery applies the common audit log filters from the query string
+func (h *AuditHandler) filteredAuditQuery(c *fiber.Ctx) (*gorm.DB, error) {
+	query := h.db.Model(&models.AuditLog{})
+
+	if patientID := c.Query("patient_id"); patientID != "" {
+		query = query.Where("audit_logs.patient_id = ?", patientID)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("audit_logs.user_id = ?", userID)
+	}
+	if clinicID := c.Query("clinic_id"); clinicID != "" {
+		query = query.Where("audit_logs.clinic_id = ?", clinicID)
+	}
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("audit_logs.entity_type = ?", entityType)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("audit_logs.action = ?", action)
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		from, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("audit_logs.created_at >= ?", from)
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		to, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("audit_logs.created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	return query, nil
+}
+
+func (h *AuditHandler) paginatedAuditLogs(c *fiber.Ctx, query *gorm.DB) (models.PaginationResponse, error) {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.AuditLog
+	if err := query.Order("audit_logs.created_at DESC, audit_logs.id DESC").
+		Offset((page - 1) * perPage).Limit(perPage).Find(&logs).Error; err != nil {
+		return models.PaginationResponse{}, err
+	}
+
+	return models.PaginationResponse{
+		Data:       logs,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	}, nil
+}
+
+// GetAuditLogs - GET /audit-logs (admin) with filters patient_id, user_id,
+// clinic_id, entity_type, action, date_from and date_to
+func (h *AuditHandler) GetAuditLogs(c *fiber.Ctx) error {
+	query, err := h.filteredAuditQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid date format",
+			Details: "Use YYYY-MM-DD",
+		})
+	}
+
+	response, err := h.paginatedAuditLogs(c, query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch audit logs",
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// GetClinicAuditLogs returns audit entries made by the clinic's users or
+// touching the clinic's patients
+func (h *AuditHandler) GetClinicAuditLogs(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	query, err := h.filteredAuditQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+	query = query.Where("audit_logs.clinic_id = ? OR audit_logs.patient_id IN (?)",
+		clinicID, h.db.Model(&models.Patient{}).Select("id").Where("clinic_id = ?", clinicID))
+
+	response, err := h.paginatedAuditLogs(c, query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch audit logs",
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// GetMyAccessLog shows a patient who else has viewed or changed their record
+func (h *AuditHandler) GetMyAccessLog(c *fiber.Ctx) error {
+	patientID := c.Locals("patient_id").(uint)
+	userID := c.Locals("user_id").(uint)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := h.db.Table("audit_logs").
+		Joins("LEFT JOIN staffs ON staffs.user_id = audit_logs.user_id AND staffs.deleted_at IS NULL").
+		Joins("LEFT JOIN clinics ON clinics.id = audit_logs.clinic_id").
+		Where("audit_logs.patient_id = ?", patientID).
+		Where("audit_logs.user_id IS NULL OR audit_logs.user_id <> ?", userID)
+
+	var total int64
+	query.Count(&total)
+
+	var entries []models.AccessLogEntry
+	if err := query.Select(`audit_logs.created_at AS accessed_at, audit_logs.action, audit_logs.entity_type,
+			audit_logs.entity_id, audit_logs.user_type,
+			COALESCE(staffs.full_name, clinics.name, audit_logs.user_type) AS accessed_by,
+			COALESCE(clinics.name, '') AS clinic_name`).
+		Order("audit_logs.created_at DESC, audit_logs.id DESC").
+		Offset((page - 1) * perPage).Limit(perPage).
+		Scan(&entries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch access log",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       entries,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"fmt"
 	"rural_health_management_system/internal/models"
+	"rural_health_management_system/internal/notify"
+	"rural_health_management_system/internal/permissions"
 	"strconv"
 	"strings"
 	"time"
@@ -14,17 +16,45 @@ import (
 )
 
 type AuthHandler struct {
-	db     *gorm.DB
-	jwtKey []byte
+	db              *gorm.DB
+	jwtKey          []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	mfaChallengeTTL time.Duration
+	mfaIssuer       string
+	lockout         models.LockoutPolicy
+	notifier        notify.Notifier
+	permissions     *permissions.Resolver
 }
 
-func NewAuthHandler(db *gorm.DB, jwtKey string) *AuthHandler {
+func NewAuthHandler(db *gorm.DB, jwtKey string, accessTokenTTL, refreshTokenTTL, mfaChallengeTTL time.Duration, mfaIssuer string, lockout models.LockoutPolicy, notifier notify.Notifier, resolver *permissions.Resolver) *AuthHandler {
 	return &AuthHandler{
-		db:     db,
-		jwtKey: []byte(jwtKey),
+		db:              db,
+		jwtKey:          []byte(jwtKey),
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		mfaChallengeTTL: mfaChallengeTTL,
+		mfaIssuer:       mfaIssuer,
+		lockout:         lockout,
+		notifier:        notifier,
+		permissions:     resolver,
 	}
 }
 
+// tokenSubject holds the profile identifiers embedded in an access token
+type tokenSubject struct {
+	PatientID *uint
+	ClinicID  *uint
+	StaffID   *uint
+	StaffRole *string
+	Profile   interface{}
+
+	// MFARequired is set when the user's clinic enforces MFA, and
+	// MFAEnrollmentRequired when it does but the user has not enrolled yet
+	MFARequired           bool
+	MFAEnrollmentRequired bool
+}
+
 // RegisterPatient registers a new patient with authentication
 func (h *AuthHandler) RegisterPatient(c *fiber.Ctx) error {
 	var req models.RegisterPatientRequest
@@ -108,7 +138,7 @@ func (h *AuthHandler) RegisterPatient(c *fiber.Ctx) error {
 	tx.Commit()
 
 	// Generate JWT token
-	token, err := h.generateToken(user.ID, user.Email, user.UserType, &patient.ID, nil, nil, nil)
+	token, refreshToken, err := h.issueTokens(c, &user, tokenSubject{PatientID: &patient.ID})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate token",
@@ -119,9 +149,11 @@ func (h *AuthHandler) RegisterPatient(c *fiber.Ctx) error {
 	h.db.Preload("Clinic").First(&patient, patient.ID)
 
 	return c.Status(fiber.StatusCreated).JSON(models.LoginResponse{
-		Token:    token,
-		UserType: "patient",
-		User:     patient,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserType:     "patient",
+		User:         patient,
 	})
 }
 
@@ -190,7 +222,7 @@ func (h *AuthHandler) RegisterClinic(c *fiber.Ctx) error {
 	tx.Commit()
 
 	// Generate JWT token
-	token, err := h.generateToken(user.ID, user.Email, user.UserType, nil, &clinic.ID, nil, nil)
+	token, refreshToken, err := h.issueTokens(c, &user, tokenSubject{ClinicID: &clinic.ID})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate token",
@@ -198,9 +230,11 @@ func (h *AuthHandler) RegisterClinic(c *fiber.Ctx) error {
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(models.LoginResponse{
-		Token:    token,
-		UserType: "clinic_staff",
-		User:     clinic,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserType:     "clinic_staff",
+		User:         clinic,
 	})
 }
 
@@ -216,59 +250,25 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	// Find user
 	var user models.User
 	if err := h.db.Where("email = ? AND is_active = true", req.Email).First(&user).Error; err != nil {
+		recordAuthEvent(h.db, c, nil, req.Email, models.AuthEventLoginFailed, "unknown account")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid credentials",
 		})
 	}
+	if user.IsLocked(time.Now()) {
+		return h.lockedResponse(c, &user)
+	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.recordFailedLogin(c, &user, models.AuthEventLoginFailed)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid credentials",
 		})
 	}
 
-	var patientID, clinicID, staffID *uint
-	var staffRole *string
-	var userProfile interface{}
-
-	// Load user profile based on type
-	switch user.UserType {
-	case "patient":
-		var patient models.Patient
-		if err := h.db.Preload("Clinic").Where("user_id = ?", user.ID).First(&patient).Error; err == nil {
-			patientID = &patient.ID
-			userProfile = patient
-		}
-	case "clinic_staff":
-		var clinic models.Clinic
-		if err := h.db.Where("user_id = ?", user.ID).First(&clinic).Error; err == nil {
-			clinicID = &clinic.ID
-			userProfile = clinic
-		}
-	case "doctor", "nurse":
-		var staff models.Staff
-		if err := h.db.Preload("Clinic").Where("user_id = ?", user.ID).First(&staff).Error; err == nil {
-			staffID = &staff.ID
-			staffRole = &staff.Role
-			clinicID = &staff.ClinicID
-			userProfile = staff
-		}
-	}
-
-	// Generate JWT token
-	token, err := h.generateToken(user.ID, user.Email, user.UserType, patientID, clinicID, staffID, staffRole)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate token",
-		})
-	}
-
-	return c.JSON(models.LoginResponse{
-		Token:    token,
-		UserType: user.UserType,
-		User:     userProfile,
-	})
+	// Users with MFA get a challenge to answer instead of tokens
+	return h.completeLogin(c, &user)
 }
 
 // ChangePassword allows users to change their password
@@ -305,15 +305,21 @@ func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update password
-	if err := h.db.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	// Update password and end every existing session in the same transaction
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return RevokeUserSessions(tx, user.ID)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update password",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Password updated successfully",
+		"message": "Password updated successfully. Please log in again.",
 	})
 }
 
@@ -332,7 +338,7 @@ func (h *AuthHandler) GetProfile(c *fiber.Ctx) error {
 		}
 		return c.JSON(patient)
 
-	case "clinic_admin":
+	case "clinic_staff":
 		var clinic models.Clinic
 		if err := h.db.Where("user_id = ?", userID).First(&clinic).Error; err != nil {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -341,7 +347,7 @@ func (h *AuthHandler) GetProfile(c *fiber.Ctx) error {
 		}
 		return c.JSON(clinic)
 
-	case "doctor", "nurse":
+	case "doctor", "nurse", "pharmacist", "clinic_admin":
 		var staff models.Staff
 		if err := h.db.Preload("Clinic").Where("user_id = ?", userID).First(&staff).Error; err != nil {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -412,10 +418,10 @@ func (h *AuthHandler) RegisterStaff(c *fiber.Ctx) error {
 		userType = "doctor"
 	case "Nurse":
 		userType = "nurse"
-	case "Clinic_Administrator", "Pharmacist":
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Clinic_Administrator and Pharmacist roles cannot have login accounts. Only Doctor and Nurse can login.",
-		})
+	case "Pharmacist":
+		userType = "pharmacist"
+	case "Clinic_Administrator":
+		userType = "clinic_admin"
 	default:
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid role",
@@ -463,8 +469,8 @@ func (h *AuthHandler) RegisterStaff(c *fiber.Ctx) error {
 
 	tx.Commit()
 
-	// Generate JWT token for the new staff member
-	token, err := h.generateToken(user.ID, user.Email, user.UserType, nil, &staff.ClinicID, &staff.ID, &staff.Role)
+	// Generate JWT token for the new staff member, held to enrollment if the clinic requires MFA
+	token, refreshToken, err := h.issueTokens(c, &user, h.loadTokenSubject(&user))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate token",
@@ -475,9 +481,11 @@ func (h *AuthHandler) RegisterStaff(c *fiber.Ctx) error {
 	h.db.Preload("Clinic").First(&staff, staff.ID)
 
 	return c.Status(fiber.StatusCreated).JSON(models.LoginResponse{
-		Token:    token,
-		UserType: userType,
-		User:     staff,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserType:     userType,
+		User:         staff,
 	})
 }
 
@@ -493,13 +501,18 @@ func (h *AuthHandler) ClinicLogin(c *fiber.Ctx) error {
 	// Find user
 	var user models.User
 	if err := h.db.Where("email = ? AND is_active = true", req.Email).First(&user).Error; err != nil {
+		recordAuthEvent(h.db, c, nil, req.Email, models.AuthEventLoginFailed, "unknown account")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid credentials",
 		})
 	}
+	if user.IsLocked(time.Now()) {
+		return h.lockedResponse(c, &user)
+	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.recordFailedLogin(c, &user, models.AuthEventLoginFailed)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid credentials",
 		})
@@ -508,7 +521,7 @@ func (h *AuthHandler) ClinicLogin(c *fiber.Ctx) error {
 	// Validate login type against user type
 	switch req.LoginType {
 	case models.ClinicLoginStaff:
-		if user.UserType != "clinic_staff" {
+		if user.UserType != "clinic_staff" && user.UserType != "clinic_admin" {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error": "Invalid login type for this user",
 			})
@@ -519,63 +532,219 @@ func (h *AuthHandler) ClinicLogin(c *fiber.Ctx) error {
 				"error": "Invalid login type for this user",
 			})
 		}
+	case models.ClinicLoginPharmacy:
+		if user.UserType != "pharmacist" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Invalid login type for this user",
+			})
+		}
 	default:
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid login type",
 		})
 	}
 
-	var patientID, clinicID, staffID *uint
-	var staffRole *string
-	var userProfile interface{}
+	// Users with MFA get a challenge to answer instead of tokens
+	return h.completeLogin(c, &user)
+}
+
+// RefreshToken exchanges a refresh token for a new access token, rotating the refresh token
+func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tokenHash := hashToken(req.RefreshToken)
+
+	var session models.AuthSession
+	if err := h.db.Where("refresh_token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		// A rotated-out token being presented again means it was copied; kill that session
+		var reused models.AuthSession
+		if err := h.db.Where("previous_token_hash = ?", tokenHash).First(&reused).Error; err == nil {
+			revokeSession(h.db, reused.ID)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid refresh token",
+		})
+	}
+
+	now := time.Now()
+	if !session.IsValid(now) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Refresh token expired or revoked",
+		})
+	}
+
+	// Deactivated users cannot refresh
+	var user models.User
+	if err := h.db.Where("id = ? AND is_active = true", session.UserID).First(&user).Error; err != nil {
+		revokeSession(h.db, session.ID)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid refresh token",
+		})
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	// Rotate the refresh token; the hash condition guards against concurrent use of the same token
+	result := h.db.Model(&models.AuthSession{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, tokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  hashToken(newRefreshToken),
+			"previous_token_hash": tokenHash,
+			"last_used_at":        now,
+			"expires_at":          now.Add(h.refreshTokenTTL),
+		})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to refresh session",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid refresh token",
+		})
+	}
+
+	subject := h.loadTokenSubject(&user)
+	token, err := h.generateToken(session.ID, &user, subject)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	return c.JSON(models.LoginResponse{
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserType:     user.UserType,
+		User:         subject.Profile,
+	})
+}
+
+// Logout revokes the current session, or all of the user's sessions when requested
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	sessionID := c.Locals("session_id").(uint)
+
+	var req models.LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	var err error
+	if req.AllSessions {
+		err = RevokeUserSessions(h.db, userID)
+	} else {
+		err = revokeSession(h.db, sessionID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to log out",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Logged out successfully",
+	})
+}
+
+// loadTokenSubject loads the profile linked to a user and the identifiers embedded in its tokens
+func (h *AuthHandler) loadTokenSubject(user *models.User) tokenSubject {
+	var subject tokenSubject
 
-	// Load user profile based on type
 	switch user.UserType {
+	case "patient":
+		var patient models.Patient
+		if err := h.db.Preload("Clinic").Where("user_id = ?", user.ID).First(&patient).Error; err == nil {
+			subject.PatientID = &patient.ID
+			subject.Profile = patient
+		}
 	case "clinic_staff":
 		var clinic models.Clinic
 		if err := h.db.Where("user_id = ?", user.ID).First(&clinic).Error; err == nil {
-			clinicID = &clinic.ID
-			userProfile = clinic
+			subject.ClinicID = &clinic.ID
+			subject.Profile = clinic
+			subject.MFARequired = clinic.RequireMFA
 		}
-	case "doctor", "nurse":
+	case "doctor", "nurse", "pharmacist", "clinic_admin":
 		var staff models.Staff
 		if err := h.db.Preload("Clinic").Where("user_id = ?", user.ID).First(&staff).Error; err == nil {
-			staffID = &staff.ID
-			staffRole = &staff.Role
-			clinicID = &staff.ClinicID
-			userProfile = staff
+			subject.StaffID = &staff.ID
+			subject.StaffRole = &staff.Role
+			subject.ClinicID = &staff.ClinicID
+			subject.Profile = staff
+			subject.MFARequired = staff.Clinic != nil && staff.Clinic.RequireMFA
 		}
 	}
 
-	// Generate JWT token
-	token, err := h.generateToken(user.ID, user.Email, user.UserType, patientID, clinicID, staffID, staffRole)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate token",
-		})
+	if subject.MFARequired {
+		var enrolled int64
+		h.db.Model(&models.UserMFA{}).Where("user_id = ? AND enabled_at IS NOT NULL", user.ID).Count(&enrolled)
+		subject.MFAEnrollmentRequired = enrolled == 0
 	}
 
-	return c.JSON(models.LoginResponse{
-		Token:    token,
-		UserType: user.UserType,
-		User:     userProfile,
-	})
+	return subject
 }
 
-// generateToken creates a JWT token
-func (h *AuthHandler) generateToken(userID uint, email, userType string, patientID, clinicID, staffID *uint, staffRole *string) (string, error) {
-	claims := models.JWTClaims{
-		UserID:    userID,
-		Email:     email,
-		UserType:  userType,
-		PatientID: patientID,
-		ClinicID:  clinicID,
-		StaffID:   staffID,
-		StaffRole: staffRole,
-		Exp:       time.Now().Add(24 * time.Hour).Unix(),
+// issueTokens starts a new session for the user and returns its access and refresh tokens
+func (h *AuthHandler) issueTokens(c *fiber.Ctx, user *models.User, subject tokenSubject) (string, string, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return "", "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	now := time.Now()
+	session := models.AuthSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        now.Add(h.refreshTokenTTL),
+		LastUsedAt:       now,
+		IPAddress:        c.IP(),
+		UserAgent:        truncate(c.Get("User-Agent"), 255),
+	}
+	if err := h.db.Create(&session).Error; err != nil {
+		return "", "", err
+	}
+
+	token, err := h.generateToken(session.ID, user, subject)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// generateToken creates a short-lived JWT access token bound to a session
+func (h *AuthHandler) generateToken(sessionID uint, user *models.User, subject tokenSubject) (string, error) {
+	claims := models.JWTClaims{
+		SessionID:             sessionID,
+		UserID:                user.ID,
+		Email:                 user.Email,
+		UserType:              user.UserType,
+		PatientID:             subject.PatientID,
+		ClinicID:              subject.ClinicID,
+		StaffID:               subject.StaffID,
+		StaffRole:             subject.StaffRole,
+		MFAEnrollmentRequired: subject.MFAEnrollmentRequired,
+		Exp:                   time.Now().Add(h.accessTokenTTL).Unix(),
+	}
+
+	mapClaims := jwt.MapClaims{
+		"sid":        claims.SessionID,
 		"user_id":    claims.UserID,
 		"email":      claims.Email,
 		"user_type":  claims.UserType,
@@ -584,7 +753,12 @@ func (h *AuthHandler) generateToken(userID uint, email, userType string, patient
 		"staff_id":   claims.StaffID,
 		"staff_role": claims.StaffRole,
 		"exp":        claims.Exp,
-	})
+	}
+	if claims.MFAEnrollmentRequired {
+		mapClaims["mfa_enrollment_required"] = true
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
 
 	return token.SignedString(h.jwtKey)
 }
@@ -636,8 +810,39 @@ func (h *AuthHandler) AuthMiddleware(c *fiber.Ctx) error {
 		}
 	}
 
+	sid, hasSID := claims["sid"].(float64)
+	userIDClaim, hasUserID := claims["user_id"].(float64)
+	if !hasSID || !hasUserID {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid token claims",
+		})
+	}
+
+	// Reject tokens whose session was revoked or whose user has been deactivated
+	var activeSessions int64
+	h.db.Model(&models.AuthSession{}).
+		Joins("JOIN users ON users.id = auth_sessions.user_id").
+		Where("auth_sessions.id = ? AND auth_sessions.user_id = ? AND auth_sessions.revoked_at IS NULL AND auth_sessions.expires_at > ?", uint(sid), uint(userIDClaim), time.Now()).
+		Where("users.is_active = ? AND users.deleted_at IS NULL", true).
+		Count(&activeSessions)
+	if activeSessions == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Session has been revoked",
+		})
+	}
+
+	// Users whose clinic requires MFA may only use the auth endpoints, to
+	// enroll, until they have set it up
+	if required, _ := claims["mfa_enrollment_required"].(bool); required && !strings.HasPrefix(c.Path(), "/api/v1/auth/") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":                   "Your clinic requires multi-factor authentication. Enroll at /api/v1/auth/mfa/enroll.",
+			"mfa_enrollment_required": true,
+		})
+	}
+
 	// Store user information in context
-	c.Locals("user_id", uint(claims["user_id"].(float64)))
+	c.Locals("session_id", uint(sid))
+	c.Locals("user_id", uint(userIDClaim))
 	c.Locals("email", claims["email"].(string))
 	c.Locals("user_type", claims["user_type"].(string))
 
@@ -738,8 +943,8 @@ func (h *AuthHandler) RequireClinicAccess() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userType := c.Locals("user_type").(string)
 
-		// Allow clinic staff, doctors, and nurses
-		allowedTypes := []string{"clinic_staff", "doctor", "nurse"}
+		// Allow clinic owners, clinic administrators, doctors, nurses and pharmacists
+		allowedTypes := []string{"clinic_staff", "clinic_admin", "doctor", "nurse", "pharmacist"}
 		for _, allowedType := range allowedTypes {
 			if userType == allowedType {
 				return c.Next()
@@ -796,7 +1001,7 @@ func (h *AuthHandler) ValidateClinicOwnership() fiber.Handler {
 
 		// For clinic-related operations, ensure user belongs to the clinic
 		switch userType {
-		case "clinic_staff", "doctor", "nurse":
+		case "clinic_staff", "clinic_admin", "doctor", "nurse", "pharmacist":
 			// These user types should have clinic_id in their context
 			c.Locals("validated_clinic_id", userClinicID)
 			return c.Next()
@@ -812,13 +1017,13 @@ func (h *AuthHandler) ValidateClinicOwnership() fiber.Handler {
 func (h *AuthHandler) RequirePermission(permission models.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userType := c.Locals("user_type").(string)
-		var staffRole *string
+		var clinicID *uint
 
-		if role, hasRole := c.Locals("staff_role").(string); hasRole {
-			staffRole = &role
+		if id, hasClinic := c.Locals("clinic_id").(uint); hasClinic {
+			clinicID = &id
 		}
 
-		if !models.HasPermission(userType, staffRole, permission) {
+		if !h.permissions.HasPermission(userType, clinicID, permission) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error": "Insufficient permissions for this action",
 			})
@@ -832,14 +1037,14 @@ func (h *AuthHandler) RequirePermission(permission models.Permission) fiber.Hand
 func (h *AuthHandler) RequireMultiplePermissions(permissions ...models.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userType := c.Locals("user_type").(string)
-		var staffRole *string
+		var clinicID *uint
 
-		if role, hasRole := c.Locals("staff_role").(string); hasRole {
-			staffRole = &role
+		if id, hasClinic := c.Locals("clinic_id").(uint); hasClinic {
+			clinicID = &id
 		}
 
 		for _, permission := range permissions {
-			if models.HasPermission(userType, staffRole, permission) {
+			if h.permissions.HasPermission(userType, clinicID, permission) {
 				return c.Next()
 			}
 		}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+	"rural_health_management_system/internal/notify"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var errClaimCodeUsed = errors.New("claim code already used")
+
+type ClaimHandler struct {
+	db       *gorm.DB
+	auth     *AuthHandler
+	notifier notify.Notifier
+	codeTTL  time.Duration
+}
+
+func NewClaimHandler(db *gorm.DB, auth *AuthHandler, notifier notify.Notifier, codeTTL time.Duration) *ClaimHandler {
+	return &ClaimHandler{
+		db:       db,
+		auth:     auth,
+		notifier: notifier,
+		codeTTL:  codeTTL,
+	}
+}
+
+// IssueClaimCode creates a one-time code the patient can use to set up a
+// portal login for their existing record. Any earlier unused code stops working.
+func (h *ClaimHandler) IssueClaimCode(c *fiber.Ctx) error {
+	query := h.db.Where("id = ?", c.Params("id"))
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var patient models.Patient
+	if err := query.First(&patient).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+	if patient.UserID != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Patient already has a portal account",
+		})
+	}
+
+	var req models.IssueClaimCodeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+	if req.Channel == "" {
+		req.Channel = "print"
+	}
+	if req.Channel != "print" && req.Channel != string(notify.ChannelSMS) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "channel must be print or sms",
+		})
+	}
+
+	code, err := models.GenerateClaimCode()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate claim code",
+		})
+	}
+
+	now := time.Now()
+	claim := models.PatientClaimCode{
+		PatientID:      patient.ID,
+		CodeHash:       hashToken(code),
+		ExpiresAt:      now.Add(h.codeTTL),
+		IssuedByUserID: currentUserID(c),
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Only the most recently issued code stays usable
+		if err := tx.Model(&models.PatientClaimCode{}).
+			Where("patient_id = ? AND used_at IS NULL", patient.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&claim).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create claim code",
+		})
+	}
+
+	if req.Channel == string(notify.ChannelSMS) {
+		msg := notify.Message{
+			Channel: notify.ChannelSMS,
+			To:      patient.Phone,
+			Subject: "Patient portal access",
+			Body: fmt.Sprintf("Your code to set up patient portal access is %s. It expires in %d hours. You will also need your date of birth.",
+				code, int(h.codeTTL.Hours())),
+		}
+		if err := h.notifier.Send(msg); err != nil {
+			log.Printf("Failed to send claim code to patient %d: %v", patient.ID, err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"patient_id": patient.ID,
+		"code":       code,
+		"channel":    req.Channel,
+		"expires_at": claim.ExpiresAt,
+	})
+}
+
+// ClaimAccount creates a patient login and links it to the existing record
+// the claim code was issued for, once the date of birth matches the record
+func (h *ClaimHandler) ClaimAccount(c *fiber.Ctx) error {
+	var req models.ClaimAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Code == "" || !strings.Contains(req.Email, "@") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code and a valid email are required",
+		})
+	}
+	if len(req.Password) < 8 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Password must be at least 8 characters",
+		})
+	}
+	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	// The same answer for an unknown code and a wrong date of birth, so neither can be probed
+	invalid := fiber.Map{
+		"error": "Invalid or expired claim code, or the date of birth does not match",
+	}
+
+	now := time.Now()
+	var claim models.PatientClaimCode
+	if err := h.db.Where("code_hash = ? AND used_at IS NULL AND expires_at > ? AND failed_attempts < ?",
+		hashToken(models.NormalizeClaimCode(req.Code)), now, models.MaxClaimAttempts).
+		First(&claim).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(invalid)
+	}
+
+	var patient models.Patient
+	if err := h.db.First(&patient, claim.PatientID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(invalid)
+	}
+	if patient.DateOfBirth.Format("2006-01-02") != dob.Format("2006-01-02") {
+		h.db.Model(&models.PatientClaimCode{}).Where("id = ?", claim.ID).
+			Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+		return c.Status(fiber.StatusBadRequest).JSON(invalid)
+	}
+	if patient.UserID != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This record already has a portal account",
+		})
+	}
+
+	var existingUser models.User
+	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Email already registered",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to hash password",
+		})
+	}
+
+	before := patient
+	user := models.User{
+		Email:    req.Email,
+		Password: string(hashedPassword),
+		UserType: "patient",
+		IsActive: true,
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Consume the code first; the used_at condition makes it single-use under concurrency
+		result := tx.Model(&models.PatientClaimCode{}).
+			Where("id = ? AND used_at IS NULL", claim.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClaimCodeUsed
+		}
+
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		result = tx.Model(&models.Patient{}).
+			Where("id = ? AND user_id IS NULL", patient.ID).
+			Update("user_id", user.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClaimCodeUsed
+		}
+		return nil
+	})
+	if err == errClaimCodeUsed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This record has already been claimed",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create account",
+		})
+	}
+
+	patient.UserID = &user.ID
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPatient, patient.ID, patient.ID, &before, &patient)
+
+	token, refreshToken, err := h.auth.issueTokens(c, &user, tokenSubject{PatientID: &patient.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	h.db.Preload("Clinic").First(&patient, patient.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.auth.accessTokenTTL.Seconds()),
+		UserType:     "patient",
+		User:         patient,
+	})
+}
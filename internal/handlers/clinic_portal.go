@@ -3,6 +3,7 @@ package handlers
 import (
 	"rural_health_management_system/internal/models"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -90,7 +91,7 @@ func (h *ClinicPortalHandler) GetMyPatients(c *fiber.Ctx) error {
 	query := h.db.Model(&models.Patient{}).Where("clinic_id = ?", clinicID)
 
 	if search != "" {
-		query = query.Where("full_name ILIKE ? OR phone ILIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Scopes(patientSearch(search))
 	}
 
 	query.Count(&total)
@@ -106,6 +107,8 @@ func (h *ClinicPortalHandler) GetMyPatients(c *fiber.Ctx) error {
 		totalPages++
 	}
 
+	auditPatients(h.db, c, models.AuditActionList, patients)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       patients,
 		Page:       page,
@@ -126,13 +129,18 @@ func (h *ClinicPortalHandler) GetMyPatient(c *fiber.Ctx) error {
 	}
 
 	var patient models.Patient
-	if err := h.db.Preload("Visits").Preload("Visits.Diagnoses").Preload("Visits.Prescriptions").
+	if err := h.db.Preload("Visits").Preload("Visits.Clinic").Preload("Visits.Diagnoses").Preload("Visits.Prescriptions").
 		Where("id = ? AND clinic_id = ?", patientID, clinicID).First(&patient).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Patient not found",
 		})
 	}
 
+	// Visits from clinics the patient was transferred from are read-only here
+	models.MarkReadOnlyVisits(patient.Visits, clinicID)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
 	return c.JSON(patient)
 }
 
@@ -229,7 +237,7 @@ func (h *ClinicPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 	var visits []models.Visit
 	var total int64
 
-	query := h.db.Model(&models.Visit{}).Where("clinic_id = ?", clinicID)
+	query := h.db.Model(&models.Visit{}).Scopes(clinicVisits(clinicID))
 
 	if patientIDStr != "" {
 		if patientID, err := strconv.ParseUint(patientIDStr, 10, 32); err == nil {
@@ -239,18 +247,21 @@ func (h *ClinicPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 
 	query.Count(&total)
 
-	if err := query.Preload("Patient").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").
+	if err := query.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").
 		Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch visits",
 		})
 	}
+	models.MarkReadOnlyVisits(visits, clinicID)
 
 	totalPages := int(total) / perPage
 	if int(total)%perPage != 0 {
 		totalPages++
 	}
 
+	auditVisits(h.db, c, models.AuditActionList, visits)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       visits,
 		Page:       page,
@@ -307,6 +318,8 @@ func (h *ClinicPortalHandler) CreateVisit(c *fiber.Ctx) error {
 
 	// Load with relationships
 	h.db.Preload("Patient").Preload("Clinic").Preload("Staff").First(&visit, visit.ID)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, &visit)
+
 	return c.Status(fiber.StatusCreated).JSON(visit)
 }
 
@@ -321,12 +334,15 @@ func (h *ClinicPortalHandler) GetMyVisit(c *fiber.Ctx) error {
 	}
 
 	var visit models.Visit
-	if err := h.db.Preload("Patient").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").
-		Where("id = ? AND clinic_id = ?", visitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Preload("VitalSigns").
+		Scopes(clinicVisits(clinicID)).Where("id = ?", visitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Visit not found",
 		})
 	}
+	visit.ReadOnly = models.VisitReadOnly(visit, clinicID)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, nil)
 
 	return c.JSON(visit)
 }
@@ -344,15 +360,26 @@ func (h *ClinicPortalHandler) CreateDiagnosis(c *fiber.Ctx) error {
 
 	// Verify visit belongs to this clinic
 	var visit models.Visit
-	if err := h.db.Where("id = ? AND clinic_id = ?", req.VisitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", req.VisitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Visit not found or doesn't belong to this clinic",
 		})
 	}
 
+	code, err := lookupICD10Code(h.db, req.DiagnosisCode)
+	if err != nil {
+		status, message := diagnosisCodeError(err)
+		return c.Status(status).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		req.Description = code.Description
+	}
+
 	diagnosis := models.Diagnosis{
 		VisitID:       req.VisitID,
-		DiagnosisCode: req.DiagnosisCode,
+		DiagnosisCode: code.Code,
 		Description:   req.Description,
 	}
 
@@ -364,6 +391,8 @@ func (h *ClinicPortalHandler) CreateDiagnosis(c *fiber.Ctx) error {
 
 	// Load with visit relationship
 	h.db.Preload("Visit").First(&diagnosis, diagnosis.ID)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityDiagnosis, diagnosis.ID, visit.PatientID, nil, &diagnosis)
+
 	return c.Status(fiber.StatusCreated).JSON(diagnosis)
 }
 
@@ -380,18 +409,45 @@ func (h *ClinicPortalHandler) CreatePrescription(c *fiber.Ctx) error {
 
 	// Verify visit belongs to this clinic
 	var visit models.Visit
-	if err := h.db.Where("id = ? AND clinic_id = ?", req.VisitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", req.VisitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Visit not found or doesn't belong to this clinic",
 		})
 	}
 
+	// Resolve structured dosing from the formulary
+	if err := applyFormulary(h.db, clinicID, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Check the medication against the patient's recorded allergies
+	allergyWarnings, ok, err := checkPrescriptionAllergies(h.db, c, visit.PatientID, &req)
+	if !ok {
+		return err
+	}
+
+	// Check the medication against the patient's other active prescriptions
+	interactionWarnings, ok, err := checkPrescriptionInteractions(h.db, c, visit.PatientID, 0, &req)
+	if !ok {
+		return err
+	}
+
 	prescription := models.Prescription{
-		VisitID:        req.VisitID,
-		MedicationName: req.MedicationName,
-		Dosage:         req.Dosage,
-		Instructions:   req.Instructions,
-		DurationDays:   req.DurationDays,
+		VisitID:                   req.VisitID,
+		MedicationName:            req.MedicationName,
+		Dosage:                    req.Dosage,
+		Instructions:              req.Instructions,
+		DurationDays:              req.DurationDays,
+		FormularyItemID:           req.FormularyItemID,
+		DoseAmount:                req.DoseAmount,
+		DoseUnit:                  req.DoseUnit,
+		Frequency:                 req.Frequency,
+		Route:                     req.Route,
+		Quantity:                  req.Quantity,
+		AllergyOverrideReason:     req.AllergyOverrideReason,
+		InteractionOverrideReason: req.InteractionOverrideReason,
 	}
 
 	if err := h.db.Create(&prescription).Error; err != nil {
@@ -402,7 +458,13 @@ func (h *ClinicPortalHandler) CreatePrescription(c *fiber.Ctx) error {
 
 	// Load with visit relationship
 	h.db.Preload("Visit").First(&prescription, prescription.ID)
-	return c.Status(fiber.StatusCreated).JSON(prescription)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPrescription, prescription.ID, visit.PatientID, nil, &prescription)
+
+	return c.Status(fiber.StatusCreated).JSON(models.PrescriptionResponse{
+		Prescription:        prescription,
+		AllergyWarnings:     allergyWarnings,
+		InteractionWarnings: interactionWarnings,
+	})
 }
 
 // GetDashboardStats returns dashboard statistics for the clinic
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"math"
 	"rural_health_management_system/internal/models"
 	"time"
 
@@ -26,6 +27,7 @@ type DiagnosisAnalytics struct {
 
 // PrescriptionAnalytics represents prescription statistics
 type PrescriptionAnalytics struct {
+	FormularyItemID *uint        `json:"formulary_item_id,omitempty"`
 	MedicationName  string       `json:"medication_name"`
 	Count           int64        `json:"count"`
 	Percentage      float64      `json:"percentage"`
@@ -41,8 +43,33 @@ type DosageInfo struct {
 
 // DemographicAnalytics represents patient demographics
 type DemographicAnalytics struct {
-	AgeGroups          []AgeGroupInfo `json:"age_groups"`
-	GenderDistribution []GenderInfo   `json:"gender_distribution"`
+	AgeGroups          []AgeGroupInfo        `json:"age_groups"`
+	GenderDistribution []GenderInfo          `json:"gender_distribution"`
+	Malnutrition       MalnutritionAnalytics `json:"malnutrition"`
+}
+
+// MalnutritionAnalytics represents under-5 malnutrition prevalence by clinic and district
+type MalnutritionAnalytics struct {
+	ByClinic   []MalnutritionPrevalence `json:"by_clinic"`
+	ByDistrict []MalnutritionPrevalence `json:"by_district"`
+}
+
+// MalnutritionPrevalence represents how many measured under-5s are malnourished,
+// going by each child's latest measurement in the past year
+type MalnutritionPrevalence struct {
+	ClinicID              uint    `json:"clinic_id,omitempty"`
+	ClinicName            string  `json:"clinic_name,omitempty"`
+	District              string  `json:"district"`
+	ChildrenMeasured      int64   `json:"children_measured"`
+	SAM                   int64   `json:"sam"`
+	MAM                   int64   `json:"mam"`
+	Stunted               int64   `json:"stunted"`
+	Underweight           int64   `json:"underweight"`
+	SAMPercentage         float64 `json:"sam_percentage"`
+	MAMPercentage         float64 `json:"mam_percentage"`
+	GAMPercentage         float64 `json:"gam_percentage"` // Global acute malnutrition, SAM and MAM together
+	StuntedPercentage     float64 `json:"stunted_percentage"`
+	UnderweightPercentage float64 `json:"underweight_percentage"`
 }
 
 // AgeGroupInfo represents age group distribution
@@ -86,6 +113,10 @@ type ComprehensiveDashboard struct {
 	IllnessTrends     []IllnessTrend          `json:"illness_trends"`
 	DistrictAnalytics []DistrictAnalytics     `json:"district_analytics"`
 	SeasonalTrends    []SeasonalTrend         `json:"seasonal_trends"`
+
+	// Diagnoses rolled up by ICD-10 chapter, and the busiest categories
+	DiagnosisChapters   []models.DiagnosisRollup `json:"diagnosis_chapters"`
+	DiagnosisCategories []models.DiagnosisRollup `json:"diagnosis_categories"`
 }
 
 // OverallStats represents overall system statistics
@@ -98,6 +129,13 @@ type OverallStats struct {
 	TotalPrescriptions int64 `json:"total_prescriptions"`
 	VisitsThisMonth    int64 `json:"visits_this_month"`
 	VisitsToday        int64 `json:"visits_today"`
+
+	// Maternal care
+	ActivePregnancies     int64   `json:"active_pregnancies"`
+	HighRiskPregnancies   int64   `json:"high_risk_pregnancies"`
+	ANCOverdue            int64   `json:"anc_overdue"`
+	DeliveriesThisMonth   int64   `json:"deliveries_this_month"`
+	FacilityDeliveryShare float64 `json:"facility_delivery_share"` // Share of this month's deliveries at a facility, %
 }
 
 // SeasonalTrend represents seasonal illness patterns
@@ -118,6 +156,7 @@ func (h *DashboardAnalyticsHandler) GetSystemDashboard(c *fiber.Ctx) error {
 
 	// Get top diagnoses across all clinics
 	dashboard.TopDiagnoses = h.getTopDiagnoses(nil, 10)
+	dashboard.DiagnosisChapters, dashboard.DiagnosisCategories = h.getDiagnosisRollups(nil, 10)
 
 	// Get top prescriptions across all clinics
 	dashboard.TopPrescriptions = h.getTopPrescriptions(nil, 10)
@@ -148,6 +187,7 @@ func (h *DashboardAnalyticsHandler) GetClinicDashboard(c *fiber.Ctx) error {
 
 	// Get top diagnoses for this clinic
 	dashboard.TopDiagnoses = h.getTopDiagnoses(&clinicID, 10)
+	dashboard.DiagnosisChapters, dashboard.DiagnosisCategories = h.getDiagnosisRollups(&clinicID, 10)
 
 	// Get top prescriptions for this clinic
 	dashboard.TopPrescriptions = h.getTopPrescriptions(&clinicID, 10)
@@ -186,6 +226,8 @@ func (h *DashboardAnalyticsHandler) getOverallStats() OverallStats {
 	tomorrow := today.Add(24 * time.Hour)
 	h.db.Model(&models.Visit{}).Where("visit_date >= ? AND visit_date < ?", today, tomorrow).Count(&stats.VisitsToday)
 
+	h.getMaternalStats(nil, &stats)
+
 	return stats
 }
 
@@ -218,15 +260,50 @@ func (h *DashboardAnalyticsHandler) getClinicStats(clinicID uint) OverallStats {
 	tomorrow := today.Add(24 * time.Hour)
 	h.db.Model(&models.Visit{}).Where("clinic_id = ? AND visit_date >= ? AND visit_date < ?", clinicID, today, tomorrow).Count(&stats.VisitsToday)
 
+	h.getMaternalStats(&clinicID, &stats)
+
 	return stats
 }
 
+// getMaternalStats counts pregnancies of patients currently registered at the
+// clinic, or across all clinics when clinicID is nil
+func (h *DashboardAnalyticsHandler) getMaternalStats(clinicID *uint, stats *OverallStats) {
+	pregnancies := func() *gorm.DB {
+		query := h.db.Table("pregnancies").
+			Joins("JOIN patients ON patients.id = pregnancies.patient_id AND patients.deleted_at IS NULL")
+		if clinicID != nil {
+			query = query.Where("patients.clinic_id = ?", *clinicID)
+		}
+		return query
+	}
+
+	active := models.PregnancyStatusActive
+	pregnancies().Where("pregnancies.status = ?", active).Count(&stats.ActivePregnancies)
+	pregnancies().Where("pregnancies.status = ? AND pregnancies.high_risk = ?", active, true).Count(&stats.HighRiskPregnancies)
+	pregnancies().Where("pregnancies.status = ? AND pregnancies.next_anc_due < ?", active,
+		time.Now().AddDate(0, 0, -models.ANCGraceDays).Format("2006-01-02")).Count(&stats.ANCOverdue)
+
+	now := time.Now()
+	firstDayOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02")
+	pregnancies().Where("pregnancies.status = ? AND pregnancies.outcome_date >= ?", models.PregnancyStatusDelivered, firstDayOfMonth).
+		Count(&stats.DeliveriesThisMonth)
+
+	if stats.DeliveriesThisMonth > 0 {
+		var atFacility int64
+		pregnancies().Where("pregnancies.status = ? AND pregnancies.outcome_date >= ? AND pregnancies.delivery_place = ?",
+			models.PregnancyStatusDelivered, firstDayOfMonth, "facility").Count(&atFacility)
+		stats.FacilityDeliveryShare = math.Round(float64(atFacility)/float64(stats.DeliveriesThisMonth)*1000) / 10
+	}
+}
+
 func (h *DashboardAnalyticsHandler) getTopDiagnoses(clinicID *uint, limit int) []DiagnosisAnalytics {
 	var results []DiagnosisAnalytics
 
+	// Group on the code alone so differing free-text descriptions do not
+	// split one code into several rows; the title comes from the catalog
 	query := h.db.Table("diagnoses").
-		Select("diagnosis_code, description, COUNT(*) as count").
-		Group("diagnosis_code, description").
+		Select("UPPER(TRIM(diagnoses.diagnosis_code)) AS diagnosis_code, MAX(diagnoses.description) AS description, COUNT(*) as count").
+		Group("UPPER(TRIM(diagnoses.diagnosis_code))").
 		Order("count DESC").
 		Limit(limit)
 
@@ -245,9 +322,12 @@ func (h *DashboardAnalyticsHandler) getTopDiagnoses(clinicID *uint, limit int) [
 
 	// Calculate total for percentages
 	var total int64
+	codes := make([]string, 0, len(rawResults))
 	for _, result := range rawResults {
 		total += result.Count
+		codes = append(codes, result.DiagnosisCode)
 	}
+	titles := icd10Titles(h.db, codes)
 
 	// Convert to response format with percentages
 	for _, result := range rawResults {
@@ -256,9 +336,13 @@ func (h *DashboardAnalyticsHandler) getTopDiagnoses(clinicID *uint, limit int) [
 			percentage = float64(result.Count) / float64(total) * 100
 		}
 
+		description := result.Description
+		if title, ok := titles[result.DiagnosisCode]; ok {
+			description = title
+		}
 		results = append(results, DiagnosisAnalytics{
 			DiagnosisCode: result.DiagnosisCode,
-			Description:   result.Description,
+			Description:   description,
 			Count:         result.Count,
 			Percentage:    percentage,
 		})
@@ -267,12 +351,76 @@ func (h *DashboardAnalyticsHandler) getTopDiagnoses(clinicID *uint, limit int) [
 	return results
 }
 
+// getDiagnosisRollups counts diagnoses per ICD-10 category and rolls them up
+// into chapters. Categories are titled from the catalog; codes recorded before
+// validation that fall outside every chapter are counted as unclassified.
+func (h *DashboardAnalyticsHandler) getDiagnosisRollups(clinicID *uint, categoryLimit int) ([]models.DiagnosisRollup, []models.DiagnosisRollup) {
+	query := h.db.Table("diagnoses").
+		Select("UPPER(LEFT(TRIM(diagnoses.diagnosis_code), 3)) AS category, COUNT(*) AS count").
+		Where("diagnoses.deleted_at IS NULL").
+		Group("category").
+		Order("count DESC")
+
+	if clinicID != nil {
+		query = query.Joins("JOIN visits ON diagnoses.visit_id = visits.id").
+			Where("visits.clinic_id = ?", *clinicID)
+	}
+
+	var rawResults []struct {
+		Category string
+		Count    int64
+	}
+	query.Scan(&rawResults)
+
+	counts := make(map[string]int64, len(rawResults))
+	var total int64
+	for _, result := range rawResults {
+		counts[result.Category] += result.Count
+		total += result.Count
+	}
+	chapters := models.RollUpDiagnosisChapters(counts)
+
+	if len(rawResults) > categoryLimit {
+		rawResults = rawResults[:categoryLimit]
+	}
+	categories := make([]string, len(rawResults))
+	for i, result := range rawResults {
+		categories[i] = result.Category
+	}
+	titles := icd10Titles(h.db, categories)
+
+	topCategories := make([]models.DiagnosisRollup, 0, len(rawResults))
+	for _, result := range rawResults {
+		rollup := models.DiagnosisRollup{
+			Code:       result.Category,
+			Title:      titles[result.Category],
+			Count:      result.Count,
+			Percentage: float64(result.Count) / float64(total) * 100,
+		}
+		if ch := models.ICD10ChapterFor(result.Category); ch != nil {
+			rollup.Chapter = ch.Number
+		} else {
+			rollup.Title = models.UnclassifiedDiagnosisTitle
+		}
+		topCategories = append(topCategories, rollup)
+	}
+	return chapters, topCategories
+}
+
+// prescriptionGroupColumns groups prescriptions by formulary item, falling back to
+// the normalised free-text medication name for prescriptions written outside the formulary
+const prescriptionGroupColumns = `prescriptions.formulary_item_id,
+	COALESCE(formulary_items.generic_name || ' ' || formulary_items.strength || ' ' || formulary_items.form,
+		INITCAP(LOWER(TRIM(prescriptions.medication_name)))) AS medication_name`
+
 func (h *DashboardAnalyticsHandler) getTopPrescriptions(clinicID *uint, limit int) []PrescriptionAnalytics {
 	var results []PrescriptionAnalytics
 
 	query := h.db.Table("prescriptions").
-		Select("medication_name, COUNT(*) as count, AVG(duration_days) as avg_duration_days").
-		Group("medication_name").
+		Select(prescriptionGroupColumns + ", COUNT(*) as count, AVG(prescriptions.duration_days) as avg_duration_days").
+		Joins("LEFT JOIN formulary_items ON formulary_items.id = prescriptions.formulary_item_id").
+		Where("prescriptions.deleted_at IS NULL").
+		Group("prescriptions.formulary_item_id, 2").
 		Order("count DESC").
 		Limit(limit)
 
@@ -282,6 +430,7 @@ func (h *DashboardAnalyticsHandler) getTopPrescriptions(clinicID *uint, limit in
 	}
 
 	var rawResults []struct {
+		FormularyItemID *uint   `json:"formulary_item_id"`
 		MedicationName  string  `json:"medication_name"`
 		Count           int64   `json:"count"`
 		AvgDurationDays float64 `json:"avg_duration_days"`
@@ -303,9 +452,10 @@ func (h *DashboardAnalyticsHandler) getTopPrescriptions(clinicID *uint, limit in
 		}
 
 		// Get common dosages for this medication
-		dosages := h.getCommonDosages(result.MedicationName, clinicID)
+		dosages := h.getCommonDosages(result.FormularyItemID, result.MedicationName, clinicID)
 
 		results = append(results, PrescriptionAnalytics{
+			FormularyItemID: result.FormularyItemID,
 			MedicationName:  result.MedicationName,
 			Count:           result.Count,
 			Percentage:      percentage,
@@ -317,16 +467,22 @@ func (h *DashboardAnalyticsHandler) getTopPrescriptions(clinicID *uint, limit in
 	return results
 }
 
-func (h *DashboardAnalyticsHandler) getCommonDosages(medicationName string, clinicID *uint) []DosageInfo {
+func (h *DashboardAnalyticsHandler) getCommonDosages(formularyItemID *uint, medicationName string, clinicID *uint) []DosageInfo {
 	var dosages []DosageInfo
 
 	query := h.db.Table("prescriptions").
-		Select("dosage, COUNT(*) as count").
-		Where("medication_name = ?", medicationName).
-		Group("dosage").
+		Select("prescriptions.dosage, COUNT(*) as count").
+		Where("prescriptions.deleted_at IS NULL").
+		Group("prescriptions.dosage").
 		Order("count DESC").
 		Limit(5)
 
+	if formularyItemID != nil {
+		query = query.Where("prescriptions.formulary_item_id = ?", *formularyItemID)
+	} else {
+		query = query.Where("prescriptions.formulary_item_id IS NULL AND INITCAP(LOWER(TRIM(prescriptions.medication_name))) = ?", medicationName)
+	}
+
 	if clinicID != nil {
 		query = query.Joins("JOIN visits ON prescriptions.visit_id = visits.id").
 			Where("visits.clinic_id = ?", *clinicID)
@@ -345,6 +501,9 @@ func (h *DashboardAnalyticsHandler) getDemographics(clinicID *uint) DemographicA
 	// Gender distribution
 	demographics.GenderDistribution = h.getGenderDistribution(clinicID)
 
+	// Under-5 malnutrition
+	demographics.Malnutrition = h.getMalnutrition(clinicID)
+
 	return demographics
 }
 
@@ -400,6 +559,88 @@ func (h *DashboardAnalyticsHandler) getAgeGroups(clinicID *uint) []AgeGroupInfo
 	return results
 }
 
+func (h *DashboardAnalyticsHandler) getMalnutrition(clinicID *uint) MalnutritionAnalytics {
+	analytics := MalnutritionAnalytics{
+		ByClinic:   []MalnutritionPrevalence{},
+		ByDistrict: []MalnutritionPrevalence{},
+	}
+
+	// Each child counts once, by their latest measurement in the past year,
+	// and only while still under 5
+	now := time.Now()
+	args := []interface{}{now.AddDate(-1, 0, 0).Format("2006-01-02"), now.AddDate(0, 0, -models.GrowthMaxAgeDays).Format("2006-01-02")}
+	clinicFilter := ""
+	if clinicID != nil {
+		clinicFilter = "AND clinics.id = ?"
+		args = append(args, *clinicID)
+	}
+
+	var byClinic []MalnutritionPrevalence
+	h.db.Raw(`
+		WITH latest AS (
+			SELECT DISTINCT ON (patient_id) patient_id, nutrition_status, haz, waz
+			FROM growth_measurements
+			WHERE measured_on >= ?
+			ORDER BY patient_id, measured_on DESC, id DESC
+		)
+		SELECT
+			clinics.id as clinic_id,
+			clinics.name as clinic_name,
+			clinics.district,
+			COUNT(*) as children_measured,
+			SUM(CASE WHEN latest.nutrition_status = 'sam' THEN 1 ELSE 0 END) as sam,
+			SUM(CASE WHEN latest.nutrition_status = 'mam' THEN 1 ELSE 0 END) as mam,
+			SUM(CASE WHEN latest.haz < -2 THEN 1 ELSE 0 END) as stunted,
+			SUM(CASE WHEN latest.waz < -2 THEN 1 ELSE 0 END) as underweight
+		FROM latest
+		JOIN patients ON patients.id = latest.patient_id AND patients.deleted_at IS NULL
+		JOIN clinics ON clinics.id = patients.clinic_id
+		WHERE patients.date_of_birth > ? `+clinicFilter+`
+		GROUP BY clinics.id, clinics.name, clinics.district
+		ORDER BY clinics.district, clinics.name
+	`, args...).Scan(&byClinic)
+
+	districts := make(map[string]*MalnutritionPrevalence)
+	var districtOrder []string
+	for i := range byClinic {
+		row := &byClinic[i]
+		setMalnutritionPercentages(row)
+		analytics.ByClinic = append(analytics.ByClinic, *row)
+
+		district, ok := districts[row.District]
+		if !ok {
+			district = &MalnutritionPrevalence{District: row.District}
+			districts[row.District] = district
+			districtOrder = append(districtOrder, row.District)
+		}
+		district.ChildrenMeasured += row.ChildrenMeasured
+		district.SAM += row.SAM
+		district.MAM += row.MAM
+		district.Stunted += row.Stunted
+		district.Underweight += row.Underweight
+	}
+	for _, name := range districtOrder {
+		setMalnutritionPercentages(districts[name])
+		analytics.ByDistrict = append(analytics.ByDistrict, *districts[name])
+	}
+
+	return analytics
+}
+
+func setMalnutritionPercentages(p *MalnutritionPrevalence) {
+	if p.ChildrenMeasured == 0 {
+		return
+	}
+	percent := func(count int64) float64 {
+		return math.Round(float64(count)/float64(p.ChildrenMeasured)*1000) / 10
+	}
+	p.SAMPercentage = percent(p.SAM)
+	p.MAMPercentage = percent(p.MAM)
+	p.GAMPercentage = percent(p.SAM + p.MAM)
+	p.StuntedPercentage = percent(p.Stunted)
+	p.UnderweightPercentage = percent(p.Underweight)
+}
+
 func (h *DashboardAnalyticsHandler) getGenderDistribution(clinicID *uint) []GenderInfo {
 	var results []GenderInfo
 
@@ -452,12 +693,12 @@ func (h *DashboardAnalyticsHandler) getIllnessTrends(clinicID *uint, months int)
 		Select(`
 			TO_CHAR(visits.visit_date, 'Month') as month,
 			DATE_PART('year', visits.visit_date) as year,
-			diagnosis_code,
+			UPPER(TRIM(diagnoses.diagnosis_code)) as diagnosis_code,
 			COUNT(*) as count
 		`).
 		Joins("JOIN visits ON diagnoses.visit_id = visits.id").
 		Where("visits.visit_date >= ?", startDate).
-		Group("month, year, diagnosis_code").
+		Group("month, year, UPPER(TRIM(diagnoses.diagnosis_code))").
 		Order("year, DATE_PART('month', visits.visit_date), count DESC")
 
 	if clinicID != nil {
@@ -484,12 +725,12 @@ func (h *DashboardAnalyticsHandler) getSeasonalTrends(clinicID *uint) []Seasonal
 			END as season,
 			DATE_PART('month', visits.visit_date) as month,
 			DATE_PART('year', visits.visit_date) as year,
-			diagnosis_code,
+			UPPER(TRIM(diagnoses.diagnosis_code)) as diagnosis_code,
 			COUNT(*) as count
 		`).
 		Joins("JOIN visits ON diagnoses.visit_id = visits.id").
 		Where("visits.visit_date >= ?", startDate).
-		Group("season, month, year, diagnosis_code").
+		Group("season, month, year, UPPER(TRIM(diagnoses.diagnosis_code))").
 		Order("year, month, count DESC")
 
 	if clinicID != nil {
@@ -553,20 +794,23 @@ func (h *DashboardAnalyticsHandler) getTopDiagnosesForDistrict(district string,
 	}
 
 	h.db.Table("diagnoses").
-		Select("diagnosis_code, description, COUNT(*) as count").
+		Select("UPPER(TRIM(diagnoses.diagnosis_code)) AS diagnosis_code, MAX(diagnoses.description) AS description, COUNT(*) as count").
 		Joins("JOIN visits ON diagnoses.visit_id = visits.id").
 		Joins("JOIN clinics ON visits.clinic_id = clinics.id").
 		Where("clinics.district = ?", district).
-		Group("diagnosis_code, description").
+		Group("UPPER(TRIM(diagnoses.diagnosis_code))").
 		Order("count DESC").
 		Limit(limit).
 		Scan(&rawResults)
 
 	// Calculate total for percentages
 	var total int64
+	codes := make([]string, 0, len(rawResults))
 	for _, result := range rawResults {
 		total += result.Count
+		codes = append(codes, result.DiagnosisCode)
 	}
+	titles := icd10Titles(h.db, codes)
 
 	// Convert to response format with percentages
 	for _, result := range rawResults {
@@ -575,9 +819,13 @@ func (h *DashboardAnalyticsHandler) getTopDiagnosesForDistrict(district string,
 			percentage = float64(result.Count) / float64(total) * 100
 		}
 
+		description := result.Description
+		if title, ok := titles[result.DiagnosisCode]; ok {
+			description = title
+		}
 		results = append(results, DiagnosisAnalytics{
 			DiagnosisCode: result.DiagnosisCode,
-			Description:   result.Description,
+			Description:   description,
 			Count:         result.Count,
 			Percentage:    percentage,
 		})
@@ -590,17 +838,19 @@ func (h *DashboardAnalyticsHandler) getTopPrescriptionsForDistrict(district stri
 	var results []PrescriptionAnalytics
 
 	var rawResults []struct {
+		FormularyItemID *uint   `json:"formulary_item_id"`
 		MedicationName  string  `json:"medication_name"`
 		Count           int64   `json:"count"`
 		AvgDurationDays float64 `json:"avg_duration_days"`
 	}
 
 	h.db.Table("prescriptions").
-		Select("medication_name, COUNT(*) as count, AVG(duration_days) as avg_duration_days").
+		Select(prescriptionGroupColumns+", COUNT(*) as count, AVG(prescriptions.duration_days) as avg_duration_days").
+		Joins("LEFT JOIN formulary_items ON formulary_items.id = prescriptions.formulary_item_id").
 		Joins("JOIN visits ON prescriptions.visit_id = visits.id").
 		Joins("JOIN clinics ON visits.clinic_id = clinics.id").
-		Where("clinics.district = ?", district).
-		Group("medication_name").
+		Where("clinics.district = ? AND prescriptions.deleted_at IS NULL", district).
+		Group("prescriptions.formulary_item_id, 2").
 		Order("count DESC").
 		Limit(limit).
 		Scan(&rawResults)
@@ -619,6 +869,7 @@ func (h *DashboardAnalyticsHandler) getTopPrescriptionsForDistrict(district stri
 		}
 
 		results = append(results, PrescriptionAnalytics{
+			FormularyItemID: result.FormularyItemID,
 			MedicationName:  result.MedicationName,
 			Count:           result.Count,
 			Percentage:      percentage,
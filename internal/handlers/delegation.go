@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var errDelegationDenied = errors.New("no delegated access to patient")
+
+// portalPatientID resolves the patient a patient portal request is about. By
+// default it is the caller's own record; a patient_id query parameter selects
+// another patient whose active delegation to the caller grants scope. An
+// empty scope accepts any active delegation.
+func portalPatientID(db *gorm.DB, c *fiber.Ctx, scope string) (uint, error) {
+	ownID := c.Locals("patient_id").(uint)
+	selected := c.Query("patient_id")
+	if selected == "" {
+		return ownID, nil
+	}
+	id, err := strconv.ParseUint(selected, 10, 32)
+	if err != nil {
+		return 0, errDelegationDenied
+	}
+	if uint(id) == ownID {
+		return ownID, nil
+	}
+
+	var delegations []models.PatientDelegation
+	now := time.Now()
+	if err := db.Where("patient_id = ? AND delegate_user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+		id, c.Locals("user_id").(uint), now).Find(&delegations).Error; err != nil {
+		return 0, err
+	}
+	if models.DelegationsAllow(delegations, scope, now) {
+		return uint(id), nil
+	}
+	return 0, errDelegationDenied
+}
+
+// portalAllows reports whether the caller may also see scope for the patient
+// the request is about. Patients always may for their own record.
+func portalAllows(db *gorm.DB, c *fiber.Ctx, scope string) (bool, error) {
+	_, err := portalPatientID(db, c, scope)
+	if err == errDelegationDenied {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// delegationErrorResponse reports a failed portalPatientID
+func delegationErrorResponse(c *fiber.Ctx, err error) error {
+	if err == errDelegationDenied {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You do not have access to this patient's records",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to check delegated access",
+	})
+}
+
+type DelegationHandler struct {
+	db *gorm.DB
+}
+
+func NewDelegationHandler(db *gorm.DB) *DelegationHandler {
+	return &DelegationHandler{db: db}
+}
+
+// findPatient loads a patient registered at the caller's clinic, or any
+// patient for administrators
+func (h *DelegationHandler) findPatient(c *fiber.Ctx) (*models.Patient, error) {
+	query := h.db.Where("id = ?", c.Params("id"))
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var patient models.Patient
+	if err := query.First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// CreateDelegation gives a parent or caregiver's portal login access to a
+// patient's records, recording who consented and how
+func (h *DelegationHandler) CreateDelegation(c *fiber.Ctx) error {
+	patient, err := h.findPatient(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var req models.CreateDelegationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	scopes, err := models.NormalizeDelegationScopes(req.Scopes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid scopes",
+			"valid": []string{models.DelegationScopeViewVisits, models.DelegationScopeViewPrescriptions, models.DelegationScopeBookAppointments},
+		})
+	}
+	req.Relationship = strings.ToLower(strings.TrimSpace(req.Relationship))
+	if !slices.Contains(models.DelegationRelationships, req.Relationship) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "relationship must be one of " + strings.Join(models.DelegationRelationships, ", "),
+		})
+	}
+	req.ConsentMethod = strings.ToLower(strings.TrimSpace(req.ConsentMethod))
+	if !slices.Contains(models.ConsentMethods, req.ConsentMethod) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "consent_method must be one of " + strings.Join(models.ConsentMethods, ", "),
+		})
+	}
+	req.ConsentGivenBy = strings.TrimSpace(req.ConsentGivenBy)
+	if len(req.ConsentGivenBy) < 2 || len(req.ConsentGivenBy) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "consent_given_by must name who gave consent",
+		})
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		expiry, err := time.ParseInLocation("2006-01-02", req.ExpiresAt, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid expires_at format. Use YYYY-MM-DD",
+			})
+		}
+		// Valid through the end of the given day
+		expiry = expiry.AddDate(0, 0, 1)
+		if !expiry.After(now) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "expires_at must be in the future",
+			})
+		}
+		expiresAt = &expiry
+	}
+
+	var delegate models.Patient
+	if err := h.db.First(&delegate, req.DelegatePatientID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Delegate patient not found",
+		})
+	}
+	if delegate.UserID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Delegate has no portal login. Issue them a claim code first.",
+		})
+	}
+	if delegate.ID == patient.ID || (patient.UserID != nil && *patient.UserID == *delegate.UserID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A patient cannot be their own delegate",
+		})
+	}
+
+	var existing int64
+	h.db.Model(&models.PatientDelegation{}).
+		Where("patient_id = ? AND delegate_user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			patient.ID, *delegate.UserID, now).
+		Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This delegate already has access. Revoke it first to change the scopes.",
+		})
+	}
+
+	delegation := models.PatientDelegation{
+		PatientID:         patient.ID,
+		DelegateUserID:    *delegate.UserID,
+		Relationship:      req.Relationship,
+		Scopes:            scopes,
+		ConsentGivenBy:    req.ConsentGivenBy,
+		ConsentMethod:     req.ConsentMethod,
+		ConsentRecordedAt: now,
+		ExpiresAt:         expiresAt,
+		GrantedByUserID:   currentUserID(c),
+	}
+	if err := h.db.Create(&delegation).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create delegation",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(delegation)
+}
+
+// GetPatientDelegations lists everyone who has been given access to a patient's records
+func (h *DelegationHandler) GetPatientDelegations(c *fiber.Ctx) error {
+	patient, err := h.findPatient(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var delegations []models.PatientDelegation
+	if err := h.db.Preload("DelegateUser").Where("patient_id = ?", patient.ID).
+		Order("created_at DESC").Find(&delegations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch delegations",
+		})
+	}
+
+	return c.JSON(delegations)
+}
+
+// RevokeDelegation ends a delegation on a patient's records
+func (h *DelegationHandler) RevokeDelegation(c *fiber.Ctx) error {
+	patient, err := h.findPatient(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+	return h.revoke(c, patient.ID, c.Params("delegationId"))
+}
+
+// GetMyDelegations lists the patients whose records the caller can access,
+// and who can access the caller's own record
+func (h *DelegationHandler) GetMyDelegations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	patientID := c.Locals("patient_id").(uint)
+	now := time.Now()
+
+	var grantedToMe []models.PatientDelegation
+	if err := h.db.Preload("Patient").
+		Where("delegate_user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Order("created_at").Find(&grantedToMe).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch delegations",
+		})
+	}
+
+	var onMyRecord []models.PatientDelegation
+	if err := h.db.Preload("DelegateUser").
+		Where("patient_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", patientID, now).
+		Order("created_at").Find(&onMyRecord).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch delegations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"granted_to_me": grantedToMe,
+		"on_my_record":  onMyRecord,
+	})
+}
+
+// RevokeMyDelegation lets a patient withdraw someone's access to their own record
+func (h *DelegationHandler) RevokeMyDelegation(c *fiber.Ctx) error {
+	return h.revoke(c, c.Locals("patient_id").(uint), c.Params("id"))
+}
+
+func (h *DelegationHandler) revoke(c *fiber.Ctx, patientID uint, delegationID string) error {
+	var delegation models.PatientDelegation
+	if err := h.db.Where("id = ? AND patient_id = ?", delegationID, patientID).First(&delegation).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Delegation not found",
+		})
+	}
+	if delegation.RevokedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Delegation has already been revoked",
+		})
+	}
+
+	now := time.Now()
+	delegation.RevokedAt = &now
+	delegation.RevokedByUserID = currentUserID(c)
+	if err := h.db.Model(&delegation).Select("revoked_at", "revoked_by_user_id").Updates(&delegation).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke delegation",
+		})
+	}
+
+	return c.JSON(delegation)
+}
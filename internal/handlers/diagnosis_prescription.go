@@ -3,6 +3,7 @@ package handlers
 import (
 	"math"
 	"strconv"
+	"strings"
 
 	"rural_health_management_system/internal/models"
 
@@ -50,6 +51,8 @@ func (h *DiagnosisHandler) GetDiagnoses(c *fiber.Ctx) error {
 
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
+	auditDiagnoses(h.db, c, models.AuditActionList, diagnoses)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       diagnoses,
 		Page:       page,
@@ -74,6 +77,8 @@ func (h *DiagnosisHandler) GetDiagnosisByID(c *fiber.Ctx) error {
 		})
 	}
 
+	auditDiagnoses(h.db, c, models.AuditActionView, []models.Diagnosis{diagnosis})
+
 	return c.JSON(diagnosis)
 }
 
@@ -86,13 +91,25 @@ func (h *DiagnosisHandler) CreateDiagnosis(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate required fields
-	if diagnosis.VisitID == 0 || diagnosis.DiagnosisCode == "" || diagnosis.Description == "" {
+	// Validate required fields; the description defaults to the catalog's
+	if diagnosis.VisitID == 0 || diagnosis.DiagnosisCode == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Missing required fields",
 		})
 	}
 
+	code, err := lookupICD10Code(h.db, diagnosis.DiagnosisCode)
+	if err != nil {
+		status, message := diagnosisCodeError(err)
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error: message,
+		})
+	}
+	diagnosis.DiagnosisCode = code.Code
+	if strings.TrimSpace(diagnosis.Description) == "" {
+		diagnosis.Description = code.Description
+	}
+
 	// Check if visit exists
 	var visit models.Visit
 	if err := h.db.First(&visit, diagnosis.VisitID).Error; err != nil {
@@ -108,6 +125,7 @@ func (h *DiagnosisHandler) CreateDiagnosis(c *fiber.Ctx) error {
 	}
 
 	h.db.Preload("Visit").First(&diagnosis, diagnosis.ID)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityDiagnosis, diagnosis.ID, visit.PatientID, nil, &diagnosis)
 	return c.Status(fiber.StatusCreated).JSON(diagnosis)
 }
 
@@ -133,9 +151,18 @@ func (h *DiagnosisHandler) UpdateDiagnosis(c *fiber.Ctx) error {
 		})
 	}
 
+	before := diagnosis
+
 	// Update fields
 	if updates.DiagnosisCode != "" {
-		diagnosis.DiagnosisCode = updates.DiagnosisCode
+		code, err := lookupICD10Code(h.db, updates.DiagnosisCode)
+		if err != nil {
+			status, message := diagnosisCodeError(err)
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error: message,
+			})
+		}
+		diagnosis.DiagnosisCode = code.Code
 	}
 	if updates.Description != "" {
 		diagnosis.Description = updates.Description
@@ -148,6 +175,7 @@ func (h *DiagnosisHandler) UpdateDiagnosis(c *fiber.Ctx) error {
 	}
 
 	h.db.Preload("Visit").First(&diagnosis, diagnosis.ID)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityDiagnosis, diagnosis.ID, visitPatientID(h.db, diagnosis.Visit, diagnosis.VisitID), &before, &diagnosis)
 	return c.JSON(diagnosis)
 }
 
@@ -172,6 +200,8 @@ func (h *DiagnosisHandler) DeleteDiagnosis(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionDelete, models.AuditEntityDiagnosis, diagnosis.ID, visitPatientID(h.db, nil, diagnosis.VisitID), &diagnosis, nil)
+
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
@@ -215,6 +245,8 @@ func (h *PrescriptionHandler) GetPrescriptions(c *fiber.Ctx) error {
 
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
+	auditPrescriptions(h.db, c, models.AuditActionList, prescriptions)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       prescriptions,
 		Page:       page,
@@ -239,34 +271,71 @@ func (h *PrescriptionHandler) GetPrescriptionByID(c *fiber.Ctx) error {
 		})
 	}
 
+	auditPrescriptions(h.db, c, models.AuditActionView, []models.Prescription{prescription})
+
 	return c.JSON(prescription)
 }
 
 func (h *PrescriptionHandler) CreatePrescription(c *fiber.Ctx) error {
-	var prescription models.Prescription
-
-	if err := c.BodyParser(&prescription); err != nil {
+	var req models.CreatePrescriptionRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Invalid input",
 		})
 	}
 
-	// Validate required fields
-	if prescription.VisitID == 0 || prescription.MedicationName == "" ||
-		prescription.Dosage == "" || prescription.Instructions == "" || prescription.DurationDays <= 0 {
+	// Check if visit exists
+	var visit models.Visit
+	if err := h.db.First(&visit, req.VisitID).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Error: "Missing required fields",
+			Error: "Visit not found",
 		})
 	}
 
-	// Check if visit exists
-	var visit models.Visit
-	if err := h.db.First(&visit, prescription.VisitID).Error; err != nil {
+	// Render formulary-based prescriptions into name, dosage and instructions
+	if err := applyFormulary(h.db, visit.ClinicID, &req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Error: "Visit not found",
+			Error:   "Invalid formulary prescription",
+			Details: err.Error(),
 		})
 	}
 
+	// Validate required fields
+	if req.MedicationName == "" || req.Dosage == "" ||
+		req.Instructions == "" || req.DurationDays <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing required fields",
+		})
+	}
+
+	// Check the medication against the patient's recorded allergies
+	allergyWarnings, ok, err := checkPrescriptionAllergies(h.db, c, visit.PatientID, &req)
+	if !ok {
+		return err
+	}
+
+	// Check the medication against the patient's other active prescriptions
+	interactionWarnings, ok, err := checkPrescriptionInteractions(h.db, c, visit.PatientID, 0, &req)
+	if !ok {
+		return err
+	}
+
+	prescription := models.Prescription{
+		VisitID:                   req.VisitID,
+		MedicationName:            req.MedicationName,
+		Dosage:                    req.Dosage,
+		Instructions:              req.Instructions,
+		DurationDays:              req.DurationDays,
+		FormularyItemID:           req.FormularyItemID,
+		DoseAmount:                req.DoseAmount,
+		DoseUnit:                  req.DoseUnit,
+		Frequency:                 req.Frequency,
+		Route:                     req.Route,
+		Quantity:                  req.Quantity,
+		AllergyOverrideReason:     req.AllergyOverrideReason,
+		InteractionOverrideReason: req.InteractionOverrideReason,
+	}
+
 	if err := h.db.Create(&prescription).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
 			Error: "Failed to create prescription",
@@ -274,7 +343,12 @@ func (h *PrescriptionHandler) CreatePrescription(c *fiber.Ctx) error {
 	}
 
 	h.db.Preload("Visit").First(&prescription, prescription.ID)
-	return c.Status(fiber.StatusCreated).JSON(prescription)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPrescription, prescription.ID, visit.PatientID, nil, &prescription)
+	return c.Status(fiber.StatusCreated).JSON(models.PrescriptionResponse{
+		Prescription:        prescription,
+		AllergyWarnings:     allergyWarnings,
+		InteractionWarnings: interactionWarnings,
+	})
 }
 
 func (h *PrescriptionHandler) UpdatePrescription(c *fiber.Ctx) error {
@@ -292,25 +366,72 @@ func (h *PrescriptionHandler) UpdatePrescription(c *fiber.Ctx) error {
 		})
 	}
 
-	var updates models.Prescription
-	if err := c.BodyParser(&updates); err != nil {
+	var req models.UpdatePrescriptionRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Invalid input",
 		})
 	}
 
+	before := prescription
+
 	// Update fields
-	if updates.MedicationName != "" {
-		prescription.MedicationName = updates.MedicationName
+	if req.MedicationName != nil && strings.TrimSpace(*req.MedicationName) != "" {
+		prescription.MedicationName = strings.TrimSpace(*req.MedicationName)
 	}
-	if updates.Dosage != "" {
-		prescription.Dosage = updates.Dosage
+	if req.Dosage != nil && *req.Dosage != "" {
+		prescription.Dosage = *req.Dosage
 	}
-	if updates.Instructions != "" {
-		prescription.Instructions = updates.Instructions
+	if req.Instructions != nil && *req.Instructions != "" {
+		prescription.Instructions = *req.Instructions
 	}
-	if updates.DurationDays > 0 {
-		prescription.DurationDays = updates.DurationDays
+	if req.DurationDays != nil && *req.DurationDays > 0 {
+		prescription.DurationDays = *req.DurationDays
+	}
+
+	var allergyWarnings []models.AllergyMatch
+	var interactionWarnings []models.InteractionWarning
+	if !strings.EqualFold(prescription.MedicationName, before.MedicationName) {
+		// Formulary prescriptions take their name from the formulary item, and
+		// what was handed out can no longer be swapped for another medication
+		if prescription.FormularyItemID != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "The medication of a formulary prescription cannot be changed; prescribe the new medication instead",
+			})
+		}
+		if prescription.QuantityDispensed > 0 {
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+				Error: "The medication of a dispensed prescription cannot be changed",
+			})
+		}
+
+		var visit models.Visit
+		if err := h.db.First(&visit, prescription.VisitID).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error: "Failed to fetch visit",
+			})
+		}
+		check := models.CreatePrescriptionRequest{
+			VisitID:                   prescription.VisitID,
+			MedicationName:            prescription.MedicationName,
+			AllergyOverrideReason:     req.AllergyOverrideReason,
+			InteractionOverrideReason: req.InteractionOverrideReason,
+		}
+
+		// Check the new medication against the patient's recorded allergies
+		allergies, ok, err := checkPrescriptionAllergies(h.db, c, visit.PatientID, &check)
+		if !ok {
+			return err
+		}
+
+		// Check it against the patient's other active prescriptions
+		interactions, ok, err := checkPrescriptionInteractions(h.db, c, visit.PatientID, prescription.ID, &check)
+		if !ok {
+			return err
+		}
+		allergyWarnings, interactionWarnings = allergies, interactions
+		prescription.AllergyOverrideReason = check.AllergyOverrideReason
+		prescription.InteractionOverrideReason = check.InteractionOverrideReason
 	}
 
 	if err := h.db.Save(&prescription).Error; err != nil {
@@ -320,7 +441,12 @@ func (h *PrescriptionHandler) UpdatePrescription(c *fiber.Ctx) error {
 	}
 
 	h.db.Preload("Visit").First(&prescription, prescription.ID)
-	return c.JSON(prescription)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPrescription, prescription.ID, visitPatientID(h.db, prescription.Visit, prescription.VisitID), &before, &prescription)
+	return c.JSON(models.PrescriptionResponse{
+		Prescription:        prescription,
+		AllergyWarnings:     allergyWarnings,
+		InteractionWarnings: interactionWarnings,
+	})
 }
 
 func (h *PrescriptionHandler) DeletePrescription(c *fiber.Ctx) error {
@@ -344,5 +470,7 @@ func (h *PrescriptionHandler) DeletePrescription(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionDelete, models.AuditEntityPrescription, prescription.ID, visitPatientID(h.db, nil, prescription.VisitID), &prescription, nil)
+
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DispensingHandler struct {
+	db *gorm.DB
+}
+
+func NewDispensingHandler(db *gorm.DB) *DispensingHandler {
+	return &DispensingHandler{db: db}
+}
+
+// findClinicPrescription loads a prescription written at the caller's clinic
+func (h *DispensingHandler) findClinicPrescription(c *fiber.Ctx) (*models.Prescription, error) {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var prescription models.Prescription
+	if err := h.db.Joins("Visit").
+		Where("prescriptions.id = ? AND \"Visit\".clinic_id = ?", c.Params("id"), clinicID).
+		First(&prescription).Error; err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+// stockItemFor finds and locks the clinic's stock item for a prescription,
+// matching on formulary item first and then on medication name
+func stockItemFor(tx *gorm.DB, clinicID uint, prescription *models.Prescription) (*models.StockItem, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("clinic_id = ? AND is_active = true", clinicID)
+	if prescription.FormularyItemID != nil {
+		query = query.Where("formulary_item_id = ? OR LOWER(medication_name) = LOWER(?)", *prescription.FormularyItemID, prescription.MedicationName).
+			Order("formulary_item_id IS NULL")
+	} else {
+		query = query.Where("LOWER(medication_name) = LOWER(?)", prescription.MedicationName)
+	}
+
+	var items []models.StockItem
+	if err := query.Limit(1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, newInventoryError(fiber.StatusConflict, "%s is not stocked at this clinic. Record it as not_in_stock instead.", prescription.MedicationName)
+	}
+	return &items[0], nil
+}
+
+// DispensePrescription issues stock against a prescription, or records that it
+// could not be supplied. Stock, the ledger and the prescription's dispensing
+// status are updated in one transaction.
+func (h *DispensingHandler) DispensePrescription(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	found, err := h.findClinicPrescription(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Prescription not found or access denied",
+		})
+	}
+
+	var req models.DispenseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Notes = truncate(strings.TrimSpace(req.Notes), 500)
+	if req.Quantity < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "quantity must be positive",
+		})
+	}
+
+	var staffID *uint
+	if id, ok := c.Locals("staff_id").(uint); ok {
+		staffID = &id
+	}
+
+	now := time.Now()
+	var before, prescription models.Prescription
+	var dispensations []models.Dispensation
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the prescription so concurrent dispensing cannot exceed the prescribed quantity
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&prescription, found.ID).Error; err != nil {
+			return err
+		}
+		before = prescription
+
+		if prescription.DispensingStatus == models.DispensingStatusFull {
+			return newInventoryError(fiber.StatusConflict, "Prescription has already been fully dispensed")
+		}
+
+		if req.NotInStock {
+			dispensation := models.Dispensation{
+				PrescriptionID:     prescription.ID,
+				ClinicID:           clinicID,
+				Outcome:            models.DispensationOutcomeNoStock,
+				DispensedByStaffID: staffID,
+				Notes:              req.Notes,
+			}
+			if err := tx.Create(&dispensation).Error; err != nil {
+				return err
+			}
+			dispensations = append(dispensations, dispensation)
+
+			status := models.NextDispensingStatus(prescription.Quantity, prescription.QuantityDispensed, true)
+			prescription.DispensingStatus = status
+			return tx.Model(&prescription).Update("dispensing_status", status).Error
+		}
+
+		quantity := req.Quantity
+		if prescription.Quantity != nil {
+			outstanding := *prescription.Quantity - prescription.QuantityDispensed
+			if quantity == 0 {
+				quantity = outstanding
+			}
+			if quantity > outstanding {
+				return newInventoryError(fiber.StatusBadRequest, "Only %d of the prescribed quantity remain to be dispensed", outstanding)
+			}
+		}
+		if quantity <= 0 {
+			return newInventoryError(fiber.StatusBadRequest, "quantity is required when the prescription has no prescribed quantity")
+		}
+
+		item, err := stockItemFor(tx, clinicID, &prescription)
+		if err != nil {
+			return err
+		}
+
+		var batches []models.StockBatch
+		batchQuery := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("stock_item_id = ?", item.ID)
+		if req.StockBatchID != nil {
+			batchQuery = batchQuery.Where("id = ?", *req.StockBatchID)
+		}
+		if err := batchQuery.Find(&batches).Error; err != nil {
+			return err
+		}
+		if req.StockBatchID != nil && len(batches) == 0 {
+			return newInventoryError(fiber.StatusBadRequest, "Batch not found for %s", item.MedicationName)
+		}
+
+		allocations, shortfall := models.AllocateFEFO(batches, quantity, now)
+		if shortfall > 0 {
+			return newInventoryError(fiber.StatusConflict, "Insufficient unexpired stock of %s: %d %s available, %d requested",
+				item.MedicationName, quantity-shortfall, item.Unit, quantity)
+		}
+
+		itemBalance := item.QuantityOnHand
+		for _, allocation := range allocations {
+			batch := allocation.Batch
+			batchBalance, newItemBalance, err := models.ApplyStockChange(batch.QuantityOnHand, itemBalance, -allocation.Quantity)
+			if err != nil {
+				return newInventoryError(fiber.StatusConflict, "%s", err.Error())
+			}
+			itemBalance = newItemBalance
+
+			if err := tx.Model(&batch).Update("quantity_on_hand", batchBalance).Error; err != nil {
+				return err
+			}
+
+			dispensation := models.Dispensation{
+				PrescriptionID:     prescription.ID,
+				ClinicID:           clinicID,
+				Outcome:            models.DispensationOutcomeIssued,
+				StockItemID:        &item.ID,
+				StockBatchID:       &batch.ID,
+				LotNumber:          batch.LotNumber,
+				QuantityDispensed:  allocation.Quantity,
+				DispensedByStaffID: staffID,
+				Notes:              req.Notes,
+			}
+			if err := tx.Create(&dispensation).Error; err != nil {
+				return err
+			}
+			dispensations = append(dispensations, dispensation)
+
+			if err := tx.Create(&models.StockTransaction{
+				ClinicID:          clinicID,
+				StockItemID:       item.ID,
+				StockBatchID:      &batch.ID,
+				Type:              models.StockTransactionDispense,
+				Quantity:          -allocation.Quantity,
+				BalanceAfter:      itemBalance,
+				BatchBalanceAfter: &batchBalance,
+				ReferenceID:       &prescription.ID,
+				UserID:            currentUserID(c),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(item).Update("quantity_on_hand", itemBalance).Error; err != nil {
+			return err
+		}
+
+		prescription.QuantityDispensed += quantity
+		prescription.DispensingStatus = models.NextDispensingStatus(prescription.Quantity, prescription.QuantityDispensed, false)
+		return tx.Model(&prescription).Updates(map[string]interface{}{
+			"quantity_dispensed": prescription.QuantityDispensed,
+			"dispensing_status":  prescription.DispensingStatus,
+		}).Error
+	})
+	if err != nil {
+		return inventoryErrorResponse(c, err, "Failed to dispense prescription")
+	}
+
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPrescription, prescription.ID, found.Visit.PatientID, &before, &prescription)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"prescription_id":    prescription.ID,
+		"dispensing_status":  prescription.DispensingStatus,
+		"quantity_dispensed": prescription.QuantityDispensed,
+		"dispensations":      dispensations,
+	})
+}
+
+// GetPrescriptionDispensations lists the dispensing history of a prescription
+func (h *DispensingHandler) GetPrescriptionDispensations(c *fiber.Ctx) error {
+	prescription, err := h.findClinicPrescription(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Prescription not found or access denied",
+		})
+	}
+
+	var dispensations []models.Dispensation
+	if err := h.db.Preload("DispensedBy").Where("prescription_id = ?", prescription.ID).
+		Order("created_at, id").Find(&dispensations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch dispensing history",
+		})
+	}
+
+	auditPrescriptions(h.db, c, models.AuditActionView, []models.Prescription{*prescription})
+
+	return c.JSON(fiber.Map{
+		"prescription_id":    prescription.ID,
+		"dispensing_status":  prescription.DispensingStatus,
+		"quantity_dispensed": prescription.QuantityDispensed,
+		"dispensations":      dispensations,
+	})
+}
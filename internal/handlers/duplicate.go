@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// normalizedPhone is the SQL equivalent of models.NormalizePhone
+func normalizedPhone(column string) string {
+	return fmt.Sprintf("RIGHT(REGEXP_REPLACE(%s, '[^0-9]', '', 'g'), 10)", column)
+}
+
+// maxDuplicatePairs caps the clinic-wide scan
+const maxDuplicatePairs = 500
+
+var errPatientMerged = errors.New("patient merged or removed concurrently")
+
+// patientRecords lists the records owned by a patient that move to the
+// surviving record on a merge. Prescriptions and diagnoses follow their visits.
+var patientRecords = []struct {
+	name  string
+	model interface{}
+}{
+	{"visits", &models.Visit{}},
+	{"appointments", &models.Appointment{}},
+	{"allergies", &models.PatientAllergy{}},
+	{"problems", &models.PatientProblem{}},
+	{"vital_signs", &models.VitalSigns{}},
+	{"referrals", &models.Referral{}},
+	{"transfers", &models.PatientTransfer{}},
+	{"delegations", &models.PatientDelegation{}},
+	{"claim_codes", &models.PatientClaimCode{}},
+	{"immunizations", &models.Immunization{}},
+	{"pregnancies", &models.Pregnancy{}},
+	{"antenatal_contacts", &models.AntenatalContact{}},
+	{"postnatal_visits", &models.PostnatalVisit{}},
+	{"growth_measurements", &models.GrowthMeasurement{}},
+	{"lab_orders", &models.LabOrder{}},
+}
+
+type DuplicateHandler struct {
+	db *gorm.DB
+}
+
+func NewDuplicateHandler(db *gorm.DB) *DuplicateHandler {
+	return &DuplicateHandler{db: db}
+}
+
+// findPatient loads a patient registered at the caller's clinic, or any
+// patient for administrators
+func (h *DuplicateHandler) findPatient(c *fiber.Ctx, id interface{}) (*models.Patient, error) {
+	query := h.db.Where("id = ?", id)
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var patient models.Patient
+	if err := query.First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// districtClinics selects the clinics in the same district as a clinic
+func (h *DuplicateHandler) districtClinics(clinicID uint) *gorm.DB {
+	district := h.db.Model(&models.Clinic{}).Select("district").Where("id = ?", clinicID)
+	return h.db.Model(&models.Clinic{}).Select("id").Where("district = (?)", district)
+}
+
+// GetPatientDuplicates lists records that may be the same person as a patient.
+// scope=district widens the search from the patient's clinic to every clinic
+// in its district.
+func (h *DuplicateHandler) GetPatientDuplicates(c *fiber.Ctx) error {
+	patient, err := h.findPatient(c, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	scope := c.Query("scope", "clinic")
+	query := h.db.Preload("Clinic").Where("id <> ?", patient.ID)
+	switch scope {
+	case "clinic":
+		query = query.Where("clinic_id = ?", patient.ClinicID)
+	case "district":
+		query = query.Where("clinic_id IN (?)", h.districtClinics(patient.ClinicID))
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "scope must be clinic or district",
+		})
+	}
+
+	// Every candidate shares a date of birth or phone, so only those are scored
+	var pool []models.Patient
+	if err := query.Where("(DATE(date_of_birth) = ? OR "+normalizedPhone("phone")+" = ?)",
+		patient.DateOfBirth.Format("2006-01-02"), models.NormalizePhone(patient.Phone)).
+		Find(&pool).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search for duplicates",
+		})
+	}
+
+	candidates := models.FindDuplicateCandidates(*patient, pool)
+
+	matched := make([]models.Patient, 0, len(candidates)+1)
+	matched = append(matched, *patient)
+	for _, candidate := range candidates {
+		matched = append(matched, candidate.Patient)
+	}
+	auditPatients(h.db, c, models.AuditActionView, matched)
+
+	return c.JSON(fiber.Map{
+		"patient":    patient,
+		"scope":      scope,
+		"candidates": candidates,
+	})
+}
+
+// GetClinicDuplicates lists likely duplicate pairs among the caller's patients
+// for review, most likely first
+func (h *DuplicateHandler) GetClinicDuplicates(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var pairs []struct {
+		PatientID   uint
+		DuplicateID uint
+	}
+	if err := h.db.Table("patients AS a").
+		Select("a.id AS patient_id, b.id AS duplicate_id").
+		Joins("JOIN patients AS b ON a.id < b.id AND b.clinic_id = a.clinic_id AND b.deleted_at IS NULL AND (DATE(a.date_of_birth) = DATE(b.date_of_birth) OR "+
+			normalizedPhone("a.phone")+" = "+normalizedPhone("b.phone")+")").
+		Where("a.clinic_id = ? AND a.deleted_at IS NULL", clinicID).
+		Order("a.id, b.id").Limit(maxDuplicatePairs).
+		Scan(&pairs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search for duplicates",
+		})
+	}
+
+	ids := make([]uint, 0, len(pairs)*2)
+	for _, p := range pairs {
+		ids = append(ids, p.PatientID, p.DuplicateID)
+	}
+	var patients []models.Patient
+	if len(ids) > 0 {
+		if err := h.db.Where("id IN ?", ids).Find(&patients).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to search for duplicates",
+			})
+		}
+	}
+	byID := make(map[uint]models.Patient, len(patients))
+	for _, p := range patients {
+		byID[p.ID] = p
+	}
+
+	result := []models.DuplicatePair{}
+	seen := make(map[uint]bool)
+	var matched []models.Patient
+	for _, p := range pairs {
+		a, b := byID[p.PatientID], byID[p.DuplicateID]
+		score, reasons := models.ScoreDuplicate(a, b)
+		if score < models.DuplicateScoreThreshold {
+			continue
+		}
+		result = append(result, models.DuplicatePair{Patient: a, Duplicate: b, Score: score, Reasons: reasons})
+		for _, m := range []models.Patient{a, b} {
+			if !seen[m.ID] {
+				seen[m.ID] = true
+				matched = append(matched, m)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+
+	auditPatients(h.db, c, models.AuditActionList, matched)
+
+	return c.JSON(fiber.Map{
+		"pairs":     result,
+		"truncated": len(pairs) == maxDuplicatePairs,
+	})
+}
+
+// MergePatients merges a duplicate record into the patient in one
+// transaction: the duplicate's visits and other records move across, its
+// login moves too if the patient has none, and the duplicate is removed.
+func (h *DuplicateHandler) MergePatients(c *fiber.Ctx) error {
+	patient, err := h.findPatient(c, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var req models.MergePatientRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Reason = truncate(strings.TrimSpace(req.Reason), 500)
+	if req.DuplicatePatientID == 0 || req.DuplicatePatientID == patient.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "duplicate_patient_id must be a different patient",
+		})
+	}
+
+	// Clinic users can merge in records from any clinic in their district
+	dupQuery := h.db.Where("id = ?", req.DuplicatePatientID)
+	if clinicID := callerClinic(c); clinicID != nil {
+		dupQuery = dupQuery.Where("clinic_id IN (?)", h.districtClinics(*clinicID))
+	}
+	var duplicate models.Patient
+	if err := dupQuery.First(&duplicate).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Duplicate patient not found",
+		})
+	}
+
+	if patient.UserID != nil && duplicate.UserID != nil && *patient.UserID != *duplicate.UserID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Both records have their own login. Remove one of the accounts before merging.",
+		})
+	}
+
+	before := *patient
+	reassigned := make(map[string]int64, len(patientRecords))
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock both records in a fixed order so concurrent merges cannot interleave
+		var locked []models.Patient
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{patient.ID, duplicate.ID}).Order("id").
+			Find(&locked).Error; err != nil {
+			return err
+		}
+		if len(locked) != 2 {
+			return errPatientMerged
+		}
+
+		for _, record := range patientRecords {
+			result := tx.Unscoped().Model(record.model).
+				Where("patient_id = ?", duplicate.ID).
+				Update("patient_id", patient.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			reassigned[record.name] = result.RowsAffected
+		}
+
+		if duplicate.UserID != nil && patient.UserID == nil {
+			if err := tx.Model(&duplicate).Update("user_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(patient).Update("user_id", *duplicate.UserID).Error; err != nil {
+				return err
+			}
+			// Tokens carry the old patient ID, so the user signs in again
+			if err := RevokeUserSessions(tx, *duplicate.UserID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&duplicate).Error
+	})
+	if err == errPatientMerged {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "One of the records was merged or removed by someone else, reload and try again",
+		})
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Both records hold the same entry, such as the same vaccine dose; remove the copy from the duplicate and try again",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge patients",
+		})
+	}
+
+	h.db.Preload("Clinic").First(patient, patient.ID)
+
+	survivorEntry := newAuditEntry(c, models.AuditActionMerge, models.AuditEntityPatient, patient.ID, patient.ID)
+	survivorEntry.Changes = models.ComputeChanges(&before, patient)
+	if survivorEntry.Changes == nil {
+		survivorEntry.Changes = models.AuditChanges{}
+	}
+	survivorEntry.Changes["merged_patient_id"] = models.AuditChange{From: nil, To: duplicate.ID}
+	duplicateEntry := newAuditEntry(c, models.AuditActionMerge, models.AuditEntityPatient, duplicate.ID, duplicate.ID)
+	duplicateEntry.Changes = models.AuditChanges{
+		"merged_into_patient_id": {From: nil, To: patient.ID},
+	}
+	if req.Reason != "" {
+		survivorEntry.Changes["reason"] = models.AuditChange{From: nil, To: req.Reason}
+		duplicateEntry.Changes["reason"] = models.AuditChange{From: nil, To: req.Reason}
+	}
+	writeAudit(h.db, []models.AuditLog{survivorEntry, duplicateEntry})
+
+	return c.JSON(fiber.Map{
+		"patient":           patient,
+		"merged_patient_id": duplicate.ID,
+		"reassigned":        reassigned,
+	})
+}
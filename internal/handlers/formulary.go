@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// applyFormulary resolves a formulary-based prescription request, filling in
+// the medication name, dosage, instructions and quantity from the structured
+// fields. Free-text requests (no formulary item) are left unchanged.
+func applyFormulary(db *gorm.DB, clinicID uint, req *models.CreatePrescriptionRequest) error {
+	if req.FormularyItemID == nil {
+		return nil
+	}
+
+	var item models.FormularyItem
+	if err := db.Where("id = ? AND is_active = true AND (clinic_id IS NULL OR clinic_id = ?)", *req.FormularyItemID, clinicID).
+		First(&item).Error; err != nil {
+		return errors.New("formulary item not found")
+	}
+
+	if req.DoseAmount == nil || *req.DoseAmount <= 0 {
+		return errors.New("dose_amount is required when prescribing from the formulary")
+	}
+	if req.DurationDays <= 0 || req.DurationDays > 365 {
+		return errors.New("duration_days must be between 1 and 365")
+	}
+
+	unit := strings.TrimSpace(req.DoseUnit)
+	if unit == "" {
+		unit = item.DefaultDoseUnit
+	}
+	if unit == "" {
+		unit = item.Form
+	}
+
+	frequencyCode := req.Frequency
+	if frequencyCode == "" {
+		frequencyCode = item.DefaultFrequency
+	}
+	frequency, ok := models.LookupFrequency(frequencyCode)
+	if !ok {
+		return errors.New("frequency must be one of OD, BD, TDS, QID, HS, STAT or PRN")
+	}
+
+	route := strings.ToLower(strings.TrimSpace(req.Route))
+	if route == "" {
+		route = strings.ToLower(item.Route)
+	} else if !models.IsValidRoute(route) {
+		return errors.New("unknown route of administration")
+	}
+
+	instruction := models.RenderInstruction(*req.DoseAmount, unit, frequency, route, req.DurationDays)
+	if extra := strings.TrimSpace(req.Instructions); extra != "" && extra != instruction {
+		instruction += ". " + extra
+	}
+
+	req.MedicationName = item.DisplayName()
+	req.Dosage = truncate(models.FormatDose(*req.DoseAmount, unit), 100)
+	req.Instructions = truncate(instruction, 500)
+	req.DoseUnit = unit
+	req.Frequency = frequency.Code
+	req.Route = route
+	if req.Quantity == nil {
+		req.Quantity = models.SuggestedQuantity(*req.DoseAmount, unit, frequency, req.DurationDays)
+	}
+
+	return nil
+}
+
+type FormularyHandler struct {
+	db *gorm.DB
+}
+
+func NewFormularyHandler(db *gorm.DB) *FormularyHandler {
+	return &FormularyHandler{db: db}
+}
+
+// callerClinic returns the caller's clinic, or nil for system administrators
+func callerClinic(c *fiber.Ctx) *uint {
+	if userType, _ := c.Locals("user_type").(string); userType == "admin" {
+		return nil
+	}
+	clinicID := c.Locals("clinic_id").(uint)
+	return &clinicID
+}
+
+// findEditableItem loads a formulary item the caller may change. Clinic users
+// can only change their own clinic's items, never the system formulary.
+func (h *FormularyHandler) findEditableItem(c *fiber.Ctx) (*models.FormularyItem, error) {
+	query := h.db.Where("id = ?", c.Params("id"))
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var item models.FormularyItem
+	if err := query.First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func validateFormularyFields(route, frequency string) error {
+	if !models.IsValidRoute(route) {
+		return errors.New("unknown route of administration")
+	}
+	if frequency != "" {
+		if _, ok := models.LookupFrequency(frequency); !ok {
+			return errors.New("default_frequency must be one of OD, BD, TDS, QID, HS, STAT or PRN")
+		}
+	}
+	return nil
+}
+
+// GetFormulary lists formulary items. Clinic users see the system formulary plus
+// their clinic's items; administrators see everything (or one clinic via clinic_id).
+func (h *FormularyHandler) GetFormulary(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.FormularyItem{})
+
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id IS NULL OR clinic_id = ?", *clinicID)
+	} else if clinicID := c.Query("clinic_id"); clinicID != "" {
+		query = query.Where("clinic_id = ?", clinicID)
+	}
+
+	if search := c.Query("search"); search != "" {
+		query = query.Where("generic_name ILIKE ? OR brand_name ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("is_active = true")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var items []models.FormularyItem
+	if err := query.Order("generic_name, strength").Offset((page - 1) * perPage).Limit(perPage).Find(&items).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch formulary",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       items,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
+
+// GetFrequencies lists the accepted dosing frequency codes
+func (h *FormularyHandler) GetFrequencies(c *fiber.Ctx) error {
+	return c.JSON(models.Frequencies)
+}
+
+// CreateFormularyItem adds an item to the system formulary (admin) or the caller's clinic formulary
+func (h *FormularyHandler) CreateFormularyItem(c *fiber.Ctx) error {
+	var req models.CreateFormularyItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.GenericName = strings.TrimSpace(req.GenericName)
+	req.Route = strings.ToLower(strings.TrimSpace(req.Route))
+	req.DefaultFrequency = strings.ToUpper(strings.TrimSpace(req.DefaultFrequency))
+
+	if len(req.GenericName) < 2 || req.Strength == "" || req.Form == "" || req.Route == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "generic_name, strength, form and route are required",
+		})
+	}
+	if err := validateFormularyFields(req.Route, req.DefaultFrequency); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	clinicID := callerClinic(c)
+	if clinicID == nil && req.ClinicID != nil {
+		var clinic models.Clinic
+		if err := h.db.First(&clinic, *req.ClinicID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Clinic not found",
+			})
+		}
+		clinicID = req.ClinicID
+	}
+
+	item := models.FormularyItem{
+		ClinicID:         clinicID,
+		GenericName:      req.GenericName,
+		BrandName:        truncate(strings.TrimSpace(req.BrandName), 255),
+		Strength:         truncate(strings.TrimSpace(req.Strength), 50),
+		Form:             truncate(strings.ToLower(strings.TrimSpace(req.Form)), 50),
+		Route:            req.Route,
+		DefaultFrequency: req.DefaultFrequency,
+		DefaultDoseUnit:  truncate(strings.TrimSpace(req.DefaultDoseUnit), 20),
+		IsActive:         true,
+	}
+
+	if err := h.db.Create(&item).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create formulary item",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+// UpdateFormularyItem edits a formulary item or deactivates it
+func (h *FormularyHandler) UpdateFormularyItem(c *fiber.Ctx) error {
+	item, err := h.findEditableItem(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Formulary item not found",
+		})
+	}
+
+	var req models.UpdateFormularyItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.GenericName != nil {
+		if len(strings.TrimSpace(*req.GenericName)) < 2 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "generic_name must be at least 2 characters",
+			})
+		}
+		item.GenericName = truncate(strings.TrimSpace(*req.GenericName), 255)
+	}
+	if req.BrandName != nil {
+		item.BrandName = truncate(strings.TrimSpace(*req.BrandName), 255)
+	}
+	if req.Strength != nil && *req.Strength != "" {
+		item.Strength = truncate(strings.TrimSpace(*req.Strength), 50)
+	}
+	if req.Form != nil && *req.Form != "" {
+		item.Form = truncate(strings.ToLower(strings.TrimSpace(*req.Form)), 50)
+	}
+	if req.Route != nil {
+		item.Route = strings.ToLower(strings.TrimSpace(*req.Route))
+	}
+	if req.DefaultFrequency != nil {
+		item.DefaultFrequency = strings.ToUpper(strings.TrimSpace(*req.DefaultFrequency))
+	}
+	if req.DefaultDoseUnit != nil {
+		item.DefaultDoseUnit = truncate(strings.TrimSpace(*req.DefaultDoseUnit), 20)
+	}
+	if req.IsActive != nil {
+		item.IsActive = *req.IsActive
+	}
+
+	if err := validateFormularyFields(item.Route, item.DefaultFrequency); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.db.Save(item).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update formulary item",
+		})
+	}
+
+	return c.JSON(item)
+}
+
+// DeleteFormularyItem removes a formulary item. Existing prescriptions keep
+// their rendered medication name and dosage.
+func (h *FormularyHandler) DeleteFormularyItem(c *fiber.Ctx) error {
+	item, err := h.findEditableItem(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Formulary item not found",
+		})
+	}
+
+	if err := h.db.Delete(item).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete formulary item",
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
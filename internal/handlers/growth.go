@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"strings"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GrowthHandler records under-5 growth measurements and scores them against
+// the WHO growth standards
+type GrowthHandler struct {
+	db            *gorm.DB
+	standardsFile string
+	reference     *models.GrowthReference
+}
+
+func NewGrowthHandler(db *gorm.DB, standardsFile string) *GrowthHandler {
+	return &GrowthHandler{db: db, standardsFile: standardsFile}
+}
+
+// Load reads the configured growth standard tables. Until they load,
+// measurements cannot be recorded.
+func (h *GrowthHandler) Load() error {
+	reference, err := models.LoadGrowthReference(h.standardsFile)
+	if err != nil {
+		return err
+	}
+	h.reference = reference
+	return nil
+}
+
+// RecordGrowth records a child's weight, length or height, MUAC and oedema,
+// working out the z-scores and flagging acute malnutrition
+func (h *GrowthHandler) RecordGrowth(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	staffID := c.Locals("staff_id").(uint)
+
+	if h.reference == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Growth standard tables are not loaded",
+		})
+	}
+
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+	if patient.Gender != "Male" && patient.Gender != "Female" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Growth standards need the patient's gender to be Male or Female",
+		})
+	}
+
+	var req models.RecordGrowthRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	measuredOn, err := parseClinicalDate(req.MeasuredOn, "measured_on")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if measuredOn.Format("2006-01-02") < patient.DateOfBirth.Format("2006-01-02") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "measured_on cannot be before the patient's date of birth",
+		})
+	}
+	if req.VisitID != nil {
+		var count int64
+		h.db.Model(&models.Visit{}).Where("id = ? AND patient_id = ? AND clinic_id = ?", *req.VisitID, patient.ID, clinicID).Count(&count)
+		if count == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "visit_id must be a visit of this patient in this clinic",
+			})
+		}
+	}
+
+	assessment, err := h.reference.Assess(patient.Gender, patient.DateOfBirth, measuredOn, models.GrowthInput{
+		WeightKg:      req.WeightKg,
+		HeightCm:      req.HeightCm,
+		MeasuredLying: req.MeasuredLying,
+		MUACCm:        req.MUACCm,
+		Oedema:        req.Oedema,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	measurement := models.GrowthMeasurement{
+		PatientID:         patient.ID,
+		ClinicID:          clinicID,
+		VisitID:           req.VisitID,
+		MeasuredOn:        measuredOn,
+		AgeDays:           assessment.AgeDays,
+		WeightKg:          req.WeightKg,
+		HeightCm:          req.HeightCm,
+		MeasuredLying:     assessment.MeasuredLying,
+		MUACCm:            req.MUACCm,
+		Oedema:            req.Oedema,
+		WAZ:               assessment.WAZ,
+		HAZ:               assessment.HAZ,
+		WHZ:               assessment.WHZ,
+		NutritionStatus:   assessment.NutritionStatus,
+		Flags:             assessment.Flags,
+		RecordedByStaffID: &staffID,
+		Notes:             strings.TrimSpace(req.Notes),
+	}
+	if err := h.db.Create(&measurement).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record growth measurement",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityGrowth, measurement.ID, patient.ID, nil, &measurement)
+
+	h.db.Preload("RecordedBy").First(&measurement, measurement.ID)
+	return c.Status(fiber.StatusCreated).JSON(measurement)
+}
+
+// GetGrowthSeries returns a child's growth measurements, oldest first, for
+// plotting against the WHO growth charts
+func (h *GrowthHandler) GetGrowthSeries(c *fiber.Ctx) error {
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var measurements []models.GrowthMeasurement
+	if err := h.db.Preload("RecordedBy").Where("patient_id = ?", patient.ID).
+		Order("measured_on, id").Find(&measurements).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch growth measurements",
+		})
+	}
+
+	entries := make([]models.AuditLog, 0, len(measurements))
+	for _, m := range measurements {
+		entries = append(entries, newAuditEntry(c, models.AuditActionList, models.AuditEntityGrowth, m.ID, patient.ID))
+	}
+	writeAudit(h.db, entries)
+
+	series := models.GrowthSeries{
+		PatientID:    patient.ID,
+		Gender:       patient.Gender,
+		DateOfBirth:  patient.DateOfBirth,
+		Measurements: measurements,
+	}
+	if len(measurements) > 0 {
+		series.Latest = &measurements[len(measurements)-1]
+	}
+	return c.JSON(series)
+}
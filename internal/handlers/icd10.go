@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errInvalidDiagnosisCode = errors.New("invalid diagnosis_code")
+	errICD10CatalogEmpty    = errors.New("ICD-10 catalog is not loaded")
+)
+
+// icd10PrefixPattern recognises a search term that is the start of a code
+// rather than words from a description
+var icd10PrefixPattern = regexp.MustCompile(`^[A-Za-z][0-9][0-9A-Za-z.]*$`)
+
+// ICD10Handler manages the ICD-10 catalog and code search
+type ICD10Handler struct {
+	db        *gorm.DB
+	codesFile string
+}
+
+func NewICD10Handler(db *gorm.DB, codesFile string) *ICD10Handler {
+	return &ICD10Handler{db: db, codesFile: codesFile}
+}
+
+// Import loads the configured code file, upserting each code. Codes missing
+// from the file are deactivated.
+func (h *ICD10Handler) Import() (int, error) {
+	codes, err := models.LoadICD10Codes(h.codesFile)
+	if err != nil {
+		return 0, err
+	}
+
+	// A full code file is too long to list in a NOT IN, so codes the upsert
+	// did not touch are found by their update time instead
+	importedAt := time.Now()
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if len(codes) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"description", "category", "chapter", "active", "updated_at"}),
+			}).CreateInBatches(&codes, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.ICD10Code{}).
+			Where("active = ? AND updated_at < ?", true, importedAt).
+			Update("active", false).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(codes), nil
+}
+
+// lookupICD10Code resolves a diagnosis code against the active catalog. A
+// subdivision that is not listed itself is accepted when its category is,
+// and takes the category's description. Failures wrap
+// errInvalidDiagnosisCode or errICD10CatalogEmpty; anything else is a
+// database error.
+func lookupICD10Code(db *gorm.DB, code string) (*models.ICD10Code, error) {
+	normalized, err := models.NormalizeICD10Code(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidDiagnosisCode, err)
+	}
+
+	var entry models.ICD10Code
+	err = db.Where("code IN ? AND active = ?", []string{normalized, models.ICD10Category(normalized)}, true).
+		Order("LENGTH(code) DESC").First(&entry).Error
+	if err == nil {
+		if entry.Code != normalized {
+			entry.ID = 0
+			entry.Code = normalized
+		}
+		return &entry, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// An empty catalog means the import failed, not that the code is wrong
+	var count int64
+	db.Model(&models.ICD10Code{}).Where("active = ?", true).Count(&count)
+	if count == 0 {
+		return nil, errICD10CatalogEmpty
+	}
+	return nil, fmt.Errorf("%w: %s is not a known ICD-10 code", errInvalidDiagnosisCode, normalized)
+}
+
+// icd10Titles maps codes to their catalog descriptions, falling back to the
+// category's description for subdivisions that are not listed. Codes missing
+// from the catalog are left out.
+func icd10Titles(db *gorm.DB, codes []string) map[string]string {
+	lookup := make([]string, 0, len(codes)*2)
+	for _, code := range codes {
+		lookup = append(lookup, code, models.ICD10Category(code))
+	}
+	var entries []models.ICD10Code
+	if len(lookup) > 0 {
+		db.Where("code IN ?", lookup).Find(&entries)
+	}
+	descriptions := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		descriptions[entry.Code] = entry.Description
+	}
+
+	titles := make(map[string]string, len(codes))
+	for _, code := range codes {
+		if title, ok := descriptions[code]; ok {
+			titles[code] = title
+		} else if title, ok := descriptions[models.ICD10Category(code)]; ok {
+			titles[code] = title
+		}
+	}
+	return titles
+}
+
+// diagnosisCodeError maps a lookupICD10Code failure to a status and message
+func diagnosisCodeError(err error) (int, string) {
+	switch {
+	case errors.Is(err, errInvalidDiagnosisCode):
+		return fiber.StatusBadRequest, err.Error()
+	case errors.Is(err, errICD10CatalogEmpty):
+		return fiber.StatusServiceUnavailable, "ICD-10 catalog is not loaded"
+	}
+	return fiber.StatusInternalServerError, "Failed to validate diagnosis code"
+}
+
+// SearchCodes finds active codes for autocomplete. A term starting like a
+// code (letter then digit) matches code prefixes, with or without the dot;
+// anything else matches every word against the description. Supports limit
+// (default 20, at most 50).
+func (h *ICD10Handler) SearchCodes(c *fiber.Ctx) error {
+	term := strings.TrimSpace(c.Query("q"))
+	if term == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q is required",
+		})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	query := h.db.Model(&models.ICD10Code{}).Where("active = ?", true)
+	if icd10PrefixPattern.MatchString(term) {
+		prefix := strings.ToUpper(strings.ReplaceAll(term, ".", ""))
+		query = query.Where("REPLACE(code, '.', '') LIKE ?", prefix+"%")
+	} else {
+		for _, word := range strings.Fields(term) {
+			query = query.Where("description ILIKE ?", "%"+word+"%")
+		}
+	}
+
+	// Categories sort before their subdivisions
+	var codes []models.ICD10Code
+	if err := query.Order("LENGTH(code), code").Limit(limit).Find(&codes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search ICD-10 codes",
+		})
+	}
+	return c.JSON(codes)
+}
+
+// GetChapters lists the ICD-10 chapters and the categories each covers
+func (h *ICD10Handler) GetChapters(c *fiber.Ctx) error {
+	return c.JSON(models.ICD10Chapters)
+}
@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errLastDoseRecord = errors.New("only record of this dose")
+
+// ImmunizationHandler manages the vaccine schedule and patients' immunization records
+type ImmunizationHandler struct {
+	db           *gorm.DB
+	scheduleFile string
+}
+
+func NewImmunizationHandler(db *gorm.DB, scheduleFile string) *ImmunizationHandler {
+	return &ImmunizationHandler{db: db, scheduleFile: scheduleFile}
+}
+
+// Import loads the configured schedule file, upserting each vaccine by code
+// and replacing its doses. Vaccines missing from the file are deactivated.
+func (h *ImmunizationHandler) Import() (int, error) {
+	vaccines, err := models.LoadImmunizationSchedule(h.scheduleFile)
+	if err != nil {
+		return 0, err
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		codes := make([]string, 0, len(vaccines))
+		for i := range vaccines {
+			v := &vaccines[i]
+			doses := v.Doses
+			v.Doses = nil
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "diseases", "route", "active", "updated_at"}),
+			}).Create(v).Error; err != nil {
+				return err
+			}
+			// The upsert does not return the ID of an existing row
+			if err := tx.Where("code = ?", v.Code).First(v).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("vaccine_id = ?", v.ID).Delete(&models.VaccineScheduleDose{}).Error; err != nil {
+				return err
+			}
+			for j := range doses {
+				doses[j].VaccineID = v.ID
+			}
+			if err := tx.Create(&doses).Error; err != nil {
+				return err
+			}
+			codes = append(codes, v.Code)
+		}
+
+		query := tx.Model(&models.Vaccine{}).Where("active = ?", true)
+		if len(codes) > 0 {
+			query = query.Where("code NOT IN ?", codes)
+		}
+		return query.Update("active", false).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(vaccines), nil
+}
+
+// activeSchedule loads the active vaccines with their doses in order
+func (h *ImmunizationHandler) activeSchedule() ([]models.Vaccine, error) {
+	var vaccines []models.Vaccine
+	err := h.db.Preload("Doses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("dose_number")
+	}).Where("active = ?", true).Order("id").Find(&vaccines).Error
+	return vaccines, err
+}
+
+// patientImmunizations loads a patient's immunization history and works out
+// the status of every scheduled dose
+func (h *ImmunizationHandler) patientImmunizations(patient *models.Patient) (*models.PatientImmunizations, error) {
+	vaccines, err := h.activeSchedule()
+	if err != nil {
+		return nil, err
+	}
+
+	var immunizations []models.Immunization
+	if err := h.db.Preload("Vaccine").Preload("AdministeredBy").
+		Where("patient_id = ?", patient.ID).
+		Order("administered_on, id").Find(&immunizations).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.PatientImmunizations{
+		PatientID:     patient.ID,
+		DateOfBirth:   patient.DateOfBirth,
+		Immunizations: immunizations,
+		Schedule:      models.ComputeDoseStatuses(patient.DateOfBirth, vaccines, immunizations, time.Now()),
+	}, nil
+}
+
+// auditImmunizations records that a patient's immunizations were viewed
+func auditImmunizations(db *gorm.DB, c *fiber.Ctx, immunizations []models.Immunization) {
+	entries := make([]models.AuditLog, 0, len(immunizations))
+	for _, imm := range immunizations {
+		entries = append(entries, newAuditEntry(c, models.AuditActionList, models.AuditEntityImmunization, imm.ID, imm.PatientID))
+	}
+	writeAudit(db, entries)
+}
+
+// GetSchedule lists the active vaccines and when each dose is due
+func (h *ImmunizationHandler) GetSchedule(c *fiber.Ctx) error {
+	vaccines, err := h.activeSchedule()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch immunization schedule",
+		})
+	}
+	return c.JSON(vaccines)
+}
+
+// GetPatientImmunizations returns a clinic patient's immunization history and
+// which doses are given, due, overdue or upcoming
+func (h *ImmunizationHandler) GetPatientImmunizations(c *fiber.Ctx) error {
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	result, err := h.patientImmunizations(patient)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch immunizations",
+		})
+	}
+
+	auditImmunizations(h.db, c, result.Immunizations)
+
+	return c.JSON(result)
+}
+
+// RecordImmunization records a vaccine dose given to a clinic patient, either
+// just now or earlier as transcribed from the patient's vaccination card
+func (h *ImmunizationHandler) RecordImmunization(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var req models.RecordImmunizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var vaccine models.Vaccine
+	if err := h.db.Preload("Doses").Where("code = ?", strings.ToUpper(strings.TrimSpace(req.VaccineCode))).
+		First(&vaccine).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown vaccine_code",
+		})
+	}
+	if req.DoseNumber < 1 || req.DoseNumber > len(vaccine.Doses) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "dose_number must be between 1 and " + strconv.Itoa(len(vaccine.Doses)) + " for " + vaccine.Code,
+		})
+	}
+
+	administeredOn := time.Now()
+	if req.AdministeredOn != "" {
+		administeredOn, err = time.ParseInLocation("2006-01-02", req.AdministeredOn, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid administered_on format. Use YYYY-MM-DD",
+			})
+		}
+	}
+	if administeredOn.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "administered_on cannot be in the future",
+		})
+	}
+	if administeredOn.Format("2006-01-02") < patient.DateOfBirth.Format("2006-01-02") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "administered_on cannot be before the patient's date of birth",
+		})
+	}
+
+	req.Site = strings.ToLower(strings.TrimSpace(req.Site))
+	if req.Site != "" && !slices.Contains(models.ImmunizationSites, req.Site) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "site must be one of " + strings.Join(models.ImmunizationSites, ", "),
+		})
+	}
+	req.BatchNumber = strings.TrimSpace(req.BatchNumber)
+	if len(req.BatchNumber) > 50 || len(req.Notes) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "batch_number must be at most 50 and notes at most 500 characters",
+		})
+	}
+
+	// Doctors and nurses record their own doses unless they name a colleague
+	administeredBy := req.AdministeredByStaffID
+	if administeredBy == nil {
+		if userType, _ := c.Locals("user_type").(string); userType == "doctor" || userType == "nurse" {
+			staffID := c.Locals("staff_id").(uint)
+			administeredBy = &staffID
+		}
+	}
+	if administeredBy != nil {
+		var count int64
+		h.db.Model(&models.Staff{}).Where("id = ? AND clinic_id = ?", *administeredBy, clinicID).Count(&count)
+		if count == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "administered_by_staff_id must be a staff member of this clinic",
+			})
+		}
+	}
+
+	var existing int64
+	h.db.Model(&models.Immunization{}).
+		Where("patient_id = ? AND vaccine_id = ? AND dose_number = ?", patient.ID, vaccine.ID, req.DoseNumber).
+		Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": vaccine.Code + " dose " + strconv.Itoa(req.DoseNumber) + " is already recorded for this patient",
+		})
+	}
+
+	immunization := models.Immunization{
+		PatientID:             patient.ID,
+		VaccineID:             vaccine.ID,
+		DoseNumber:            req.DoseNumber,
+		AdministeredOn:        administeredOn,
+		BatchNumber:           req.BatchNumber,
+		Site:                  req.Site,
+		AdministeredByStaffID: administeredBy,
+		ClinicID:              clinicID,
+		RecordedByUserID:      currentUserID(c),
+		Notes:                 strings.TrimSpace(req.Notes),
+	}
+	if err := h.db.Create(&immunization).Error; err != nil {
+		// Another request recorded the same dose since the check above
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": vaccine.Code + " dose " + strconv.Itoa(req.DoseNumber) + " is already recorded for this patient",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record immunization",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityImmunization, immunization.ID, patient.ID, nil, &immunization)
+
+	h.db.Preload("Vaccine").Preload("AdministeredBy").First(&immunization, immunization.ID)
+	return c.Status(fiber.StatusCreated).JSON(immunization)
+}
+
+// GetDueList lists the clinic's children with doses due or overdue, or
+// falling due within the next days (default 7, i.e. "due this week")
+func (h *ImmunizationHandler) GetDueList(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	days, _ := strconv.Atoi(c.Query("days", "7"))
+	if days < 0 || days > 60 {
+		days = 7
+	}
+	includeOverdue := c.Query("include_overdue", "true") != "false"
+
+	vaccines, err := h.activeSchedule()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch immunization schedule",
+		})
+	}
+
+	// Patients past the oldest scheduled age have nothing left to be due
+	now := time.Now()
+	var patients []models.Patient
+	if err := h.db.Where("clinic_id = ? AND date_of_birth > ?", clinicID,
+		now.AddDate(0, 0, -models.ScheduleMaxAgeDays(vaccines))).
+		Order("date_of_birth DESC").Find(&patients).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch patients",
+		})
+	}
+
+	patientIDs := make([]uint, len(patients))
+	for i, p := range patients {
+		patientIDs[i] = p.ID
+	}
+	given := make(map[uint][]models.Immunization)
+	if len(patientIDs) > 0 {
+		var immunizations []models.Immunization
+		if err := h.db.Where("patient_id IN ?", patientIDs).Find(&immunizations).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch immunizations",
+			})
+		}
+		for _, imm := range immunizations {
+			given[imm.PatientID] = append(given[imm.PatientID], imm)
+		}
+	}
+
+	until := now.AddDate(0, 0, days)
+	entries := []models.ImmunizationDueEntry{}
+	var listed []models.Patient
+	for _, p := range patients {
+		doses := models.DosesDueBy(models.ComputeDoseStatuses(p.DateOfBirth, vaccines, given[p.ID], now), until)
+		if !includeOverdue {
+			filtered := doses[:0]
+			for _, d := range doses {
+				if d.Status != models.DoseStatusOverdue {
+					filtered = append(filtered, d)
+				}
+			}
+			doses = filtered
+		}
+		if len(doses) == 0 {
+			continue
+		}
+		entries = append(entries, models.ImmunizationDueEntry{
+			PatientID:   p.ID,
+			MRN:         p.MRN,
+			FullName:    p.FullName,
+			DateOfBirth: p.DateOfBirth,
+			Phone:       p.Phone,
+			Doses:       doses,
+		})
+		listed = append(listed, p)
+	}
+
+	// Earliest due dose first, so the most overdue children lead the list
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Doses[0].DueDate.Before(entries[j].Doses[0].DueDate)
+	})
+
+	auditPatients(h.db, c, models.AuditActionList, listed)
+
+	return c.JSON(fiber.Map{
+		"due_by":   until.Format("2006-01-02"),
+		"patients": entries,
+		"total":    len(entries),
+	})
+}
+
+// GetMyImmunizations returns the patient's (or a delegated child's)
+// immunization history and schedule
+func (h *ImmunizationHandler) GetMyImmunizations(c *fiber.Ctx) error {
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeViewVisits)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+
+	var patient models.Patient
+	if err := h.db.First(&patient, patientID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient profile not found",
+		})
+	}
+
+	result, err := h.patientImmunizations(&patient)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch immunizations",
+		})
+	}
+
+	auditImmunizations(h.db, c, result.Immunizations)
+
+	return c.JSON(result)
+}
+
+// GetMyUpcomingImmunizations lists the vaccine doses the patient (or a
+// delegated child) should get next: anything due or overdue, and doses
+// falling due within the next days (default 90)
+func (h *ImmunizationHandler) GetMyUpcomingImmunizations(c *fiber.Ctx) error {
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeViewVisits)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+
+	days, _ := strconv.Atoi(c.Query("days", "90"))
+	if days < 0 || days > 730 {
+		days = 90
+	}
+
+	var patient models.Patient
+	if err := h.db.First(&patient, patientID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient profile not found",
+		})
+	}
+
+	result, err := h.patientImmunizations(&patient)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch immunizations",
+		})
+	}
+
+	upcoming := models.DosesDueBy(result.Schedule, time.Now().AddDate(0, 0, days))
+	if upcoming == nil {
+		upcoming = []models.DoseStatus{}
+	}
+
+	return c.JSON(fiber.Map{
+		"patient_id": patient.ID,
+		"upcoming":   upcoming,
+	})
+}
+
+// GetVaccines - GET /vaccines (admin) lists every vaccine, including inactive ones
+func (h *ImmunizationHandler) GetVaccines(c *fiber.Ctx) error {
+	var vaccines []models.Vaccine
+	if err := h.db.Preload("Doses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("dose_number")
+	}).Order("active DESC, id").Find(&vaccines).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch vaccines",
+		})
+	}
+	return c.JSON(vaccines)
+}
+
+// ReloadSchedule - POST /vaccines/reload re-imports the schedule file
+func (h *ImmunizationHandler) ReloadSchedule(c *fiber.Ctx) error {
+	count, err := h.Import()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to import immunization schedule",
+			Details: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Immunization schedule imported",
+		"file":     h.scheduleFile,
+		"imported": count,
+	})
+}
+
+// GetDuplicateDoses - GET /immunizations/duplicates (admin) lists doses that
+// were recorded more than once before doses were unique, with every copy
+func (h *ImmunizationHandler) GetDuplicateDoses(c *fiber.Ctx) error {
+	var records []models.Immunization
+	if err := h.db.Preload("Vaccine").Preload("AdministeredBy").
+		Where(`(patient_id, vaccine_id, dose_number) IN (SELECT patient_id, vaccine_id, dose_number
+			FROM immunizations GROUP BY patient_id, vaccine_id, dose_number HAVING COUNT(*) > 1)`).
+		Order("patient_id, vaccine_id, dose_number, id").Find(&records).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch duplicate immunizations",
+		})
+	}
+
+	duplicates := []models.DuplicateDose{}
+	for _, record := range records {
+		last := len(duplicates) - 1
+		if last < 0 || duplicates[last].PatientID != record.PatientID ||
+			duplicates[last].VaccineID != record.VaccineID || duplicates[last].DoseNumber != record.DoseNumber {
+			duplicates = append(duplicates, models.DuplicateDose{
+				PatientID:  record.PatientID,
+				VaccineID:  record.VaccineID,
+				DoseNumber: record.DoseNumber,
+			})
+			last++
+		}
+		duplicates[last].Records = append(duplicates[last].Records, record)
+	}
+
+	auditImmunizations(h.db, c, records)
+	return c.JSON(duplicates)
+}
+
+// DeleteDuplicateDose - DELETE /immunizations/:id (admin) removes one copy of
+// a dose recorded more than once. The last record of a dose is never deleted.
+// Once no duplicates remain the unique dose index is created.
+func (h *ImmunizationHandler) DeleteDuplicateDose(c *fiber.Ctx) error {
+	var immunization models.Immunization
+	if err := h.db.First(&immunization, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Immunization not found",
+		})
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock every copy so two admins cannot each delete a different one
+		var copies []models.Immunization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("patient_id = ? AND vaccine_id = ? AND dose_number = ?",
+				immunization.PatientID, immunization.VaccineID, immunization.DoseNumber).
+			Find(&copies).Error; err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(copies, func(other models.Immunization) bool { return other.ID == immunization.ID }) {
+			return gorm.ErrRecordNotFound
+		}
+		if len(copies) < 2 {
+			return errLastDoseRecord
+		}
+		return tx.Delete(&immunization).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Immunization not found",
+		})
+	}
+	if errors.Is(err, errLastDoseRecord) {
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+			Error: "This is the only record of the dose and cannot be deleted",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to delete immunization",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionDelete, models.AuditEntityImmunization, immunization.ID, immunization.PatientID, &immunization, nil)
+
+	indexed, err := models.EnsureDoseIndex(h.db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Immunization deleted but the unique dose index could not be created",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message":           "Duplicate immunization deleted",
+		"dose_index_active": indexed,
+	})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeMedications resolves the prescriptions a patient is still taking today.
+// Courses are capped at 365 days, so older visits cannot contribute.
+func activeMedications(db *gorm.DB, patientID uint) ([]models.ActiveMedication, error) {
+	now := time.Now()
+
+	var prescriptions []models.Prescription
+	if err := db.Joins("Visit").
+		Where("\"Visit\".patient_id = ? AND \"Visit\".visit_date >= ?", patientID, now.AddDate(-1, 0, -1)).
+		Order("\"Visit\".visit_date, prescriptions.id").
+		Find(&prescriptions).Error; err != nil {
+		return nil, err
+	}
+
+	return models.ActiveMedications(prescriptions, now), nil
+}
+
+// patientInteractionWarnings checks a medication against the patient's active
+// medications, leaving out the prescription it replaces (0 for a new one)
+func patientInteractionWarnings(db *gorm.DB, patientID, replacing uint, medicationName string) ([]models.InteractionWarning, error) {
+	active, err := activeMedications(db, patientID)
+	if err != nil {
+		return nil, err
+	}
+	active = slices.DeleteFunc(active, func(m models.ActiveMedication) bool {
+		return replacing != 0 && m.PrescriptionID == replacing
+	})
+	if len(active) == 0 {
+		return nil, nil
+	}
+
+	// Only rules naming a drug in the new medication can match
+	var rules []models.DrugInteraction
+	medication := strings.ToLower(medicationName)
+	if err := db.Where("STRPOS(?, drug_a) > 0 OR STRPOS(?, drug_b) > 0", medication, medication).
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return models.MatchInteractions(medicationName, active, rules), nil
+}
+
+// checkPrescriptionInteractions runs the drug-drug interaction check for a new
+// prescription, or for a changed one replacing prescription ID replacing. It
+// writes the error response itself and returns ok=false when the prescription
+// must not be saved.
+func checkPrescriptionInteractions(db *gorm.DB, c *fiber.Ctx, patientID, replacing uint, req *models.CreatePrescriptionRequest) ([]models.InteractionWarning, bool, error) {
+	warnings, err := patientInteractionWarnings(db, patientID, replacing, req.MedicationName)
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check drug interactions",
+		})
+	}
+
+	req.InteractionOverrideReason = strings.TrimSpace(req.InteractionOverrideReason)
+	if !models.RequiresInteractionOverride(warnings) {
+		req.InteractionOverrideReason = ""
+		return warnings, true, nil
+	}
+
+	if req.InteractionOverrideReason == "" {
+		return warnings, false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":                "Medication has a severe interaction with an active prescription. Provide interaction_override_reason to prescribe anyway.",
+			"interaction_warnings": warnings,
+		})
+	}
+	if len(req.InteractionOverrideReason) > 500 {
+		return warnings, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "interaction_override_reason must be at most 500 characters",
+		})
+	}
+
+	return warnings, true, nil
+}
+
+// GetPatientMedications lists the medications a patient is currently taking
+func (h *MedicalPortalHandler) GetPatientMedications(c *fiber.Ctx) error {
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	active, err := activeMedications(h.db, patient.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch active medications",
+		})
+	}
+	if active == nil {
+		active = []models.ActiveMedication{}
+	}
+
+	entries := make([]models.AuditLog, 0, len(active))
+	for _, m := range active {
+		entries = append(entries, newAuditEntry(c, models.AuditActionList, models.AuditEntityPrescription, m.PrescriptionID, patient.ID))
+	}
+	writeAudit(h.db, entries)
+
+	return c.JSON(active)
+}
+
+// InteractionHandler manages the drug-drug interaction rule table
+type InteractionHandler struct {
+	db        *gorm.DB
+	rulesFile string
+}
+
+func NewInteractionHandler(db *gorm.DB, rulesFile string) *InteractionHandler {
+	return &InteractionHandler{db: db, rulesFile: rulesFile}
+}
+
+// Import loads the configured rules file and upserts every rule, returning the
+// number of rules imported. Rules missing from the file are left in place.
+func (h *InteractionHandler) Import() (int, error) {
+	rules, err := models.LoadInteractionRules(h.rulesFile)
+	if err != nil {
+		return 0, err
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	// A pair listed twice keeps its last definition; Postgres rejects
+	// upserting the same row twice in one statement
+	index := make(map[[2]string]int, len(rules))
+	unique := rules[:0]
+	for _, rule := range rules {
+		key := [2]string{rule.DrugA, rule.DrugB}
+		if i, ok := index[key]; ok {
+			unique[i] = rule
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, rule)
+	}
+	rules = unique
+
+	err = h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "drug_a"}, {Name: "drug_b"}},
+		DoUpdates: clause.AssignmentColumns([]string{"severity", "description", "management", "updated_at"}),
+	}).CreateInBatches(&rules, 100).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(rules), nil
+}
+
+// GetInteractions - GET /drug-interactions lists interaction rules
+func (h *InteractionHandler) GetInteractions(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.DrugInteraction{})
+	if drug := strings.ToLower(strings.TrimSpace(c.Query("drug"))); drug != "" {
+		query = query.Where("drug_a LIKE ? OR drug_b LIKE ?", "%"+drug+"%", "%"+drug+"%")
+	}
+	if severity := c.Query("severity"); severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var rules []models.DrugInteraction
+	if err := query.Order("drug_a, drug_b").Offset((page - 1) * perPage).Limit(perPage).Find(&rules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch drug interactions",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       rules,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
+
+// ReloadInteractions - POST /drug-interactions/reload re-imports the rules file
+func (h *InteractionHandler) ReloadInteractions(c *fiber.Ctx) error {
+	count, err := h.Import()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to import drug interactions",
+			Details: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Drug interactions imported",
+		"file":     h.rulesFile,
+		"imported": count,
+	})
+}
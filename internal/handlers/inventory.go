@@ -0,0 +1,657 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inventoryError is a stock operation failure that should be reported to the
+// caller with the given status rather than as an internal error
+type inventoryError struct {
+	status  int
+	message string
+}
+
+func (e *inventoryError) Error() string {
+	return e.message
+}
+
+func newInventoryError(status int, format string, args ...interface{}) error {
+	return &inventoryError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// inventoryErrorResponse maps stock operation errors to an HTTP response
+func inventoryErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	var invErr *inventoryError
+	if errors.As(err, &invErr) {
+		return c.Status(invErr.status).JSON(fiber.Map{
+			"error": invErr.message,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// currentUserID returns the authenticated user's ID, if any
+func currentUserID(c *fiber.Ctx) *uint {
+	if userID, ok := c.Locals("user_id").(uint); ok {
+		return &userID
+	}
+	return nil
+}
+
+// lockStockItem loads a clinic's stock item for update within a transaction
+func lockStockItem(tx *gorm.DB, clinicID, stockItemID uint) (*models.StockItem, error) {
+	var item models.StockItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND clinic_id = ?", stockItemID, clinicID).
+		First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, newInventoryError(fiber.StatusBadRequest, "Stock item %d not found in this clinic", stockItemID)
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+type InventoryHandler struct {
+	db *gorm.DB
+}
+
+func NewInventoryHandler(db *gorm.DB) *InventoryHandler {
+	return &InventoryHandler{db: db}
+}
+
+// GetStockItems lists the clinic's stock items. Supports search, low_stock=true
+// and include_inactive=true.
+func (h *InventoryHandler) GetStockItems(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.StockItem{}).Where("clinic_id = ?", clinicID)
+	if search := c.Query("search"); search != "" {
+		query = query.Where("medication_name ILIKE ?", "%"+search+"%")
+	}
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("is_active = true")
+	}
+	if c.Query("low_stock") == "true" {
+		query = query.Where("quantity_on_hand <= reorder_level")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var items []models.StockItem
+	if err := query.Order("medication_name").Offset((page - 1) * perPage).Limit(perPage).Find(&items).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch stock items",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       items,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
+
+// GetStockItem returns a stock item with its batches, earliest expiry first.
+// Empty batches are included only when include_empty=true.
+func (h *InventoryHandler) GetStockItem(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	batches := func(db *gorm.DB) *gorm.DB {
+		if c.Query("include_empty") != "true" {
+			db = db.Where("quantity_on_hand > 0")
+		}
+		return db.Order("expiry_date, id")
+	}
+
+	var item models.StockItem
+	if err := h.db.Preload("FormularyItem").Preload("Batches", batches).
+		Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID).
+		First(&item).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Stock item not found",
+		})
+	}
+
+	return c.JSON(item)
+}
+
+// CreateStockItem adds a medication to the clinic's stock list
+func (h *InventoryHandler) CreateStockItem(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var req models.CreateStockItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	name := strings.TrimSpace(req.MedicationName)
+	if req.FormularyItemID != nil {
+		var formularyItem models.FormularyItem
+		if err := h.db.Where("id = ? AND (clinic_id IS NULL OR clinic_id = ?)", *req.FormularyItemID, clinicID).
+			First(&formularyItem).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Formulary item not found",
+			})
+		}
+		name = formularyItem.DisplayName()
+	}
+
+	unit := strings.ToLower(strings.TrimSpace(req.Unit))
+	if len(name) < 2 || unit == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "medication_name (or formulary_item_id) and unit are required",
+		})
+	}
+	if req.ReorderLevel < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reorder_level must not be negative",
+		})
+	}
+
+	var existing int64
+	h.db.Model(&models.StockItem{}).Where("clinic_id = ? AND LOWER(medication_name) = LOWER(?)", clinicID, name).Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A stock item for this medication already exists",
+		})
+	}
+
+	item := models.StockItem{
+		ClinicID:        clinicID,
+		FormularyItemID: req.FormularyItemID,
+		MedicationName:  truncate(name, 255),
+		Unit:            truncate(unit, 30),
+		ReorderLevel:    req.ReorderLevel,
+		IsActive:        true,
+	}
+
+	if err := h.db.Create(&item).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create stock item",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+// UpdateStockItem changes a stock item's unit, reorder level or active flag.
+// Quantities only change through receipts and adjustments.
+func (h *InventoryHandler) UpdateStockItem(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var item models.StockItem
+	if err := h.db.Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID).First(&item).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Stock item not found",
+		})
+	}
+
+	var req models.UpdateStockItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Unit != nil {
+		unit := strings.ToLower(strings.TrimSpace(*req.Unit))
+		if unit == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unit must not be empty",
+			})
+		}
+		updates["unit"] = truncate(unit, 30)
+	}
+	if req.ReorderLevel != nil {
+		if *req.ReorderLevel < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "reorder_level must not be negative",
+			})
+		}
+		updates["reorder_level"] = *req.ReorderLevel
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&item).Updates(updates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update stock item",
+			})
+		}
+	}
+
+	h.db.First(&item, item.ID)
+	return c.JSON(item)
+}
+
+// ReceiveStock records a delivery. Each line adds to an existing batch with the
+// same lot number or creates a new batch, and writes a ledger entry.
+func (h *InventoryHandler) ReceiveStock(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var req models.ReceiveStockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if len(req.Lines) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one line is required",
+		})
+	}
+
+	now := time.Now()
+	receivedAt := now
+	if req.ReceivedAt != "" {
+		parsed, err := time.Parse("2006-01-02", req.ReceivedAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid received_at format. Use YYYY-MM-DD",
+			})
+		}
+		if parsed.After(now) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "received_at cannot be in the future",
+			})
+		}
+		receivedAt = parsed
+	}
+
+	// Validate every line before touching stock
+	expiries := make([]time.Time, len(req.Lines))
+	for i, line := range req.Lines {
+		lotNumber := strings.ToUpper(strings.TrimSpace(line.LotNumber))
+		if line.StockItemID == 0 || lotNumber == "" || line.Quantity <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Line %d: stock_item_id, lot_number and a positive quantity are required", i+1),
+			})
+		}
+		expiry, err := models.ParseExpiryDate(line.ExpiryDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Line %d: %s", i+1, err.Error()),
+			})
+		}
+		if (models.StockBatch{ExpiryDate: expiry}).IsExpired(now) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Line %d: batch %s has already expired", i+1, lotNumber),
+			})
+		}
+		req.Lines[i].LotNumber = truncate(lotNumber, 50)
+		expiries[i] = expiry
+	}
+
+	receipt := models.StockReceipt{
+		ClinicID:         clinicID,
+		Supplier:         truncate(strings.TrimSpace(req.Supplier), 255),
+		ReferenceNumber:  truncate(strings.TrimSpace(req.ReferenceNumber), 100),
+		ReceivedAt:       receivedAt,
+		Notes:            truncate(strings.TrimSpace(req.Notes), 1000),
+		ReceivedByUserID: currentUserID(c),
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&receipt).Error; err != nil {
+			return err
+		}
+
+		for i, line := range req.Lines {
+			item, err := lockStockItem(tx, clinicID, line.StockItemID)
+			if err != nil {
+				return err
+			}
+
+			var batch models.StockBatch
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("stock_item_id = ? AND lot_number = ?", item.ID, line.LotNumber).
+				Limit(1).Find(&batch)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				batch = models.StockBatch{
+					StockItemID:      item.ID,
+					LotNumber:        line.LotNumber,
+					ExpiryDate:       expiries[i],
+					QuantityReceived: line.Quantity,
+					QuantityOnHand:   line.Quantity,
+				}
+				if err := tx.Create(&batch).Error; err != nil {
+					return err
+				}
+			} else {
+				if !batch.ExpiryDate.Equal(expiries[i]) {
+					return newInventoryError(fiber.StatusBadRequest, "Line %d: lot %s is already recorded with expiry %s",
+						i+1, line.LotNumber, batch.ExpiryDate.Format("2006-01-02"))
+				}
+				batch.QuantityReceived += line.Quantity
+				batch.QuantityOnHand += line.Quantity
+				if err := tx.Model(&batch).Updates(map[string]interface{}{
+					"quantity_received": batch.QuantityReceived,
+					"quantity_on_hand":  batch.QuantityOnHand,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			item.QuantityOnHand += line.Quantity
+			if err := tx.Model(item).Update("quantity_on_hand", item.QuantityOnHand).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Create(&models.StockReceiptLine{
+				StockReceiptID: receipt.ID,
+				StockItemID:    item.ID,
+				StockBatchID:   batch.ID,
+				Quantity:       line.Quantity,
+			}).Error; err != nil {
+				return err
+			}
+
+			batchBalance := batch.QuantityOnHand
+			if err := tx.Create(&models.StockTransaction{
+				ClinicID:          clinicID,
+				StockItemID:       item.ID,
+				StockBatchID:      &batch.ID,
+				Type:              models.StockTransactionReceipt,
+				Quantity:          line.Quantity,
+				BalanceAfter:      item.QuantityOnHand,
+				BatchBalanceAfter: &batchBalance,
+				ReferenceID:       &receipt.ID,
+				UserID:            receipt.ReceivedByUserID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return inventoryErrorResponse(c, err, "Failed to record stock receipt")
+	}
+
+	h.db.Preload("Lines.StockItem").Preload("Lines.StockBatch").First(&receipt, receipt.ID)
+	return c.Status(fiber.StatusCreated).JSON(receipt)
+}
+
+// GetStockReceipts lists the clinic's stock receipts, newest first
+func (h *InventoryHandler) GetStockReceipts(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := h.db.Model(&models.StockReceipt{}).Where("clinic_id = ?", clinicID)
+
+	var total int64
+	query.Count(&total)
+
+	var receipts []models.StockReceipt
+	if err := query.Preload("Lines.StockItem").Preload("Lines.StockBatch").
+		Order("received_at DESC, id DESC").Offset((page - 1) * perPage).Limit(perPage).
+		Find(&receipts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch stock receipts",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       receipts,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
+
+// AdjustStock applies a signed correction to one batch (damage, expiry write-off,
+// stock count corrections) and records it in the ledger
+func (h *InventoryHandler) AdjustStock(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var req models.StockAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.ToLower(strings.TrimSpace(req.Reason))
+	if req.StockBatchID == 0 || req.Quantity == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "stock_batch_id and a non-zero quantity are required",
+		})
+	}
+	if !models.IsValidAdjustmentReason(req.Reason) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reason must be one of " + strings.Join(models.StockAdjustmentReasons, ", "),
+		})
+	}
+	if req.Reason == "other" && strings.TrimSpace(req.Notes) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "notes are required when the reason is other",
+		})
+	}
+
+	var entry models.StockTransaction
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the item before the batch, in the same order as receiving and
+		// dispensing, so concurrent stock changes cannot deadlock
+		var batch models.StockBatch
+		if err := tx.Select("stock_batches.stock_item_id").
+			Joins("JOIN stock_items ON stock_items.id = stock_batches.stock_item_id").
+			Where("stock_batches.id = ? AND stock_items.clinic_id = ?", req.StockBatchID, clinicID).
+			First(&batch).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return newInventoryError(fiber.StatusNotFound, "Stock batch not found")
+			}
+			return err
+		}
+
+		item, err := lockStockItem(tx, clinicID, batch.StockItemID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND stock_item_id = ?", req.StockBatchID, item.ID).
+			First(&batch).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return newInventoryError(fiber.StatusNotFound, "Stock batch not found")
+			}
+			return err
+		}
+
+		batchBalance, itemBalance, err := models.ApplyStockChange(batch.QuantityOnHand, item.QuantityOnHand, req.Quantity)
+		if err != nil {
+			return newInventoryError(fiber.StatusConflict, "%s", err.Error())
+		}
+
+		if err := tx.Model(&batch).Update("quantity_on_hand", batchBalance).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(item).Update("quantity_on_hand", itemBalance).Error; err != nil {
+			return err
+		}
+
+		entry = models.StockTransaction{
+			ClinicID:          clinicID,
+			StockItemID:       item.ID,
+			StockBatchID:      &batch.ID,
+			Type:              models.StockTransactionAdjustment,
+			Quantity:          req.Quantity,
+			BalanceAfter:      itemBalance,
+			BatchBalanceAfter: &batchBalance,
+			Reason:            req.Reason,
+			Notes:             truncate(strings.TrimSpace(req.Notes), 500),
+			UserID:            currentUserID(c),
+		}
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		return inventoryErrorResponse(c, err, "Failed to adjust stock")
+	}
+
+	h.db.Preload("StockItem").Preload("StockBatch").First(&entry, entry.ID)
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// GetStockLedger lists stock movements, newest first. Supports stock_item_id,
+// type, date_from and date_to filters.
+func (h *InventoryHandler) GetStockLedger(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.StockTransaction{}).Where("clinic_id = ?", clinicID)
+	if stockItemID := c.Query("stock_item_id"); stockItemID != "" {
+		query = query.Where("stock_item_id = ?", stockItemID)
+	}
+	if txType := c.Query("type"); txType != "" {
+		query = query.Where("type = ?", txType)
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		from, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date_from format. Use YYYY-MM-DD",
+			})
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		to, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date_to format. Use YYYY-MM-DD",
+			})
+		}
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var entries []models.StockTransaction
+	if err := query.Preload("StockItem").Preload("StockBatch").
+		Order("created_at DESC, id DESC").Offset((page - 1) * perPage).Limit(perPage).
+		Find(&entries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch stock ledger",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       entries,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
+
+// GetLowStock lists active items at or below their reorder level, emptiest first
+func (h *InventoryHandler) GetLowStock(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var items []models.StockItem
+	if err := h.db.Where("clinic_id = ? AND is_active = true AND quantity_on_hand <= reorder_level", clinicID).
+		Order("quantity_on_hand, medication_name").
+		Find(&items).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch low stock items",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+// GetNearExpiry lists batches with stock left that expire within the given
+// number of days (default 90), including batches already expired
+func (h *InventoryHandler) GetNearExpiry(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	days, err := strconv.Atoi(c.Query("days", "90"))
+	if err != nil || days < 0 || days > 730 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "days must be between 0 and 730",
+		})
+	}
+
+	now := time.Now()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+
+	var batches []models.StockBatch
+	if err := h.db.Preload("StockItem").
+		Joins("JOIN stock_items ON stock_items.id = stock_batches.stock_item_id").
+		Where("stock_items.clinic_id = ? AND stock_batches.quantity_on_hand > 0 AND stock_batches.expiry_date <= ?", clinicID, cutoff).
+		Order("stock_batches.expiry_date, stock_batches.id").
+		Find(&batches).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch expiring batches",
+		})
+	}
+
+	type expiringBatch struct {
+		models.StockBatch
+		Expired bool `json:"expired"`
+	}
+
+	response := make([]expiringBatch, 0, len(batches))
+	for _, b := range batches {
+		response = append(response, expiringBatch{StockBatch: b, Expired: b.IsExpired(now)})
+	}
+
+	return c.JSON(fiber.Map{
+		"days":    days,
+		"batches": response,
+		"count":   len(response),
+	})
+}
@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errLabOrderChanged = errors.New("lab order status changed concurrently")
+
+// LabHandler manages the lab test catalog, orders placed during visits and
+// their results
+type LabHandler struct {
+	db          *gorm.DB
+	catalogFile string
+}
+
+func NewLabHandler(db *gorm.DB, catalogFile string) *LabHandler {
+	return &LabHandler{db: db, catalogFile: catalogFile}
+}
+
+// Import loads the configured catalog file, upserting each test by code and
+// replacing its components and reference ranges. Tests missing from the file
+// are deactivated.
+func (h *LabHandler) Import() (int, error) {
+	tests, err := models.LoadLabCatalog(h.catalogFile)
+	if err != nil {
+		return 0, err
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		codes := make([]string, 0, len(tests))
+		for i := range tests {
+			t := &tests[i]
+			components := t.Components
+			t.Components = nil
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "specimen", "active", "updated_at"}),
+			}).Create(t).Error; err != nil {
+				return err
+			}
+			// The upsert does not return the ID of an existing row
+			if err := tx.Where("code = ?", t.Code).First(t).Error; err != nil {
+				return err
+			}
+
+			existing := tx.Model(&models.LabTestComponent{}).Select("id").Where("lab_test_id = ?", t.ID)
+			if err := tx.Where("component_id IN (?)", existing).Delete(&models.LabReferenceRange{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("lab_test_id = ?", t.ID).Delete(&models.LabTestComponent{}).Error; err != nil {
+				return err
+			}
+			for j := range components {
+				components[j].LabTestID = t.ID
+			}
+			// Reference ranges are created with their components
+			if err := tx.Create(&components).Error; err != nil {
+				return err
+			}
+			codes = append(codes, t.Code)
+		}
+
+		query := tx.Model(&models.LabTest{}).Where("active = ?", true)
+		if len(codes) > 0 {
+			query = query.Where("code NOT IN ?", codes)
+		}
+		return query.Update("active", false).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(tests), nil
+}
+
+// preloadLabTestComponents loads a test's components in report order with their ranges
+func preloadLabTestComponents(db *gorm.DB) *gorm.DB {
+	return db.Order("position").Preload("Ranges", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// preloadLabResults loads an order's results in report order
+func preloadLabResults(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// findClinicLabOrder loads a lab order placed at the caller's clinic
+func findClinicLabOrder(db *gorm.DB, c *fiber.Ctx) (*models.LabOrder, error) {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var order models.LabOrder
+	if err := db.Preload("LabTest").
+		Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID).
+		First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// transitionLabOrder moves an order on from the status it was loaded with.
+// The status condition guards against two staff members acting on the same
+// order at once.
+func transitionLabOrder(tx *gorm.DB, order *models.LabOrder, updates map[string]interface{}) error {
+	result := tx.Model(&models.LabOrder{}).
+		Where("id = ? AND status = ?", order.ID, order.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errLabOrderChanged
+	}
+	return nil
+}
+
+// loadLabOrder reloads an order with everything shown on a lab report
+func (h *LabHandler) loadLabOrder(order *models.LabOrder) {
+	h.db.Preload("Patient").Preload("LabTest").Preload("OrderedBy").Preload("CollectedBy").Preload("ResultedBy").
+		Preload("Results", preloadLabResults).
+		First(order, order.ID)
+}
+
+// auditLabOrders records that lab orders were viewed
+func auditLabOrders(db *gorm.DB, c *fiber.Ctx, action string, orders []models.LabOrder) {
+	entries := make([]models.AuditLog, 0, len(orders))
+	for _, o := range orders {
+		entries = append(entries, newAuditEntry(c, action, models.AuditEntityLabOrder, o.ID, o.PatientID))
+	}
+	writeAudit(db, entries)
+}
+
+// GetCatalog lists the active lab tests with their components and reference ranges
+func (h *LabHandler) GetCatalog(c *fiber.Ctx) error {
+	var tests []models.LabTest
+	if err := h.db.Preload("Components", preloadLabTestComponents).
+		Where("active = ?", true).Order("name").Find(&tests).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch lab catalog",
+		})
+	}
+	return c.JSON(tests)
+}
+
+// CreateLabOrders orders one or more catalog tests for a visit in this clinic
+func (h *LabHandler) CreateLabOrders(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	staffID := c.Locals("staff_id").(uint)
+
+	var visit models.Visit
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", c.Params("id")).First(&visit).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Visit not found in this clinic",
+		})
+	}
+
+	var req models.CreateLabOrdersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := req.Normalize(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var tests []models.LabTest
+	if err := h.db.Where("code IN ? AND active = ?", req.TestCodes, true).Find(&tests).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch lab catalog",
+		})
+	}
+	byCode := make(map[string]models.LabTest, len(tests))
+	for _, t := range tests {
+		byCode[t.Code] = t
+	}
+	var unknown []string
+	for _, code := range req.TestCodes {
+		if _, ok := byCode[code]; !ok {
+			unknown = append(unknown, code)
+		}
+	}
+	if len(unknown) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown or inactive lab tests: " + strings.Join(unknown, ", "),
+		})
+	}
+
+	now := time.Now()
+	orders := make([]models.LabOrder, 0, len(req.TestCodes))
+	for _, code := range req.TestCodes {
+		orders = append(orders, models.LabOrder{
+			VisitID:          visit.ID,
+			PatientID:        visit.PatientID,
+			ClinicID:         clinicID,
+			LabTestID:        byCode[code].ID,
+			Status:           models.LabOrderStatusOrdered,
+			Priority:         req.Priority,
+			ClinicalNotes:    req.ClinicalNotes,
+			OrderedByStaffID: staffID,
+			OrderedAt:        now,
+		})
+	}
+	if err := h.db.Create(&orders).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create lab orders",
+		})
+	}
+
+	for i := range orders {
+		recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityLabOrder, orders[i].ID, orders[i].PatientID, nil, &orders[i])
+		t := byCode[req.TestCodes[i]]
+		orders[i].LabTest = &t
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(orders)
+}
+
+// GetVisitLabOrders returns the lab orders of a visit with any results
+func (h *LabHandler) GetVisitLabOrders(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var visit models.Visit
+	if err := h.db.Scopes(clinicVisits(clinicID)).Where("id = ?", c.Params("id")).First(&visit).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Visit not found",
+		})
+	}
+
+	var orders []models.LabOrder
+	if err := h.db.Preload("LabTest").Preload("OrderedBy").Preload("Results", preloadLabResults).
+		Where("visit_id = ?", visit.ID).Order("ordered_at, id").Find(&orders).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch lab orders",
+		})
+	}
+
+	auditLabOrders(h.db, c, models.AuditActionList, orders)
+
+	return c.JSON(orders)
+}
+
+// GetLabOrder returns a lab order of this clinic with its results
+func (h *LabHandler) GetLabOrder(c *fiber.Ctx) error {
+	order, err := findClinicLabOrder(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Lab order not found",
+		})
+	}
+
+	h.loadLabOrder(order)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityLabOrder, order.ID, order.PatientID, nil, nil)
+
+	return c.JSON(order)
+}
+
+// GetWorklist lists the clinic's pending lab orders, urgent first then oldest.
+// Supports status (ordered or collected) and priority filters.
+func (h *LabHandler) GetWorklist(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	query := h.db.Preload("Patient").Preload("LabTest").Preload("OrderedBy").
+		Joins("JOIN patients ON patients.id = lab_orders.patient_id AND patients.deleted_at IS NULL").
+		Where("lab_orders.clinic_id = ?", clinicID)
+
+	switch status := c.Query("status"); status {
+	case "":
+		query = query.Where("lab_orders.status IN ?", []string{models.LabOrderStatusOrdered, models.LabOrderStatusCollected})
+	case models.LabOrderStatusOrdered, models.LabOrderStatusCollected:
+		query = query.Where("lab_orders.status = ?", status)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be ordered or collected",
+		})
+	}
+	switch priority := c.Query("priority"); priority {
+	case "":
+	case models.LabPriorityRoutine, models.LabPriorityUrgent:
+		query = query.Where("lab_orders.priority = ?", priority)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "priority must be routine or urgent",
+		})
+	}
+
+	var orders []models.LabOrder
+	if err := query.Order("CASE WHEN lab_orders.priority = 'urgent' THEN 0 ELSE 1 END, lab_orders.ordered_at, lab_orders.id").
+		Find(&orders).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch lab worklist",
+		})
+	}
+
+	entries := make([]models.LabWorklistEntry, 0, len(orders))
+	for _, o := range orders {
+		entry := models.LabWorklistEntry{
+			LabOrderID:    o.ID,
+			VisitID:       o.VisitID,
+			PatientID:     o.PatientID,
+			Status:        o.Status,
+			Priority:      o.Priority,
+			ClinicalNotes: o.ClinicalNotes,
+			OrderedAt:     o.OrderedAt,
+			CollectedAt:   o.CollectedAt,
+		}
+		if o.Patient != nil {
+			entry.MRN, entry.FullName = o.Patient.MRN, o.Patient.FullName
+		}
+		if o.LabTest != nil {
+			entry.TestCode, entry.TestName, entry.Specimen = o.LabTest.Code, o.LabTest.Name, o.LabTest.Specimen
+		}
+		if o.OrderedBy != nil {
+			entry.OrderedBy = o.OrderedBy.FullName
+		}
+		entries = append(entries, entry)
+	}
+
+	auditLabOrders(h.db, c, models.AuditActionList, orders)
+
+	return c.JSON(fiber.Map{
+		"worklist": entries,
+		"total":    len(entries),
+	})
+}
+
+// CollectSpecimen records that the specimen for an order has been taken
+func (h *LabHandler) CollectSpecimen(c *fiber.Ctx) error {
+	staffID := c.Locals("staff_id").(uint)
+
+	order, err := findClinicLabOrder(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Lab order not found",
+		})
+	}
+	if err := models.CheckLabOrderTransition(order.Status, models.LabOrderStatusCollected); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	before := *order
+	err = transitionLabOrder(h.db, order, map[string]interface{}{
+		"status":                models.LabOrderStatusCollected,
+		"collected_at":          time.Now(),
+		"collected_by_staff_id": staffID,
+	})
+	if err == errLabOrderChanged {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Lab order was updated by someone else, reload and try again",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update lab order",
+		})
+	}
+
+	h.loadLabOrder(order)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityLabOrder, order.ID, order.PatientID, &before, order)
+
+	return c.JSON(order)
+}
+
+// RecordResults records an order's results, flagging each against its
+// reference range for the patient, and finalizes the order
+func (h *LabHandler) RecordResults(c *fiber.Ctx) error {
+	staffID := c.Locals("staff_id").(uint)
+
+	order, err := findClinicLabOrder(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Lab order not found",
+		})
+	}
+	if err := models.CheckLabOrderTransition(order.Status, models.LabOrderStatusResulted); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var req models.RecordLabResultsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	if len(req.Notes) > 1000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "notes must be at most 1000 characters",
+		})
+	}
+
+	var test models.LabTest
+	if err := h.db.Preload("Components", preloadLabTestComponents).First(&test, order.LabTestID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch lab test",
+		})
+	}
+	var patient models.Patient
+	if err := h.db.First(&patient, order.PatientID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	now := time.Now()
+	results, err := models.EvaluateLabResults(test, patient.Gender, patient.DateOfBirth, now, req.Results)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	abnormal, critical := models.SummarizeLabResults(results)
+
+	before := *order
+	updates := map[string]interface{}{
+		"status":               models.LabOrderStatusResulted,
+		"resulted_at":          now,
+		"resulted_by_staff_id": staffID,
+		"result_notes":         req.Notes,
+		"abnormal":             abnormal,
+		"critical":             critical,
+	}
+	// Point-of-care tests are collected and resulted in one step
+	if order.CollectedAt == nil {
+		updates["collected_at"] = now
+		updates["collected_by_staff_id"] = staffID
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := transitionLabOrder(tx, order, updates); err != nil {
+			return err
+		}
+		for i := range results {
+			results[i].LabOrderID = order.ID
+		}
+		return tx.Create(&results).Error
+	})
+	if err == errLabOrderChanged {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Lab order was updated by someone else, reload and try again",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record lab results",
+		})
+	}
+
+	h.loadLabOrder(order)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityLabOrder, order.ID, order.PatientID, &before, order)
+
+	return c.JSON(order)
+}
+
+// CancelLabOrder cancels an order that has not been resulted
+func (h *LabHandler) CancelLabOrder(c *fiber.Ctx) error {
+	order, err := findClinicLabOrder(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Lab order not found",
+		})
+	}
+	if err := models.CheckLabOrderTransition(order.Status, models.LabOrderStatusCancelled); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var req models.CancelLabOrderRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	before := *order
+	err = transitionLabOrder(h.db, order, map[string]interface{}{
+		"status":        models.LabOrderStatusCancelled,
+		"cancelled_at":  time.Now(),
+		"cancel_reason": truncate(strings.TrimSpace(req.Reason), 500),
+	})
+	if err == errLabOrderChanged {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Lab order was updated by someone else, reload and try again",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update lab order",
+		})
+	}
+
+	h.loadLabOrder(order)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityLabOrder, order.ID, order.PatientID, &before, order)
+
+	return c.JSON(order)
+}
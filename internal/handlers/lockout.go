@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"rural_health_management_system/internal/models"
+	"rural_health_management_system/internal/notify"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ipWindow counts one IP address's requests in the current window
+type ipWindow struct {
+	start time.Time
+	count int
+}
+
+// AuthRateLimiter limits how many requests each IP address can make to the
+// registration and credential endpoints within a fixed window, slowing down
+// password guessing spread across many accounts and email enumeration through
+// registration. Counts are kept in memory, per server process.
+// Behind a reverse proxy the client IP is only correct when the proxy is
+// listed in TRUSTED_PROXIES.
+func AuthRateLimiter(max int, window time.Duration) fiber.Handler {
+	var mu sync.Mutex
+	windows := make(map[string]*ipWindow)
+	lastSweep := time.Now()
+
+	return func(c *fiber.Ctx) error {
+		ip := c.IP()
+		now := time.Now()
+
+		mu.Lock()
+		// Drop lapsed windows now and then so the map does not grow without bound
+		if now.Sub(lastSweep) > window {
+			for key, w := range windows {
+				if now.Sub(w.start) >= window {
+					delete(windows, key)
+				}
+			}
+			lastSweep = now
+		}
+		w, ok := windows[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &ipWindow{start: now}
+			windows[ip] = w
+		}
+		w.count++
+		count, resetAt := w.count, w.start.Add(window)
+		mu.Unlock()
+
+		if count > max {
+			if count == max+1 {
+				log.Printf("Auth rate limit reached for %s on %s", ip, c.Path())
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(resetAt.Sub(now).Seconds())+1))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many requests. Please wait a moment and try again.",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// recordAuthEvent appends to the auth event log. A failed write is logged
+// rather than failing the sign-in.
+func recordAuthEvent(db *gorm.DB, c *fiber.Ctx, userID *uint, email, eventType, detail string) {
+	event := models.AuthEvent{
+		UserID:    userID,
+		Email:     truncate(email, 255),
+		EventType: eventType,
+		IPAddress: c.IP(),
+		UserAgent: truncate(c.Get("User-Agent"), 255),
+		Detail:    truncate(detail, 255),
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record auth event %s for %q: %v", eventType, email, err)
+	}
+}
+
+// lockedResponse refuses a sign-in attempt on a locked account. The reply is
+// the same as for a wrong password so that it does not reveal which accounts
+// exist; the owner learns of the lock from the notice sent when it started.
+func (h *AuthHandler) lockedResponse(c *fiber.Ctx, user *models.User) error {
+	recordAuthEvent(h.db, c, &user.ID, user.Email, models.AuthEventLoginBlocked, "")
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": "Invalid credentials",
+	})
+}
+
+// recordFailedLogin counts a wrong password or MFA code against the user,
+// locking the account once the policy's limit is reached
+func (h *AuthHandler) recordFailedLogin(c *fiber.Ctx, user *models.User, eventType string) {
+	var lockedUntil *time.Time
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var current models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, user.ID).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"failed_login_attempts": current.FailedLoginAttempts + 1}
+		if current.FailedLoginAttempts+1 >= h.lockout.MaxAttempts {
+			until := time.Now().Add(h.lockout.Duration(current.LockoutCount))
+			updates = map[string]interface{}{
+				"failed_login_attempts": 0,
+				"lockout_count":         current.LockoutCount + 1,
+				"locked_until":          until,
+			}
+			lockedUntil = &until
+		}
+		return tx.Model(&current).Updates(updates).Error
+	})
+	if err != nil {
+		log.Printf("Failed to record failed sign-in for user %d: %v", user.ID, err)
+	}
+
+	recordAuthEvent(h.db, c, &user.ID, user.Email, eventType, "")
+	if lockedUntil == nil {
+		return
+	}
+
+	recordAuthEvent(h.db, c, &user.ID, user.Email, models.AuthEventAccountLocked,
+		"locked until "+lockedUntil.Format(time.RFC3339))
+	msg := notify.Message{
+		Channel: notify.ChannelEmail,
+		To:      user.Email,
+		Subject: "Account locked",
+		Body: fmt.Sprintf("Your account was locked until %s after repeated failed sign-in attempts from %s. If this was not you, reset your password once the lock ends or contact your administrator.",
+			lockedUntil.Format("2006-01-02 15:04 MST"), c.IP()),
+	}
+	if err := h.notifier.Send(msg); err != nil {
+		log.Printf("Failed to send lockout notice to user %d: %v", user.ID, err)
+	}
+}
+
+// recordLoginSuccess clears the user's failed sign-in counters
+func (h *AuthHandler) recordLoginSuccess(c *fiber.Ctx, user *models.User) {
+	if user.FailedLoginAttempts > 0 || user.LockoutCount > 0 || user.LockedUntil != nil {
+		if err := h.db.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"lockout_count":         0,
+			"locked_until":          nil,
+		}).Error; err != nil {
+			log.Printf("Failed to reset sign-in counters for user %d: %v", user.ID, err)
+		}
+	}
+	recordAuthEvent(h.db, c, &user.ID, user.Email, models.AuthEventLoginSuccess, "")
+}
+
+// UnlockAccount - POST /users/:id/unlock (admin) clears a lockout and the failed attempt count
+func (h *AuthHandler) UnlockAccount(c *fiber.Ctx) error {
+	var user models.User
+	if err := h.db.First(&user, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "User not found",
+		})
+	}
+
+	if err := h.db.Model(&user).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"lockout_count":         0,
+		"locked_until":          nil,
+	}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to unlock account",
+		})
+	}
+
+	recordAuthEvent(h.db, c, &user.ID, user.Email, models.AuthEventAccountUnlocked,
+		"unlocked by user "+strconv.FormatUint(uint64(c.Locals("user_id").(uint)), 10))
+
+	return c.JSON(fiber.Map{
+		"message": "Account unlocked",
+		"user_id": user.ID,
+		"email":   user.Email,
+	})
+}
+
+// GetAuthEvents - GET /auth-events (admin) with filters user_id, email,
+// event_type, ip_address, date_from and date_to
+func (h *AuthHandler) GetAuthEvents(c *fiber.Ctx) error {
+	query := h.db.Model(&models.AuthEvent{})
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if email := c.Query("email"); email != "" {
+		query = query.Where("email = ?", email)
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if ip := c.Query("ip_address"); ip != "" {
+		query = query.Where("ip_address = ?", ip)
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		from, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid date format",
+				Details: "Use YYYY-MM-DD",
+			})
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		to, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid date format",
+				Details: "Use YYYY-MM-DD",
+			})
+		}
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.AuthEvent
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).Limit(perPage).Find(&events).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch auth events",
+		})
+	}
+
+	return c.JSON(models.PaginationResponse{
+		Data:       events,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
@@ -0,0 +1,688 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var errPregnancyNotActive = errors.New("pregnancy is not active")
+
+// MaternalHandler manages pregnancy episodes: antenatal contacts, the
+// outcome and postnatal visits
+type MaternalHandler struct {
+	db *gorm.DB
+}
+
+func NewMaternalHandler(db *gorm.DB) *MaternalHandler {
+	return &MaternalHandler{db: db}
+}
+
+// findClinicPregnancy loads a pregnancy of a patient currently registered at
+// the caller's clinic, along with the patient
+func findClinicPregnancy(db *gorm.DB, c *fiber.Ctx) (*models.Pregnancy, error) {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var pregnancy models.Pregnancy
+	if err := db.Preload("Patient").
+		Joins("JOIN patients ON patients.id = pregnancies.patient_id AND patients.deleted_at IS NULL").
+		Where("pregnancies.id = ? AND patients.clinic_id = ?", c.Params("id"), clinicID).
+		First(&pregnancy).Error; err != nil {
+		return nil, err
+	}
+	return &pregnancy, nil
+}
+
+// refreshPregnancy recomputes the pregnancy's derived columns from its
+// antenatal contacts and saves them
+func refreshPregnancy(tx *gorm.DB, pregnancy *models.Pregnancy, dob time.Time) error {
+	var contacts []models.AntenatalContact
+	if err := tx.Where("pregnancy_id = ?", pregnancy.ID).Find(&contacts).Error; err != nil {
+		return err
+	}
+	pregnancy.Refresh(dob, contacts)
+	return tx.Model(pregnancy).
+		Select("lmp", "edd", "risk_flags", "high_risk", "next_anc_due").
+		Updates(pregnancy).Error
+}
+
+// loadPregnancyDetail loads a pregnancy's contacts and postnatal visits in order
+func (h *MaternalHandler) loadPregnancyDetail(pregnancy *models.Pregnancy) error {
+	return h.db.Preload("AntenatalContacts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("contact_date, id")
+	}).Preload("AntenatalContacts.RecordedBy").Preload("PostnatalVisits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("visit_date, id")
+	}).Preload("PostnatalVisits.RecordedBy").First(pregnancy, pregnancy.ID).Error
+}
+
+// formatContacts fills in the display gestational age of each contact
+func formatContacts(contacts []models.AntenatalContact) {
+	for i := range contacts {
+		contacts[i].GestationalAge = models.FormatGestationalAge(contacts[i].GestationalAgeDays)
+	}
+}
+
+// pregnancyResponse adds the current gestational age and ANC status to a pregnancy
+func pregnancyResponse(pregnancy *models.Pregnancy) fiber.Map {
+	formatContacts(pregnancy.AntenatalContacts)
+	response := fiber.Map{
+		"pregnancy":   pregnancy,
+		"anc_overdue": pregnancy.Status == models.PregnancyStatusActive && models.ANCOverdue(pregnancy.NextANCDue, time.Now()),
+	}
+	if pregnancy.Status == models.PregnancyStatusActive {
+		days := models.GestationalAgeDays(pregnancy.LMP, time.Now())
+		response["gestational_age_days"] = days
+		response["gestational_age"] = models.FormatGestationalAge(days)
+	}
+	return response
+}
+
+// checkPatientVisit checks an optional linked visit belongs to the patient and this clinic
+func (h *MaternalHandler) checkPatientVisit(visitID *uint, patientID, clinicID uint) bool {
+	if visitID == nil {
+		return true
+	}
+	var count int64
+	h.db.Model(&models.Visit{}).Where("id = ? AND patient_id = ? AND clinic_id = ?", *visitID, patientID, clinicID).Count(&count)
+	return count > 0
+}
+
+// parseClinicalDate parses an optional YYYY-MM-DD date, defaulting to today,
+// and rejects dates in the future
+func parseClinicalDate(value, field string) (time.Time, error) {
+	date := time.Now()
+	if value != "" {
+		var err error
+		date, err = time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return date, errors.New("Invalid " + field + " format. Use YYYY-MM-DD")
+		}
+	}
+	if date.After(time.Now()) {
+		return date, errors.New(field + " cannot be in the future")
+	}
+	return date, nil
+}
+
+// GetPatientPregnancies lists a clinic patient's pregnancies, latest first,
+// with their antenatal contacts and postnatal visits
+func (h *MaternalHandler) GetPatientPregnancies(c *fiber.Ctx) error {
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var pregnancies []models.Pregnancy
+	if err := h.db.Preload("AntenatalContacts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("contact_date, id")
+	}).Preload("PostnatalVisits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("visit_date, id")
+	}).Where("patient_id = ?", patient.ID).Order("lmp DESC").Find(&pregnancies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch pregnancies",
+		})
+	}
+
+	entries := make([]models.AuditLog, 0, len(pregnancies))
+	for i := range pregnancies {
+		formatContacts(pregnancies[i].AntenatalContacts)
+		entries = append(entries, newAuditEntry(c, models.AuditActionList, models.AuditEntityPregnancy, pregnancies[i].ID, patient.ID))
+	}
+	writeAudit(h.db, entries)
+
+	return c.JSON(fiber.Map{
+		"patient_id":  patient.ID,
+		"pregnancies": pregnancies,
+	})
+}
+
+// RegisterPregnancy opens a pregnancy episode for a clinic patient. A patient
+// can have only one active pregnancy.
+func (h *MaternalHandler) RegisterPregnancy(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	patient, err := findClinicPatient(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+	if patient.Gender != "Female" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Pregnancies can only be registered for female patients",
+		})
+	}
+
+	var req models.CreatePregnancyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.LMP == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "lmp is required",
+		})
+	}
+	lmp, err := parseClinicalDate(req.LMP, "lmp")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if models.GestationalAgeDays(lmp, time.Now()) > models.MaxGestationalAgeDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "lmp is more than 44 weeks ago",
+		})
+	}
+	if err := models.ValidateObstetricHistory(req.Gravida, req.Parity); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	risks, err := models.NormalizeClinicalFlags(req.ReportedRisks, models.ReportedPregnancyRisks)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reported_risks must be from " + strings.Join(models.ReportedPregnancyRisks, ", "),
+		})
+	}
+	if len(req.Notes) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "notes must be at most 500 characters",
+		})
+	}
+
+	var active int64
+	h.db.Model(&models.Pregnancy{}).Where("patient_id = ? AND status = ?", patient.ID, models.PregnancyStatusActive).Count(&active)
+	if active > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Patient already has an active pregnancy",
+		})
+	}
+
+	pregnancy := models.Pregnancy{
+		PatientID:          patient.ID,
+		ClinicID:           clinicID,
+		LMP:                lmp,
+		Gravida:            req.Gravida,
+		Parity:             req.Parity,
+		Status:             models.PregnancyStatusActive,
+		ReportedRisks:      risks,
+		Notes:              strings.TrimSpace(req.Notes),
+		RegisteredByUserID: currentUserID(c),
+	}
+	pregnancy.Refresh(patient.DateOfBirth, nil)
+
+	if err := h.db.Create(&pregnancy).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to register pregnancy",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPregnancy, pregnancy.ID, patient.ID, nil, &pregnancy)
+
+	return c.Status(fiber.StatusCreated).JSON(pregnancyResponse(&pregnancy))
+}
+
+// GetPregnancy returns a pregnancy with its antenatal contacts, outcome and
+// postnatal visits
+func (h *MaternalHandler) GetPregnancy(c *fiber.Ctx) error {
+	pregnancy, err := findClinicPregnancy(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Pregnancy not found",
+		})
+	}
+	if err := h.loadPregnancyDetail(pregnancy); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch pregnancy",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPregnancy, pregnancy.ID, pregnancy.PatientID, nil, nil)
+
+	return c.JSON(pregnancyResponse(pregnancy))
+}
+
+// UpdatePregnancy corrects an active pregnancy's LMP, obstetric history,
+// reported risks or notes. Redating the LMP recomputes the gestational age
+// of every antenatal contact.
+func (h *MaternalHandler) UpdatePregnancy(c *fiber.Ctx) error {
+	pregnancy, err := findClinicPregnancy(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Pregnancy not found",
+		})
+	}
+	if pregnancy.Status != models.PregnancyStatusActive {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only an active pregnancy can be updated",
+		})
+	}
+
+	var req models.UpdatePregnancyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *pregnancy
+	before.Patient = nil
+
+	if req.LMP != nil {
+		lmp, err := parseClinicalDate(*req.LMP, "lmp")
+		if err != nil || *req.LMP == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid lmp. Use YYYY-MM-DD, not in the future",
+			})
+		}
+		if models.GestationalAgeDays(lmp, time.Now()) > models.MaxGestationalAgeDays {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "lmp is more than 44 weeks ago",
+			})
+		}
+		var earliest models.AntenatalContact
+		if err := h.db.Where("pregnancy_id = ?", pregnancy.ID).Order("contact_date").First(&earliest).Error; err == nil &&
+			earliest.ContactDate.Format("2006-01-02") < lmp.Format("2006-01-02") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "lmp cannot be after an antenatal contact",
+			})
+		}
+		pregnancy.LMP = lmp
+	}
+	if req.Gravida != nil {
+		pregnancy.Gravida = *req.Gravida
+	}
+	if req.Parity != nil {
+		pregnancy.Parity = *req.Parity
+	}
+	if err := models.ValidateObstetricHistory(pregnancy.Gravida, pregnancy.Parity); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.ReportedRisks != nil {
+		risks, err := models.NormalizeClinicalFlags(*req.ReportedRisks, models.ReportedPregnancyRisks)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "reported_risks must be from " + strings.Join(models.ReportedPregnancyRisks, ", "),
+			})
+		}
+		pregnancy.ReportedRisks = risks
+	}
+	if req.Notes != nil {
+		if len(*req.Notes) > 500 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "notes must be at most 500 characters",
+			})
+		}
+		pregnancy.Notes = strings.TrimSpace(*req.Notes)
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(pregnancy).Select("gravida", "parity", "reported_risks", "notes").Updates(pregnancy).Error; err != nil {
+			return err
+		}
+		if req.LMP != nil {
+			if err := tx.Model(&models.AntenatalContact{}).Where("pregnancy_id = ?", pregnancy.ID).
+				Update("gestational_age_days", gorm.Expr("contact_date - ?::date", pregnancy.LMP.Format("2006-01-02"))).Error; err != nil {
+				return err
+			}
+		}
+		return refreshPregnancy(tx, pregnancy, pregnancy.Patient.DateOfBirth)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update pregnancy",
+		})
+	}
+
+	after := *pregnancy
+	after.Patient = nil
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPregnancy, pregnancy.ID, pregnancy.PatientID, &before, &after)
+
+	if err := h.loadPregnancyDetail(pregnancy); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch pregnancy",
+		})
+	}
+	return c.JSON(pregnancyResponse(pregnancy))
+}
+
+// RecordANCContact records an antenatal contact and updates the pregnancy's
+// risk flags and next contact due date
+func (h *MaternalHandler) RecordANCContact(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	staffID := c.Locals("staff_id").(uint)
+
+	pregnancy, err := findClinicPregnancy(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Pregnancy not found",
+		})
+	}
+
+	var req models.RecordANCContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	contact, err := req.ToContact()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	contactDate, err := parseClinicalDate(req.ContactDate, "contact_date")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	ga := models.GestationalAgeDays(pregnancy.LMP, contactDate)
+	if ga < 0 || ga > models.MaxGestationalAgeDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "contact_date must fall between the LMP and 44 weeks of gestation",
+		})
+	}
+	if !h.checkPatientVisit(contact.VisitID, pregnancy.PatientID, clinicID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "visit_id must be a visit of this patient in this clinic",
+		})
+	}
+
+	contact.PregnancyID = pregnancy.ID
+	contact.PatientID = pregnancy.PatientID
+	contact.ClinicID = clinicID
+	contact.ContactDate = contactDate
+	contact.GestationalAgeDays = ga
+	contact.RecordedByStaffID = &staffID
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Re-check under the transaction so a contact cannot land after the outcome
+		result := tx.Model(&models.Pregnancy{}).
+			Where("id = ? AND status = ?", pregnancy.ID, models.PregnancyStatusActive).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPregnancyNotActive
+		}
+		if err := tx.Create(&contact).Error; err != nil {
+			return err
+		}
+		return refreshPregnancy(tx, pregnancy, pregnancy.Patient.DateOfBirth)
+	})
+	if errors.Is(err, errPregnancyNotActive) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Antenatal contacts can only be recorded for an active pregnancy",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record antenatal contact",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityANCContact, contact.ID, contact.PatientID, nil, &contact)
+
+	contact.GestationalAge = models.FormatGestationalAge(contact.GestationalAgeDays)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"contact":      contact,
+		"risk_flags":   pregnancy.RiskFlags,
+		"high_risk":    pregnancy.HighRisk,
+		"next_anc_due": pregnancy.NextANCDue,
+	})
+}
+
+// RecordOutcome records how an active pregnancy ended: a delivery (live birth
+// or stillbirth) or a miscarriage, abortion or ectopic pregnancy
+func (h *MaternalHandler) RecordOutcome(c *fiber.Ctx) error {
+	pregnancy, err := findClinicPregnancy(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Pregnancy not found",
+		})
+	}
+
+	var req models.RecordPregnancyOutcomeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	status, err := req.OutcomeStatus()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if req.OutcomeDate == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "outcome_date is required",
+		})
+	}
+	outcomeDate, err := parseClinicalDate(req.OutcomeDate, "outcome_date")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	ga := models.GestationalAgeDays(pregnancy.LMP, outcomeDate)
+	if ga < 0 || ga > models.MaxGestationalAgeDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "outcome_date must fall between the LMP and 44 weeks of gestation",
+		})
+	}
+
+	before := *pregnancy
+	before.Patient = nil
+
+	pregnancy.Status = status
+	pregnancy.Outcome = req.Outcome
+	pregnancy.OutcomeDate = &outcomeDate
+	pregnancy.GestationAtOutcomeDays = &ga
+	pregnancy.DeliveryMode = req.DeliveryMode
+	pregnancy.DeliveryPlace = req.DeliveryPlace
+	pregnancy.LiveBirths = req.LiveBirths
+	pregnancy.Stillbirths = req.Stillbirths
+	pregnancy.BirthWeightGrams = req.BirthWeightGrams
+	pregnancy.MaternalComplications = strings.TrimSpace(req.MaternalComplications)
+	pregnancy.OutcomeRecordedByUserID = currentUserID(c)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Pregnancy{}).
+			Where("id = ? AND status = ?", pregnancy.ID, models.PregnancyStatusActive).
+			Select("status", "outcome", "outcome_date", "gestation_at_outcome_days", "delivery_mode", "delivery_place",
+				"live_births", "stillbirths", "birth_weight_grams", "maternal_complications", "outcome_recorded_by_user_id").
+			Updates(pregnancy)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPregnancyNotActive
+		}
+		return refreshPregnancy(tx, pregnancy, pregnancy.Patient.DateOfBirth)
+	})
+	if errors.Is(err, errPregnancyNotActive) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The outcome of this pregnancy is already recorded",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record pregnancy outcome",
+		})
+	}
+
+	after := *pregnancy
+	after.Patient = nil
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPregnancy, pregnancy.ID, pregnancy.PatientID, &before, &after)
+
+	if err := h.loadPregnancyDetail(pregnancy); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch pregnancy",
+		})
+	}
+	return c.JSON(pregnancyResponse(pregnancy))
+}
+
+// RecordPostnatalVisit records a check-up of the mother within the
+// postnatal period after a delivery
+func (h *MaternalHandler) RecordPostnatalVisit(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	staffID := c.Locals("staff_id").(uint)
+
+	pregnancy, err := findClinicPregnancy(h.db, c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Pregnancy not found",
+		})
+	}
+	if pregnancy.Status != models.PregnancyStatusDelivered || pregnancy.OutcomeDate == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Postnatal visits can only be recorded after a delivery",
+		})
+	}
+
+	var req models.RecordPostnatalVisitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	visit, err := req.ToPostnatalVisit()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	visitDate, err := parseClinicalDate(req.VisitDate, "visit_date")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	days := models.GestationalAgeDays(*pregnancy.OutcomeDate, visitDate)
+	if days < 0 || days > models.PostnatalPeriodDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "visit_date must fall within 42 days after the delivery",
+		})
+	}
+	if !h.checkPatientVisit(visit.VisitID, pregnancy.PatientID, clinicID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "visit_id must be a visit of this patient in this clinic",
+		})
+	}
+
+	visit.PregnancyID = pregnancy.ID
+	visit.PatientID = pregnancy.PatientID
+	visit.ClinicID = clinicID
+	visit.VisitDate = visitDate
+	visit.DaysAfterDelivery = days
+	visit.RecordedByStaffID = &staffID
+
+	if err := h.db.Create(&visit).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record postnatal visit",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPostnatal, visit.ID, visit.PatientID, nil, &visit)
+
+	h.db.Preload("RecordedBy").First(&visit, visit.ID)
+	return c.Status(fiber.StatusCreated).JSON(visit)
+}
+
+// GetWatchList lists the clinic's women with an active pregnancy who are high
+// risk or overdue for an antenatal contact, overdue women first. filter=high_risk
+// or filter=anc_overdue narrows the list to one group.
+func (h *MaternalHandler) GetWatchList(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	now := time.Now()
+	overdueBefore := now.AddDate(0, 0, -models.ANCGraceDays).Format("2006-01-02")
+
+	query := h.db.Preload("Patient").
+		Joins("JOIN patients ON patients.id = pregnancies.patient_id AND patients.deleted_at IS NULL").
+		Where("patients.clinic_id = ? AND pregnancies.status = ?", clinicID, models.PregnancyStatusActive)
+	switch c.Query("filter") {
+	case "":
+		query = query.Where("(pregnancies.high_risk = ? OR pregnancies.next_anc_due < ?)", true, overdueBefore)
+	case "high_risk":
+		query = query.Where("pregnancies.high_risk = ?", true)
+	case "anc_overdue":
+		query = query.Where("pregnancies.next_anc_due < ?", overdueBefore)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "filter must be high_risk or anc_overdue",
+		})
+	}
+
+	var pregnancies []models.Pregnancy
+	if err := query.Find(&pregnancies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch pregnancies",
+		})
+	}
+
+	contactCounts := make(map[uint]int64, len(pregnancies))
+	if len(pregnancies) > 0 {
+		ids := make([]uint, len(pregnancies))
+		for i, p := range pregnancies {
+			ids[i] = p.ID
+		}
+		var counts []struct {
+			PregnancyID uint
+			Count       int64
+		}
+		h.db.Model(&models.AntenatalContact{}).Select("pregnancy_id, COUNT(*) AS count").
+			Where("pregnancy_id IN ?", ids).Group("pregnancy_id").Scan(&counts)
+		for _, row := range counts {
+			contactCounts[row.PregnancyID] = row.Count
+		}
+	}
+
+	entries := make([]models.MaternalWatchEntry, 0, len(pregnancies))
+	patients := make([]models.Patient, 0, len(pregnancies))
+	for _, p := range pregnancies {
+		ga := models.GestationalAgeDays(p.LMP, now)
+		entries = append(entries, models.MaternalWatchEntry{
+			PregnancyID:        p.ID,
+			PatientID:          p.PatientID,
+			MRN:                p.Patient.MRN,
+			FullName:           p.Patient.FullName,
+			Phone:              p.Patient.Phone,
+			EDD:                p.EDD,
+			GestationalAge:     models.FormatGestationalAge(ga),
+			GestationalAgeDays: ga,
+			RiskFlags:          p.RiskFlags,
+			HighRisk:           p.HighRisk,
+			NextANCDue:         p.NextANCDue,
+			ANCOverdue:         models.ANCOverdue(p.NextANCDue, now),
+			ContactCount:       contactCounts[p.ID],
+		})
+		patients = append(patients, *p.Patient)
+	}
+	models.SortMaternalWatchList(entries)
+
+	auditPatients(h.db, c, models.AuditActionList, patients)
+
+	return c.JSON(fiber.Map{
+		"women": entries,
+		"total": len(entries),
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"rural_health_management_system/internal/models"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -91,7 +92,7 @@ func (h *MedicalPortalHandler) GetMyPatients(c *fiber.Ctx) error {
 	query := h.db.Model(&models.Patient{}).Where("clinic_id = ?", clinicID)
 
 	if search != "" {
-		query = query.Where("full_name ILIKE ? OR phone ILIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Scopes(patientSearch(search))
 	}
 
 	query.Count(&total)
@@ -104,6 +105,8 @@ func (h *MedicalPortalHandler) GetMyPatients(c *fiber.Ctx) error {
 
 	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
 
+	auditPatients(h.db, c, models.AuditActionList, patients)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       patients,
 		Page:       page,
@@ -124,12 +127,19 @@ func (h *MedicalPortalHandler) GetMyPatient(c *fiber.Ctx) error {
 	}
 
 	var patient models.Patient
-	if err := h.db.Preload("Clinic").Preload("Visits.Staff").Preload("Visits.Diagnoses").Preload("Visits.Prescriptions").Where("id = ? AND clinic_id = ?", patientID, clinicID).First(&patient).Error; err != nil {
+	if err := h.db.Preload("Clinic").Preload("Visits.Clinic").Preload("Visits.Staff").Preload("Visits.Diagnoses").Preload("Visits.Prescriptions").
+		Preload("Allergies", "status = ?", models.ClinicalStatusActive).Preload("Problems", "status = ?", models.ClinicalStatusActive).
+		Where("id = ? AND clinic_id = ?", patientID, clinicID).First(&patient).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Patient not found",
 		})
 	}
 
+	// Visits from clinics the patient was transferred from are read-only here
+	models.MarkReadOnlyVisits(patient.Visits, clinicID)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
 	return c.JSON(patient)
 }
 
@@ -180,6 +190,8 @@ func (h *MedicalPortalHandler) CreateVisit(c *fiber.Ctx) error {
 	// Load relationships
 	h.db.Preload("Patient").Preload("Clinic").Preload("Staff").First(&visit, visit.ID)
 
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, &visit)
+
 	return c.Status(fiber.StatusCreated).JSON(visit)
 }
 
@@ -201,7 +213,7 @@ func (h *MedicalPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 	var visits []models.Visit
 	var total int64
 
-	query := h.db.Model(&models.Visit{}).Where("clinic_id = ?", clinicID)
+	query := h.db.Model(&models.Visit{}).Scopes(clinicVisits(clinicID))
 
 	// If not showing all, filter by staff ID
 	if showAll != "true" {
@@ -214,14 +226,17 @@ func (h *MedicalPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 
 	query.Count(&total)
 
-	if err := query.Preload("Patient").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
+	if err := query.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch visits",
 		})
 	}
+	models.MarkReadOnlyVisits(visits, clinicID)
 
 	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
 
+	auditVisits(h.db, c, models.AuditActionList, visits)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       visits,
 		Page:       page,
@@ -242,11 +257,19 @@ func (h *MedicalPortalHandler) GetMyVisit(c *fiber.Ctx) error {
 	}
 
 	var visit models.Visit
-	if err := h.db.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Where("id = ? AND clinic_id = ?", visitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Preload("VitalSigns").
+		Preload("LabOrders", func(db *gorm.DB) *gorm.DB {
+			return db.Order("ordered_at, id")
+		}).Preload("LabOrders.LabTest").Preload("LabOrders.Results", preloadLabResults).
+		Scopes(clinicVisits(clinicID)).Where("id = ?", visitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Visit not found",
 		})
 	}
+	visit.ReadOnly = models.VisitReadOnly(visit, clinicID)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, nil)
+	auditLabOrders(h.db, c, models.AuditActionView, visit.LabOrders)
 
 	return c.JSON(visit)
 }
@@ -264,15 +287,26 @@ func (h *MedicalPortalHandler) CreateDiagnosis(c *fiber.Ctx) error {
 
 	// Verify visit exists and belongs to this clinic
 	var visit models.Visit
-	if err := h.db.Where("id = ? AND clinic_id = ?", req.VisitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", req.VisitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Visit not found in this clinic",
 		})
 	}
 
+	code, err := lookupICD10Code(h.db, req.DiagnosisCode)
+	if err != nil {
+		status, message := diagnosisCodeError(err)
+		return c.Status(status).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		req.Description = code.Description
+	}
+
 	diagnosis := models.Diagnosis{
 		VisitID:       req.VisitID,
-		DiagnosisCode: req.DiagnosisCode,
+		DiagnosisCode: code.Code,
 		Description:   req.Description,
 	}
 
@@ -285,6 +319,8 @@ func (h *MedicalPortalHandler) CreateDiagnosis(c *fiber.Ctx) error {
 	// Load relationships
 	h.db.Preload("Visit").First(&diagnosis, diagnosis.ID)
 
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityDiagnosis, diagnosis.ID, visit.PatientID, nil, &diagnosis)
+
 	return c.Status(fiber.StatusCreated).JSON(diagnosis)
 }
 
@@ -301,18 +337,45 @@ func (h *MedicalPortalHandler) CreatePrescription(c *fiber.Ctx) error {
 
 	// Verify visit exists and belongs to this clinic
 	var visit models.Visit
-	if err := h.db.Where("id = ? AND clinic_id = ?", req.VisitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", req.VisitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Visit not found in this clinic",
 		})
 	}
 
+	// Resolve structured dosing from the formulary
+	if err := applyFormulary(h.db, clinicID, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Check the medication against the patient's recorded allergies
+	allergyWarnings, ok, err := checkPrescriptionAllergies(h.db, c, visit.PatientID, &req)
+	if !ok {
+		return err
+	}
+
+	// Check the medication against the patient's other active prescriptions
+	interactionWarnings, ok, err := checkPrescriptionInteractions(h.db, c, visit.PatientID, 0, &req)
+	if !ok {
+		return err
+	}
+
 	prescription := models.Prescription{
-		VisitID:        req.VisitID,
-		MedicationName: req.MedicationName,
-		Dosage:         req.Dosage,
-		Instructions:   req.Instructions,
-		DurationDays:   req.DurationDays,
+		VisitID:                   req.VisitID,
+		MedicationName:            req.MedicationName,
+		Dosage:                    req.Dosage,
+		Instructions:              req.Instructions,
+		DurationDays:              req.DurationDays,
+		FormularyItemID:           req.FormularyItemID,
+		DoseAmount:                req.DoseAmount,
+		DoseUnit:                  req.DoseUnit,
+		Frequency:                 req.Frequency,
+		Route:                     req.Route,
+		Quantity:                  req.Quantity,
+		AllergyOverrideReason:     req.AllergyOverrideReason,
+		InteractionOverrideReason: req.InteractionOverrideReason,
 	}
 
 	if err := h.db.Create(&prescription).Error; err != nil {
@@ -324,7 +387,13 @@ func (h *MedicalPortalHandler) CreatePrescription(c *fiber.Ctx) error {
 	// Load relationships
 	h.db.Preload("Visit").First(&prescription, prescription.ID)
 
-	return c.Status(fiber.StatusCreated).JSON(prescription)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPrescription, prescription.ID, visit.PatientID, nil, &prescription)
+
+	return c.Status(fiber.StatusCreated).JSON(models.PrescriptionResponse{
+		Prescription:        prescription,
+		AllergyWarnings:     allergyWarnings,
+		InteractionWarnings: interactionWarnings,
+	})
 }
 
 // GetStaff returns staff information (read-only for medical staff)
@@ -457,6 +526,8 @@ func (h *MedicalPortalHandler) GetMyDiagnoses(c *fiber.Ctx) error {
 
 	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
 
+	auditDiagnoses(h.db, c, models.AuditActionList, diagnoses)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       diagnoses,
 		Page:       page,
@@ -519,6 +590,8 @@ func (h *MedicalPortalHandler) GetMyPrescriptions(c *fiber.Ctx) error {
 
 	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
 
+	auditPrescriptions(h.db, c, models.AuditActionList, prescriptions)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       prescriptions,
 		Page:       page,
@@ -552,6 +625,8 @@ func (h *MedicalPortalHandler) GetMyDiagnosis(c *fiber.Ctx) error {
 		})
 	}
 
+	auditDiagnoses(h.db, c, models.AuditActionView, []models.Diagnosis{diagnosis})
+
 	return c.JSON(diagnosis)
 }
 
@@ -579,5 +654,107 @@ func (h *MedicalPortalHandler) GetMyPrescription(c *fiber.Ctx) error {
 		})
 	}
 
+	auditPrescriptions(h.db, c, models.AuditActionView, []models.Prescription{prescription})
+
 	return c.JSON(prescription)
 }
+
+// CreateVitalSigns records vital signs for a visit in this clinic (doctors and nurses)
+func (h *MedicalPortalHandler) CreateVitalSigns(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	staffID := c.Locals("staff_id").(uint)
+
+	var visit models.Visit
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", c.Params("id")).First(&visit).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Visit not found in this clinic",
+		})
+	}
+
+	var req models.CreateVitalSignsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	vitals, err := req.ToVitalSigns()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	vitals.VisitID = visit.ID
+	vitals.PatientID = visit.PatientID
+	vitals.RecordedByStaffID = &staffID
+	vitals.RecordedAt = time.Now()
+	if req.RecordedAt != nil {
+		if req.RecordedAt.After(time.Now().Add(5 * time.Minute)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "recorded_at cannot be in the future",
+			})
+		}
+		vitals.RecordedAt = *req.RecordedAt
+	}
+
+	if err := h.db.Create(&vitals).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record vital signs",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityVitalSigns, vitals.ID, vitals.PatientID, nil, &vitals)
+
+	h.db.Preload("RecordedBy").First(&vitals, vitals.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(vitals)
+}
+
+// GetPatientVitals returns a patient's vital signs across visits, oldest first,
+// for trend charts. Supports date_from and date_to (YYYY-MM-DD).
+func (h *MedicalPortalHandler) GetPatientVitals(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var patient models.Patient
+	if err := h.db.Where("id = ? AND clinic_id = ?", c.Params("id"), clinicID).First(&patient).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	query := h.db.Model(&models.VitalSigns{}).Where("patient_id = ?", patient.ID)
+
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		from, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date format. Use YYYY-MM-DD",
+			})
+		}
+		query = query.Where("recorded_at >= ?", from)
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		to, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date format. Use YYYY-MM-DD",
+			})
+		}
+		query = query.Where("recorded_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var readings []models.VitalSigns
+	if err := query.Order("recorded_at ASC").Find(&readings).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch vital signs",
+		})
+	}
+
+	auditVitalSigns(h.db, c, models.AuditActionList, readings)
+
+	return c.JSON(fiber.Map{
+		"patient_id": patient.ID,
+		"readings":   readings,
+	})
+}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"errors"
+	"rural_health_management_system/internal/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var errMFAAlreadyEnabled = errors.New("mfa already enabled")
+
+// completeLogin finishes a successful password check. Users with MFA enabled
+// get a short-lived challenge token to exchange at /auth/mfa/challenge along
+// with a code; everyone else gets their tokens straight away.
+func (h *AuthHandler) completeLogin(c *fiber.Ctx, user *models.User) error {
+	var enabled int64
+	h.db.Model(&models.UserMFA{}).Where("user_id = ? AND enabled_at IS NOT NULL", user.ID).Count(&enabled)
+	if enabled > 0 {
+		challengeToken, err := generateRefreshToken()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to start MFA challenge",
+			})
+		}
+		challenge := models.MFAChallenge{
+			UserID:    user.ID,
+			TokenHash: hashToken(challengeToken),
+			ExpiresAt: time.Now().Add(h.mfaChallengeTTL),
+			IPAddress: c.IP(),
+		}
+		if err := h.db.Create(&challenge).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to start MFA challenge",
+			})
+		}
+
+		return c.JSON(models.MFAChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: challengeToken,
+			ExpiresIn:      int64(h.mfaChallengeTTL.Seconds()),
+		})
+	}
+
+	h.recordLoginSuccess(c, user)
+	subject := h.loadTokenSubject(user)
+	token, refreshToken, err := h.issueTokens(c, user, subject)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	return c.JSON(models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserType:     user.UserType,
+		User:         subject.Profile,
+	})
+}
+
+// CompleteMFAChallenge exchanges a login's challenge token and an
+// authenticator or recovery code for the session tokens
+func (h *AuthHandler) CompleteMFAChallenge(c *fiber.Ctx) error {
+	var req models.MFAChallengeRequest
+	if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	code := req.Code
+	if code == "" {
+		code = req.RecoveryCode
+	}
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code or recovery_code is required",
+		})
+	}
+
+	now := time.Now()
+	var challenge models.MFAChallenge
+	if err := h.db.Where("token_hash = ?", hashToken(req.ChallengeToken)).First(&challenge).Error; err != nil ||
+		challenge.UsedAt != nil || !now.Before(challenge.ExpiresAt) || challenge.FailedAttempts >= models.MaxMFAChallengeAttempts {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired challenge. Please log in again.",
+		})
+	}
+
+	var user models.User
+	if err := h.db.Where("id = ? AND is_active = true", challenge.UserID).First(&user).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired challenge. Please log in again.",
+		})
+	}
+	if user.IsLocked(now) {
+		return h.lockedResponse(c, &user)
+	}
+	mfa, err := h.enabledMFA(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired challenge. Please log in again.",
+		})
+	}
+
+	ok, err := h.consumeMFACode(mfa, code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify code",
+		})
+	}
+	if !ok {
+		h.db.Model(&models.MFAChallenge{}).Where("id = ?", challenge.ID).
+			Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+		h.recordFailedLogin(c, &user, models.AuthEventMFAFailed)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":              "Invalid code",
+			"attempts_remaining": max(models.MaxMFAChallengeAttempts-challenge.FailedAttempts-1, 0),
+		})
+	}
+
+	// Single use; the condition stops two concurrent requests both succeeding
+	result := h.db.Model(&models.MFAChallenge{}).Where("id = ? AND used_at IS NULL", challenge.ID).Update("used_at", now)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to complete challenge",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired challenge. Please log in again.",
+		})
+	}
+
+	h.recordLoginSuccess(c, &user)
+	subject := h.loadTokenSubject(&user)
+	token, refreshToken, err := h.issueTokens(c, &user, subject)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	return c.JSON(models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserType:     user.UserType,
+		User:         subject.Profile,
+	})
+}
+
+// GetMFAStatus reports whether the caller has MFA enabled and whether their clinic requires it
+func (h *AuthHandler) GetMFAStatus(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	subject := h.loadTokenSubject(&user)
+
+	status := fiber.Map{
+		"enabled":  false,
+		"required": subject.MFARequired,
+	}
+	if mfa, err := h.enabledMFA(userID); err == nil {
+		var remaining int64
+		h.db.Model(&models.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining)
+		status["enabled"] = true
+		status["enabled_at"] = mfa.EnabledAt
+		status["recovery_codes_remaining"] = remaining
+	}
+
+	return c.JSON(status)
+}
+
+// EnrollMFA generates a new TOTP secret for the caller. MFA is not enabled
+// until a code from the authenticator app is confirmed with VerifyMFA.
+func (h *AuthHandler) EnrollMFA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	email := c.Locals("email").(string)
+
+	var mfa models.UserMFA
+	err := h.db.Where("user_id = ?", userID).First(&mfa).Error
+	if err == nil && mfa.EnabledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "MFA is already enabled. Disable it first to enroll a new device.",
+		})
+	}
+
+	secret, genErr := models.GenerateTOTPSecret()
+	if genErr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate secret",
+		})
+	}
+
+	// Starting again replaces an unconfirmed secret
+	if err == nil {
+		err = h.db.Model(&mfa).Updates(map[string]interface{}{"secret": secret, "last_used_step": 0}).Error
+	} else {
+		mfa = models.UserMFA{UserID: userID, Secret: secret}
+		err = h.db.Create(&mfa).Error
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start enrollment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"secret":           secret,
+		"provisioning_uri": models.TOTPProvisioningURI(secret, h.mfaIssuer, email),
+		"message":          "Add this account to your authenticator app, then confirm a code at /auth/mfa/verify",
+	})
+}
+
+// VerifyMFA confirms enrollment with a code from the authenticator app,
+// enables MFA and returns the recovery codes. They are only shown this once.
+func (h *AuthHandler) VerifyMFA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var mfa models.UserMFA
+	if err := h.db.Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No MFA enrollment in progress. Start at /auth/mfa/enroll.",
+		})
+	}
+	if mfa.EnabledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "MFA is already enabled",
+		})
+	}
+
+	step, ok := models.VerifyTOTP(mfa.Secret, req.Code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid code. Check the time on your device and try again.",
+		})
+	}
+
+	codes, err := models.GenerateRecoveryCodes(models.RecoveryCodeCount)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate recovery codes",
+		})
+	}
+
+	now := time.Now()
+	sessionID := c.Locals("session_id").(uint)
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserMFA{}).Where("id = ? AND enabled_at IS NULL", mfa.ID).
+			Updates(map[string]interface{}{"enabled_at": now, "last_used_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errMFAAlreadyEnabled
+		}
+		if err := replaceRecoveryCodes(tx, userID, codes); err != nil {
+			return err
+		}
+		// Sessions signed in with only a password must not outlive enrollment
+		return tx.Model(&models.AuthSession{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, sessionID).
+			Update("revoked_at", now).Error
+	})
+	if err == errMFAAlreadyEnabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "MFA is already enabled",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to enable MFA",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":        "MFA enabled. Store these recovery codes somewhere safe; each works once.",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableMFA turns MFA off after re-checking the password and a code. It is
+// refused while the user's clinic requires MFA.
+func (h *AuthHandler) DisableMFA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req models.MFADisableRequest
+	if err := c.BodyParser(&req); err != nil || req.Password == "" || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid credentials",
+		})
+	}
+	if h.loadTokenSubject(&user).MFARequired {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Your clinic requires multi-factor authentication",
+		})
+	}
+
+	mfa, err := h.enabledMFA(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "MFA is not enabled",
+		})
+	}
+	ok, err := h.consumeMFACode(mfa, req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify code",
+		})
+	}
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid code",
+		})
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(mfa).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to disable MFA",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "MFA disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes, invalidating the old ones
+func (h *AuthHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	mfa, err := h.enabledMFA(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "MFA is not enabled",
+		})
+	}
+	ok, err := h.consumeMFACode(mfa, req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify code",
+		})
+	}
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid code",
+		})
+	}
+
+	codes, err := models.GenerateRecoveryCodes(models.RecoveryCodeCount)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate recovery codes",
+		})
+	}
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate recovery codes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"recovery_codes": codes,
+	})
+}
+
+// SetClinicMFAPolicy turns MFA enforcement on or off for a clinic's staff.
+// Clinic users change their own clinic; administrators name it in the path.
+func (h *AuthHandler) SetClinicMFAPolicy(c *fiber.Ctx) error {
+	var req models.ClinicMFAPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	query := h.db.Model(&models.Clinic{})
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("id = ?", *clinicID)
+	} else {
+		query = query.Where("id = ?", c.Params("id"))
+	}
+
+	var clinic models.Clinic
+	if err := query.First(&clinic).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Clinic not found",
+		})
+	}
+	if err := h.db.Model(&clinic).Update("require_mfa", req.RequireMFA).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update MFA policy",
+		})
+	}
+
+	// Staff without MFA are held to enrollment from their next token refresh
+	var unenrolled int64
+	if req.RequireMFA {
+		h.db.Model(&models.User{}).
+			Where("users.id IN (?) OR users.id IN (?)",
+				h.db.Model(&models.Clinic{}).Select("user_id").Where("id = ? AND user_id IS NOT NULL", clinic.ID),
+				h.db.Model(&models.Staff{}).Select("user_id").Where("clinic_id = ? AND user_id IS NOT NULL", clinic.ID)).
+			Where("users.id NOT IN (?)", h.db.Model(&models.UserMFA{}).Select("user_id").Where("enabled_at IS NOT NULL")).
+			Count(&unenrolled)
+	}
+
+	return c.JSON(fiber.Map{
+		"clinic_id":          clinic.ID,
+		"require_mfa":        req.RequireMFA,
+		"users_not_enrolled": unenrolled,
+	})
+}
+
+// enabledMFA loads a user's MFA settings if MFA is enabled
+func (h *AuthHandler) enabledMFA(userID uint) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	if err := h.db.Where("user_id = ? AND enabled_at IS NOT NULL", userID).First(&mfa).Error; err != nil {
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// consumeMFACode accepts either a current authenticator code or an unused
+// recovery code, marking it used so it cannot be presented again
+func (h *AuthHandler) consumeMFACode(mfa *models.UserMFA, code string) (bool, error) {
+	if step, ok := models.VerifyTOTP(mfa.Secret, code, time.Now(), mfa.LastUsedStep); ok {
+		result := h.db.Model(&models.UserMFA{}).Where("id = ? AND last_used_step < ?", mfa.ID, step).
+			Update("last_used_step", step)
+		return result.RowsAffected == 1, result.Error
+	}
+
+	result := h.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", mfa.UserID, hashToken(models.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// replaceRecoveryCodes stores hashes of a new set of recovery codes in place of the old ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	records := make([]models.MFARecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = models.MFARecoveryCode{UserID: userID, CodeHash: hashToken(code)}
+	}
+	return tx.Create(&records).Error
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"strings"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// patientSearch matches patients by name, phone or medical record number.
+// Record numbers match with or without their separators, but only for terms
+// shaped like one, so that a name such as "kat" does not match every MRN
+// with that district prefix.
+func patientSearch(search string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		like := "%" + search + "%"
+		if !models.LooksLikeMRN(search) {
+			return db.Where("(full_name ILIKE ? OR phone ILIKE ?)", like, like)
+		}
+		compact := "%" + strings.ReplaceAll(models.NormalizeMRN(search), "-", "") + "%"
+		return db.Where("(full_name ILIKE ? OR phone ILIKE ? OR REPLACE(mrn, '-', '') ILIKE ?)", like, like, compact)
+	}
+}
+
+type PatientLookupHandler struct {
+	db *gorm.DB
+}
+
+func NewPatientLookupHandler(db *gorm.DB) *PatientLookupHandler {
+	return &PatientLookupHandler{db: db}
+}
+
+// GetPatientByMRN finds a patient by medical record number, as written on a
+// patient's card. Clinic users can only find their own clinic's patients.
+func (h *PatientLookupHandler) GetPatientByMRN(c *fiber.Ctx) error {
+	if !models.ValidMRN(c.Params("mrn")) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid medical record number, check it was copied correctly",
+		})
+	}
+
+	query := h.db.Preload("Clinic").Where("mrn = ?", models.NormalizeMRN(c.Params("mrn")))
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var patient models.Patient
+	if err := query.First(&patient).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
+	return c.JSON(patient)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"rural_health_management_system/internal/models"
+	"rural_health_management_system/internal/notify"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var errResetTokenUsed = errors.New("reset token already used")
+
+type PasswordResetHandler struct {
+	db       *gorm.DB
+	notifier notify.Notifier
+	tokenTTL time.Duration
+}
+
+func NewPasswordResetHandler(db *gorm.DB, notifier notify.Notifier, tokenTTL time.Duration) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		db:       db,
+		notifier: notifier,
+		tokenTTL: tokenTTL,
+	}
+}
+
+// RequestReset issues a reset token and sends it to the account's email or phone
+func (h *PasswordResetHandler) RequestReset(c *fiber.Ctx) error {
+	var req models.PasswordResetRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	channel := notify.ChannelEmail
+	if req.Channel == string(notify.ChannelSMS) {
+		channel = notify.ChannelSMS
+	}
+
+	// Always return the same response so the endpoint cannot be used to discover accounts
+	response := fiber.Map{
+		"message": "If the account exists, password reset instructions have been sent",
+	}
+
+	var user models.User
+	if err := h.db.Where("email = ? AND is_active = true", req.Email).First(&user).Error; err != nil {
+		return c.JSON(response)
+	}
+
+	recipient := user.Email
+	if channel == notify.ChannelSMS {
+		recipient = userPhone(h.db, &user)
+		if recipient == "" {
+			return c.JSON(response)
+		}
+	}
+
+	token, err := generateRefreshToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate reset token",
+		})
+	}
+
+	now := time.Now()
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Only the most recently issued token stays usable
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(h.tokenTTL),
+			RequestIP: c.IP(),
+		}).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create reset token",
+		})
+	}
+
+	msg := notify.Message{
+		Channel: channel,
+		To:      recipient,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Use this code to reset your password: %s. It expires in %d minutes. If you did not request a reset, ignore this message.",
+			token, int(h.tokenTTL.Minutes())),
+	}
+	if err := h.notifier.Send(msg); err != nil {
+		log.Printf("Failed to send password reset to user %d: %v", user.ID, err)
+	}
+
+	return c.JSON(response)
+}
+
+// ConfirmReset sets a new password using a valid reset token and ends all existing sessions
+func (h *PasswordResetHandler) ConfirmReset(c *fiber.Ctx) error {
+	var req models.PasswordResetConfirmRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.NewPassword) < 8 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Password must be at least 8 characters",
+		})
+	}
+
+	now := time.Now()
+	var resetToken models.PasswordResetToken
+	if err := h.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(req.Token), now).
+		First(&resetToken).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired reset token",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to hash password",
+		})
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Consume the token first; the used_at condition makes it single-use under concurrency
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenUsed
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", resetToken.UserID).
+			Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+
+		return RevokeUserSessions(tx, resetToken.UserID)
+	})
+	if err == errResetTokenUsed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired reset token",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reset password",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Password has been reset. Please log in with your new password.",
+	})
+}
+
+// userPhone returns the contact phone number from the profile linked to a user
+func userPhone(db *gorm.DB, user *models.User) string {
+	var phone string
+
+	switch user.UserType {
+	case "patient":
+		db.Model(&models.Patient{}).Where("user_id = ?", user.ID).Select("phone").Scan(&phone)
+	case "clinic_staff":
+		db.Model(&models.Clinic{}).Where("user_id = ?", user.ID).Select("contact_number").Scan(&phone)
+	default:
+		db.Model(&models.Staff{}).Where("user_id = ?", user.ID).Select("phone").Scan(&phone)
+	}
+
+	return phone
+}
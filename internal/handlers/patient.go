@@ -39,7 +39,7 @@ func (h *PatientHandler) GetPatients(c *fiber.Ctx) error {
 
 	// Apply filters
 	if search != "" {
-		query = query.Where("full_name ILIKE ? OR phone ILIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Scopes(patientSearch(search))
 	}
 	if clinicID != "" {
 		query = query.Where("clinic_id = ?", clinicID)
@@ -63,6 +63,8 @@ func (h *PatientHandler) GetPatients(c *fiber.Ctx) error {
 
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
+	auditPatients(h.db, c, models.AuditActionList, patients)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       patients,
 		Page:       page,
@@ -88,6 +90,8 @@ func (h *PatientHandler) GetPatient(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
 	return c.JSON(patient)
 }
 
@@ -157,6 +161,8 @@ func (h *PatientHandler) CreatePatient(c *fiber.Ctx) error {
 	// Preload clinic for response
 	h.db.Preload("Clinic").First(&patient, patient.ID)
 
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPatient, patient.ID, patient.ID, nil, &patient)
+
 	return c.Status(fiber.StatusCreated).JSON(patient)
 }
 
@@ -184,6 +190,8 @@ func (h *PatientHandler) UpdatePatient(c *fiber.Ctx) error {
 		})
 	}
 
+	before := patient
+
 	// Update fields if provided
 	if req.FullName != nil {
 		patient.FullName = *req.FullName
@@ -239,6 +247,8 @@ func (h *PatientHandler) UpdatePatient(c *fiber.Ctx) error {
 	// Preload clinic for response
 	h.db.Preload("Clinic").First(&patient, patient.ID)
 
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPatient, patient.ID, patient.ID, &before, &patient)
+
 	return c.JSON(patient)
 }
 
@@ -277,5 +287,7 @@ func (h *PatientHandler) DeletePatient(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionDelete, models.AuditEntityPatient, patient.ID, patient.ID, &patient, nil)
+
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
@@ -16,9 +16,13 @@ func NewPatientPortalHandler(db *gorm.DB) *PatientPortalHandler {
 	return &PatientPortalHandler{db: db}
 }
 
-// GetMyProfile returns the patient's own profile
+// GetMyProfile returns the patient's own profile, or with patient_id that of
+// a patient whose records the caller has been given access to
 func (h *PatientPortalHandler) GetMyProfile(c *fiber.Ctx) error {
-	patientID := c.Locals("patient_id").(uint)
+	patientID, err := portalPatientID(h.db, c, "")
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
 
 	var patient models.Patient
 	if err := h.db.Preload("Clinic").First(&patient, patientID).Error; err != nil {
@@ -27,6 +31,8 @@ func (h *PatientPortalHandler) GetMyProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
 	return c.JSON(patient)
 }
 
@@ -68,6 +74,7 @@ func (h *PatientPortalHandler) UpdateMyProfile(c *fiber.Ctx) error {
 		updates["date_of_birth"] = *req.DateOfBirth
 	}
 
+	before := patient
 	if err := h.db.Model(&patient).Updates(updates).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update patient",
@@ -76,12 +83,17 @@ func (h *PatientPortalHandler) UpdateMyProfile(c *fiber.Ctx) error {
 
 	// Return updated patient with clinic
 	h.db.Preload("Clinic").First(&patient, patientID)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPatient, patient.ID, patient.ID, &before, &patient)
+
 	return c.JSON(patient)
 }
 
 // GetMyVisits returns all visits for the authenticated patient
 func (h *PatientPortalHandler) GetMyVisits(c *fiber.Ctx) error {
-	patientID := c.Locals("patient_id").(uint)
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeViewVisits)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
@@ -94,12 +106,21 @@ func (h *PatientPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 	var visits []models.Visit
 	var total int64
 
+	// Delegates see prescriptions only when that scope was granted too
+	showPrescriptions, err := portalAllows(h.db, c, models.DelegationScopeViewPrescriptions)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+
 	query := h.db.Model(&models.Visit{}).Where("patient_id = ?", patientID)
 
 	query.Count(&total)
 
-	if err := query.Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").
-		Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
+	query = query.Preload("Clinic").Preload("Staff").Preload("Diagnoses")
+	if showPrescriptions {
+		query = query.Preload("Prescriptions")
+	}
+	if err := query.Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch visits",
 		})
@@ -110,6 +131,8 @@ func (h *PatientPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 		totalPages++
 	}
 
+	auditVisits(h.db, c, models.AuditActionList, visits)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       visits,
 		Page:       page,
@@ -121,7 +144,10 @@ func (h *PatientPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 
 // GetMyVisit returns a specific visit for the authenticated patient
 func (h *PatientPortalHandler) GetMyVisit(c *fiber.Ctx) error {
-	patientID := c.Locals("patient_id").(uint)
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeViewVisits)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
 	visitID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -129,20 +155,39 @@ func (h *PatientPortalHandler) GetMyVisit(c *fiber.Ctx) error {
 		})
 	}
 
+	// Delegates see prescriptions only when that scope was granted too
+	showPrescriptions, err := portalAllows(h.db, c, models.DelegationScopeViewPrescriptions)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+
+	// Patients only see lab orders once their results are final
+	query := h.db.Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("VitalSigns").
+		Preload("LabOrders", "status = ?", models.LabOrderStatusResulted).
+		Preload("LabOrders.LabTest").Preload("LabOrders.Results", preloadLabResults)
+	if showPrescriptions {
+		query = query.Preload("Prescriptions")
+	}
+
 	var visit models.Visit
-	if err := h.db.Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").
-		Where("id = ? AND patient_id = ?", visitID, patientID).First(&visit).Error; err != nil {
+	if err := query.Where("id = ? AND patient_id = ?", visitID, patientID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Visit not found",
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, nil)
+	auditLabOrders(h.db, c, models.AuditActionView, visit.LabOrders)
+
 	return c.JSON(visit)
 }
 
 // GetMyDiagnoses returns all diagnoses for the authenticated patient
 func (h *PatientPortalHandler) GetMyDiagnoses(c *fiber.Ctx) error {
-	patientID := c.Locals("patient_id").(uint)
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeViewVisits)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
@@ -174,6 +219,8 @@ func (h *PatientPortalHandler) GetMyDiagnoses(c *fiber.Ctx) error {
 		totalPages++
 	}
 
+	auditDiagnoses(h.db, c, models.AuditActionList, diagnoses)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       diagnoses,
 		Page:       page,
@@ -185,7 +232,10 @@ func (h *PatientPortalHandler) GetMyDiagnoses(c *fiber.Ctx) error {
 
 // GetMyPrescriptions returns all prescriptions for the authenticated patient
 func (h *PatientPortalHandler) GetMyPrescriptions(c *fiber.Ctx) error {
-	patientID := c.Locals("patient_id").(uint)
+	patientID, err := portalPatientID(h.db, c, models.DelegationScopeViewPrescriptions)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
@@ -205,8 +255,10 @@ func (h *PatientPortalHandler) GetMyPrescriptions(c *fiber.Ctx) error {
 
 	query.Count(&total)
 
+	// Dispensing history shows whether each medicine was actually supplied
 	if err := query.Preload("Visit").Preload("Visit.Clinic").Preload("Visit.Staff").
-		Offset(offset).Limit(perPage).Order("created_at DESC").Find(&prescriptions).Error; err != nil {
+		Preload("Dispensations", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Offset(offset).Limit(perPage).Order("prescriptions.created_at DESC").Find(&prescriptions).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch prescriptions",
 		})
@@ -217,6 +269,8 @@ func (h *PatientPortalHandler) GetMyPrescriptions(c *fiber.Ctx) error {
 		totalPages++
 	}
 
+	auditPrescriptions(h.db, c, models.AuditActionList, prescriptions)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       prescriptions,
 		Page:       page,
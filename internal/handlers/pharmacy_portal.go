@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"rural_health_management_system/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type PharmacyPortalHandler struct {
+	db *gorm.DB
+}
+
+func NewPharmacyPortalHandler(db *gorm.DB) *PharmacyPortalHandler {
+	return &PharmacyPortalHandler{db: db}
+}
+
+// GetMyProfile returns the pharmacist's own profile
+func (h *PharmacyPortalHandler) GetMyProfile(c *fiber.Ctx) error {
+	staffID := c.Locals("staff_id").(uint)
+
+	var staff models.Staff
+	if err := h.db.Preload("Clinic").Preload("User").First(&staff, staffID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Staff profile not found",
+		})
+	}
+
+	return c.JSON(staff)
+}
+
+// GetPrescriptions returns all prescriptions written in the pharmacist's clinic
+func (h *PharmacyPortalHandler) GetPrescriptions(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	patientID := c.Query("patient_id")
+	search := c.Query("search")
+	activeOnly := c.Query("active_only") == "true"
+
+	offset := (page - 1) * perPage
+
+	var prescriptions []models.Prescription
+	var total int64
+
+	query := h.db.Model(&models.Prescription{}).
+		Joins("JOIN visits ON prescriptions.visit_id = visits.id").
+		Where("visits.clinic_id = ?", clinicID).
+		Preload("Visit").
+		Preload("Visit.Patient").
+		Preload("Visit.Staff")
+
+	if patientID != "" {
+		query = query.Where("visits.patient_id = ?", patientID)
+	}
+
+	if search != "" {
+		query = query.Where("prescriptions.medication_name ILIKE ?", "%"+search+"%")
+	}
+
+	// Filter by dispensing status, e.g. not_dispensed for the dispensing queue
+	if status := c.Query("dispensing_status"); status != "" {
+		query = query.Where("prescriptions.dispensing_status = ?", status)
+	}
+
+	// Filter for active prescriptions (not expired)
+	if activeOnly {
+		query = query.Where("prescriptions.created_at + INTERVAL '1 day' * prescriptions.duration_days > ?", time.Now())
+	}
+
+	query.Count(&total)
+
+	if err := query.Offset(offset).Limit(perPage).Order("prescriptions.created_at DESC").Find(&prescriptions).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch prescriptions",
+		})
+	}
+
+	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
+
+	auditPrescriptions(h.db, c, models.AuditActionList, prescriptions)
+
+	return c.JSON(models.PaginationResponse{
+		Data:       prescriptions,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
+// GetPrescription returns a specific prescription from the pharmacist's clinic
+func (h *PharmacyPortalHandler) GetPrescription(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+	prescriptionID := c.Params("id")
+
+	var prescription models.Prescription
+	if err := h.db.Model(&models.Prescription{}).
+		Joins("JOIN visits ON prescriptions.visit_id = visits.id").
+		Where("prescriptions.id = ? AND visits.clinic_id = ?", prescriptionID, clinicID).
+		Preload("Visit").
+		Preload("Visit.Patient").
+		Preload("Visit.Staff").
+		First(&prescription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Prescription not found or access denied",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch prescription",
+		})
+	}
+
+	auditPrescriptions(h.db, c, models.AuditActionView, []models.Prescription{prescription})
+
+	return c.JSON(prescription)
+}
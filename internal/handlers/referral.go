@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReferralHandler struct {
+	db *gorm.DB
+}
+
+func NewReferralHandler(db *gorm.DB) *ReferralHandler {
+	return &ReferralHandler{db: db}
+}
+
+// CreateReferral refers the patient of one of the clinic's visits to another clinic
+func (h *ReferralHandler) CreateReferral(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var req models.CreateReferralRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	req.Urgency = strings.ToLower(strings.TrimSpace(req.Urgency))
+	if req.Urgency == "" {
+		req.Urgency = models.ReferralUrgencyRoutine
+	}
+	if len(req.Reason) < 5 || len(req.Reason) > 1000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reason must be between 5 and 1000 characters",
+		})
+	}
+	if !models.IsValidReferralUrgency(req.Urgency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "urgency must be one of routine, urgent or emergency",
+		})
+	}
+
+	var visit models.Visit
+	if err := h.db.Scopes(writableClinicVisits(clinicID)).Where("visits.id = ?", req.VisitID).First(&visit).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Visit not found in this clinic",
+		})
+	}
+
+	if req.ToClinicID == clinicID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot refer a patient to the same clinic",
+		})
+	}
+	var toClinic models.Clinic
+	if err := h.db.First(&toClinic, req.ToClinicID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Receiving clinic not found",
+		})
+	}
+
+	var open int64
+	h.db.Model(&models.Referral{}).
+		Where("visit_id = ? AND to_clinic_id = ? AND status IN ?", visit.ID, toClinic.ID,
+			[]string{models.ReferralStatusSent, models.ReferralStatusAccepted}).
+		Count(&open)
+	if open > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This visit already has an open referral to that clinic",
+		})
+	}
+
+	referral := models.Referral{
+		PatientID:    visit.PatientID,
+		FromClinicID: clinicID,
+		ToClinicID:   toClinic.ID,
+		VisitID:      visit.ID,
+		Reason:       req.Reason,
+		Urgency:      req.Urgency,
+		Status:       models.ReferralStatusSent,
+	}
+	if staffID, ok := c.Locals("staff_id").(uint); ok {
+		referral.ReferredByStaffID = &staffID
+	}
+
+	if err := h.db.Create(&referral).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create referral",
+		})
+	}
+
+	h.db.Preload("Patient").Preload("FromClinic").Preload("ToClinic").Preload("ReferredBy").First(&referral, referral.ID)
+	return c.Status(fiber.StatusCreated).JSON(referral)
+}
+
+// listReferrals lists referrals matching the clinic column (incoming or outgoing)
+func (h *ReferralHandler) listReferrals(c *fiber.Ctx, clinicColumn string) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := h.db.Model(&models.Referral{}).Where(clinicColumn+" = ?", clinicID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if urgency := c.Query("urgency"); urgency != "" {
+		query = query.Where("urgency = ?", urgency)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	// Emergencies first, then oldest first so nothing waits unnoticed
+	var referrals []models.Referral
+	if err := query.Preload("Patient").Preload("FromClinic").Preload("ToClinic").Preload("ReferredBy").
+		Order("CASE urgency WHEN 'emergency' THEN 0 WHEN 'urgent' THEN 1 ELSE 2 END, created_at").
+		Offset((page - 1) * perPage).Limit(perPage).
+		Find(&referrals).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch referrals",
+		})
+	}
+
+	entries := make([]models.AuditLog, 0, len(referrals))
+	for _, r := range referrals {
+		entries = append(entries, newAuditEntry(c, models.AuditActionList, models.AuditEntityPatient, r.PatientID, r.PatientID))
+	}
+	writeAudit(h.db, entries)
+
+	return c.JSON(models.PaginationResponse{
+		Data:       referrals,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	})
+}
+
+// GetIncomingReferrals lists referrals sent to the caller's clinic
+func (h *ReferralHandler) GetIncomingReferrals(c *fiber.Ctx) error {
+	return h.listReferrals(c, "to_clinic_id")
+}
+
+// GetOutgoingReferrals lists referrals the caller's clinic has sent
+func (h *ReferralHandler) GetOutgoingReferrals(c *fiber.Ctx) error {
+	return h.listReferrals(c, "from_clinic_id")
+}
+
+// GetReferral returns a referral with the referring visit's diagnoses and
+// prescriptions. Both the referring and the receiving clinic may read it.
+func (h *ReferralHandler) GetReferral(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var referral models.Referral
+	if err := h.db.Preload("Patient").Preload("FromClinic").Preload("ToClinic").
+		Preload("ReferredBy").Preload("RespondedBy").
+		Preload("Visit").Preload("Visit.Staff").Preload("Visit.Diagnoses").Preload("Visit.Prescriptions").Preload("Visit.VitalSigns").
+		Where("id = ? AND (from_clinic_id = ? OR to_clinic_id = ?)", c.Params("id"), clinicID, clinicID).
+		First(&referral).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Referral not found",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, referral.PatientID, referral.PatientID, nil, nil)
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityVisit, referral.VisitID, referral.PatientID, nil, nil)
+	if referral.Visit != nil {
+		auditDiagnoses(h.db, c, models.AuditActionView, referral.Visit.Diagnoses)
+		auditPrescriptions(h.db, c, models.AuditActionView, referral.Visit.Prescriptions)
+	}
+
+	return c.JSON(referral)
+}
+
+// UpdateReferralStatus lets the receiving clinic accept, decline or complete a referral
+func (h *ReferralHandler) UpdateReferralStatus(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var referral models.Referral
+	if err := h.db.Where("id = ? AND to_clinic_id = ?", c.Params("id"), clinicID).First(&referral).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Referral not found",
+		})
+	}
+
+	var req models.UpdateReferralStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	req.FeedbackNote = strings.TrimSpace(req.FeedbackNote)
+	if !models.CanTransitionReferral(referral.Status, req.Status) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Cannot change referral from " + referral.Status + " to " + req.Status,
+		})
+	}
+	if req.Status == models.ReferralStatusDeclined && req.FeedbackNote == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "feedback_note is required when declining a referral",
+		})
+	}
+	if len(req.FeedbackNote) > 2000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "feedback_note must be at most 2000 characters",
+		})
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status": req.Status,
+	}
+	if req.FeedbackNote != "" {
+		updates["feedback_note"] = req.FeedbackNote
+	}
+	if staffID, ok := c.Locals("staff_id").(uint); ok {
+		updates["responded_by_staff_id"] = staffID
+	}
+	switch req.Status {
+	case models.ReferralStatusAccepted:
+		updates["accepted_at"] = now
+	case models.ReferralStatusCompleted:
+		updates["completed_at"] = now
+	case models.ReferralStatusDeclined:
+		updates["declined_at"] = now
+	}
+
+	// Conditional on the current status so concurrent responses cannot both apply
+	result := h.db.Model(&models.Referral{}).
+		Where("id = ? AND status = ?", referral.ID, referral.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update referral",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Referral was updated by someone else, reload and try again",
+		})
+	}
+
+	h.db.Preload("Patient").Preload("FromClinic").Preload("ToClinic").Preload("ReferredBy").Preload("RespondedBy").
+		First(&referral, referral.ID)
+	return c.JSON(referral)
+}
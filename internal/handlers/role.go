@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"strconv"
+
+	"rural_health_management_system/internal/models"
+	"rural_health_management_system/internal/permissions"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleHandler manages roles and their permission grants
+type RoleHandler struct {
+	db       *gorm.DB
+	resolver *permissions.Resolver
+}
+
+func NewRoleHandler(db *gorm.DB, resolver *permissions.Resolver) *RoleHandler {
+	return &RoleHandler{db: db, resolver: resolver}
+}
+
+// GetPermissions - GET /permissions lists every grantable permission
+func (h *RoleHandler) GetPermissions(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"permissions": models.AllPermissions,
+	})
+}
+
+// GetRoles - GET /roles lists roles with their default permissions
+// (or a clinic's effective permissions when clinic_id is given)
+func (h *RoleHandler) GetRoles(c *fiber.Ctx) error {
+	var clinicID *uint
+	if idStr := c.Query("clinic_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Invalid clinic ID",
+			})
+		}
+		cid := uint(id)
+		clinicID = &cid
+	}
+
+	var roles []models.Role
+	if err := h.db.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch roles",
+		})
+	}
+
+	response := make([]models.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		roleResponse, err := h.buildRoleResponse(role, clinicID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error: "Failed to resolve role permissions",
+			})
+		}
+		response = append(response, roleResponse)
+	}
+
+	return c.JSON(response)
+}
+
+// GetRole - GET /roles/:id
+func (h *RoleHandler) GetRole(c *fiber.Ctx) error {
+	var role models.Role
+	if err := h.db.Preload("Permissions").First(&role, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Role not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch role",
+		})
+	}
+
+	var overrides []models.ClinicPermissionOverride
+	h.db.Preload("Clinic").Where("role_id = ?", role.ID).Order("clinic_id, permission").Find(&overrides)
+
+	response, err := h.buildRoleResponse(role, nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to resolve role permissions",
+		})
+	}
+	response.Overrides = overrides
+
+	return c.JSON(response)
+}
+
+// UpdateRolePermissions - PUT /roles/:id/permissions replaces a role's default permissions
+func (h *RoleHandler) UpdateRolePermissions(c *fiber.Ctx) error {
+	var role models.Role
+	if err := h.db.First(&role, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Role not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch role",
+		})
+	}
+
+	var req models.UpdateRolePermissionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid input",
+			Details: err.Error(),
+		})
+	}
+
+	for _, p := range req.Permissions {
+		if !models.IsValidPermission(p) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Unknown permission",
+				Details: p,
+			})
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Description != nil {
+			if err := tx.Model(&role).Update("description", *req.Description).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		seen := make(map[models.Permission]bool)
+		for _, p := range req.Permissions {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if err := tx.Create(&models.RolePermission{RoleID: role.ID, Permission: p}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to update role permissions",
+		})
+	}
+
+	h.resolver.Invalidate()
+
+	h.db.Preload("Permissions").First(&role, role.ID)
+	response, _ := h.buildRoleResponse(role, nil)
+	return c.JSON(response)
+}
+
+// SetPermissionOverride - PUT /roles/:id/overrides grants or revokes a permission for one clinic
+func (h *RoleHandler) SetPermissionOverride(c *fiber.Ctx) error {
+	var role models.Role
+	if err := h.db.First(&role, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Role not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch role",
+		})
+	}
+
+	var req models.SetPermissionOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid input",
+			Details: err.Error(),
+		})
+	}
+
+	if !models.IsValidPermission(req.Permission) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Unknown permission",
+			Details: req.Permission,
+		})
+	}
+
+	var clinic models.Clinic
+	if err := h.db.First(&clinic, req.ClinicID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Clinic not found",
+		})
+	}
+
+	override := models.ClinicPermissionOverride{
+		ClinicID:   req.ClinicID,
+		RoleID:     role.ID,
+		Permission: req.Permission,
+		Granted:    req.Granted,
+	}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "clinic_id"}, {Name: "role_id"}, {Name: "permission"}},
+		DoUpdates: clause.AssignmentColumns([]string{"granted", "updated_at"}),
+	}).Create(&override).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to save permission override",
+		})
+	}
+
+	h.resolver.Invalidate()
+
+	h.db.Where("clinic_id = ? AND role_id = ? AND permission = ?", req.ClinicID, role.ID, req.Permission).First(&override)
+	return c.JSON(override)
+}
+
+// DeletePermissionOverride - DELETE /roles/:id/overrides/:override_id restores the default for a clinic
+func (h *RoleHandler) DeletePermissionOverride(c *fiber.Ctx) error {
+	var override models.ClinicPermissionOverride
+	if err := h.db.Where("id = ? AND role_id = ?", c.Params("override_id"), c.Params("id")).First(&override).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Permission override not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch permission override",
+		})
+	}
+
+	if err := h.db.Delete(&override).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to delete permission override",
+		})
+	}
+
+	h.resolver.Invalidate()
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// GetMyClinicRoles returns the effective permissions of each role in the caller's clinic
+func (h *RoleHandler) GetMyClinicRoles(c *fiber.Ctx) error {
+	clinicID := c.Locals("clinic_id").(uint)
+
+	var roles []models.Role
+	if err := h.db.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch roles",
+		})
+	}
+
+	response := make([]models.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		roleResponse, err := h.buildRoleResponse(role, &clinicID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve role permissions",
+			})
+		}
+		response = append(response, roleResponse)
+	}
+
+	return c.JSON(response)
+}
+
+func (h *RoleHandler) buildRoleResponse(role models.Role, clinicID *uint) (models.RoleResponse, error) {
+	effective, err := h.resolver.Permissions(role.Name, clinicID)
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+	if effective == nil {
+		effective = []models.Permission{}
+	}
+
+	return models.RoleResponse{
+		Role:                 role,
+		EffectivePermissions: effective,
+	}, nil
+}
@@ -149,6 +149,8 @@ func (h *StaffPortalHandler) CreatePatient(c *fiber.Ctx) error {
 	// Load clinic relationship
 	h.db.Preload("Clinic").First(&patient, patient.ID)
 
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityPatient, patient.ID, patient.ID, nil, &patient)
+
 	return c.Status(fiber.StatusCreated).JSON(patient)
 }
 
@@ -171,7 +173,7 @@ func (h *StaffPortalHandler) GetMyPatients(c *fiber.Ctx) error {
 	query := h.db.Model(&models.Patient{}).Where("clinic_id = ?", clinicID)
 
 	if search != "" {
-		query = query.Where("full_name ILIKE ? OR phone ILIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Scopes(patientSearch(search))
 	}
 
 	query.Count(&total)
@@ -184,6 +186,8 @@ func (h *StaffPortalHandler) GetMyPatients(c *fiber.Ctx) error {
 
 	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
 
+	auditPatients(h.db, c, models.AuditActionList, patients)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       patients,
 		Page:       page,
@@ -204,12 +208,17 @@ func (h *StaffPortalHandler) GetMyPatient(c *fiber.Ctx) error {
 	}
 
 	var patient models.Patient
-	if err := h.db.Preload("Clinic").Preload("Visits.Staff").Preload("Visits.Diagnoses").Preload("Visits.Prescriptions").Where("id = ? AND clinic_id = ?", patientID, clinicID).First(&patient).Error; err != nil {
+	if err := h.db.Preload("Clinic").Preload("Visits.Clinic").Preload("Visits.Staff").Preload("Visits.Diagnoses").Preload("Visits.Prescriptions").Where("id = ? AND clinic_id = ?", patientID, clinicID).First(&patient).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Patient not found",
 		})
 	}
 
+	// Visits from clinics the patient was transferred from are read-only here
+	models.MarkReadOnlyVisits(patient.Visits, clinicID)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
 	return c.JSON(patient)
 }
 
@@ -247,9 +256,10 @@ func (h *StaffPortalHandler) CreateStaff(c *fiber.Ctx) error {
 		userType = "doctor"
 	case "Nurse":
 		userType = "nurse"
-	case "Clinic_Administrator", "Pharmacist":
-		// These roles don't get login accounts
-		userType = ""
+	case "Pharmacist":
+		userType = "pharmacist"
+	case "Clinic_Administrator":
+		userType = "clinic_admin"
 	default:
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid role",
@@ -264,21 +274,18 @@ func (h *StaffPortalHandler) CreateStaff(c *fiber.Ctx) error {
 		}
 	}()
 
-	var user *models.User
-	// Create user only for Doctor and Nurse roles
-	if userType != "" {
-		user = &models.User{
-			Email:    req.Email,
-			Password: string(hashedPassword),
-			UserType: userType,
-			IsActive: true,
-		}
-		if err := tx.Create(user).Error; err != nil {
-			tx.Rollback()
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create user",
-			})
-		}
+	// Create login account for the staff member
+	user := &models.User{
+		Email:    req.Email,
+		Password: string(hashedPassword),
+		UserType: userType,
+		IsActive: true,
+	}
+	if err := tx.Create(user).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create user",
+		})
 	}
 
 	// Create staff
@@ -288,13 +295,10 @@ func (h *StaffPortalHandler) CreateStaff(c *fiber.Ctx) error {
 		Phone:    req.Phone,
 		Email:    req.Email,
 		ClinicID: clinicID,
+		UserID:   &user.ID,
 		IsActive: true,
 	}
 
-	if user != nil {
-		staff.UserID = &user.ID
-	}
-
 	if err := tx.Create(&staff).Error; err != nil {
 		tx.Rollback()
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -404,6 +408,8 @@ func (h *StaffPortalHandler) CreateVisit(c *fiber.Ctx) error {
 	// Load relationships
 	h.db.Preload("Patient").Preload("Clinic").Preload("Staff").First(&visit, visit.ID)
 
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, &visit)
+
 	return c.Status(fiber.StatusCreated).JSON(visit)
 }
 
@@ -424,7 +430,7 @@ func (h *StaffPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 	var visits []models.Visit
 	var total int64
 
-	query := h.db.Model(&models.Visit{}).Where("clinic_id = ?", clinicID)
+	query := h.db.Model(&models.Visit{}).Scopes(clinicVisits(clinicID))
 
 	if patientID != "" {
 		query = query.Where("patient_id = ?", patientID)
@@ -436,14 +442,17 @@ func (h *StaffPortalHandler) GetMyVisits(c *fiber.Ctx) error {
 
 	query.Count(&total)
 
-	if err := query.Preload("Patient").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
+	if err := query.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Offset(offset).Limit(perPage).Order("visit_date DESC").Find(&visits).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch visits",
 		})
 	}
+	models.MarkReadOnlyVisits(visits, clinicID)
 
 	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
 
+	auditVisits(h.db, c, models.AuditActionList, visits)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       visits,
 		Page:       page,
@@ -464,11 +473,14 @@ func (h *StaffPortalHandler) GetMyVisit(c *fiber.Ctx) error {
 	}
 
 	var visit models.Visit
-	if err := h.db.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Where("id = ? AND clinic_id = ?", visitID, clinicID).First(&visit).Error; err != nil {
+	if err := h.db.Preload("Patient").Preload("Clinic").Preload("Staff").Preload("Diagnoses").Preload("Prescriptions").Preload("VitalSigns").Scopes(clinicVisits(clinicID)).Where("id = ?", visitID).First(&visit).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Visit not found",
 		})
 	}
+	visit.ReadOnly = models.VisitReadOnly(visit, clinicID)
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, nil)
 
 	return c.JSON(visit)
 }
@@ -279,6 +279,8 @@ func (h *VisitHandler) GetVisits(c *fiber.Ctx) error {
 
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
+	auditVisits(h.db, c, models.AuditActionList, visits)
+
 	return c.JSON(models.PaginationResponse{
 		Data:       visits,
 		Page:       page,
@@ -293,7 +295,7 @@ func (h *VisitHandler) GetVisitByID(c *fiber.Ctx) error {
 
 	var visit models.Visit
 	if err := h.db.Preload("Patient").Preload("Clinic").Preload("Staff").
-		Preload("Diagnoses").Preload("Prescriptions").First(&visit, id).Error; err != nil {
+		Preload("Diagnoses").Preload("Prescriptions").Preload("VitalSigns").First(&visit, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
 				Error: "Visit not found",
@@ -304,6 +306,8 @@ func (h *VisitHandler) GetVisitByID(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, nil)
+
 	return c.JSON(visit)
 }
 
@@ -357,6 +361,7 @@ func (h *VisitHandler) CreateVisit(c *fiber.Ctx) error {
 	}
 
 	h.db.Preload("Patient").Preload("Clinic").Preload("Staff").First(&visit, visit.ID)
+	recordAudit(h.db, c, models.AuditActionCreate, models.AuditEntityVisit, visit.ID, visit.PatientID, nil, &visit)
 	return c.Status(fiber.StatusCreated).JSON(visit)
 }
 
@@ -382,6 +387,8 @@ func (h *VisitHandler) UpdateVisit(c *fiber.Ctx) error {
 		})
 	}
 
+	before := visit
+
 	// Update fields if provided
 	if updates.PatientID != 0 {
 		var patient models.Patient
@@ -429,6 +436,7 @@ func (h *VisitHandler) UpdateVisit(c *fiber.Ctx) error {
 	}
 
 	h.db.Preload("Patient").Preload("Clinic").Preload("Staff").First(&visit, visit.ID)
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityVisit, visit.ID, visit.PatientID, &before, &visit)
 	return c.JSON(visit)
 }
 
@@ -464,5 +472,7 @@ func (h *VisitHandler) DeleteVisit(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(h.db, c, models.AuditActionDelete, models.AuditEntityVisit, visit.ID, visit.PatientID, &visit, nil)
+
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
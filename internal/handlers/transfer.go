@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// clinicVisits scopes a visit query to the visits a clinic may read: its own,
+// plus earlier visits at other clinics of patients who transferred in
+func clinicVisits(clinicID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		patients := db.Session(&gorm.Session{NewDB: true}).Model(&models.Patient{}).
+			Select("id").Where("clinic_id = ?", clinicID)
+		return db.Where("(visits.clinic_id = ? OR visits.patient_id IN (?))", clinicID, patients)
+	}
+}
+
+// writableClinicVisits scopes a visit query to the visits a clinic may change
+// or add records to: its own visits of patients still registered with it.
+// After a transfer the old clinic's visits are history, read-only to both.
+func writableClinicVisits(clinicID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN patients ON patients.id = visits.patient_id AND patients.deleted_at IS NULL").
+			Where("visits.clinic_id = ? AND patients.clinic_id = ?", clinicID, clinicID)
+	}
+}
+
+var errPatientTransferred = errors.New("patient clinic changed concurrently")
+
+type TransferHandler struct {
+	db *gorm.DB
+}
+
+func NewTransferHandler(db *gorm.DB) *TransferHandler {
+	return &TransferHandler{db: db}
+}
+
+// TransferPatient moves a patient's home clinic. Clinic users can only transfer
+// their own patients out; administrators can transfer any patient. Booked
+// appointments at the old clinic are cancelled.
+func (h *TransferHandler) TransferPatient(c *fiber.Ctx) error {
+	query := h.db.Where("id = ?", c.Params("id"))
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var patient models.Patient
+	if err := query.First(&patient).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var req models.TransferPatientRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.Reason) < 5 || len(req.Reason) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reason must be between 5 and 500 characters",
+		})
+	}
+	if req.ToClinicID == patient.ClinicID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Patient is already registered at that clinic",
+		})
+	}
+
+	var toClinic models.Clinic
+	if err := h.db.First(&toClinic, req.ToClinicID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Destination clinic not found",
+		})
+	}
+
+	before := patient
+	transfer := models.PatientTransfer{
+		PatientID:           patient.ID,
+		FromClinicID:        patient.ClinicID,
+		ToClinicID:          toClinic.ID,
+		Reason:              req.Reason,
+		TransferredByUserID: currentUserID(c),
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Conditional on the current clinic so two concurrent transfers cannot both apply
+		result := tx.Model(&models.Patient{}).
+			Where("id = ? AND clinic_id = ?", patient.ID, patient.ClinicID).
+			Update("clinic_id", toClinic.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPatientTransferred
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Appointment{}).
+			Where("patient_id = ? AND clinic_id = ? AND status = ? AND start_time > ?",
+				patient.ID, patient.ClinicID, models.AppointmentStatusBooked, now).
+			Updates(map[string]interface{}{
+				"status":              models.AppointmentStatusCancelled,
+				"cancelled_at":        now,
+				"cancellation_reason": "Patient transferred to " + truncate(toClinic.Name, 400),
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&transfer).Error
+	})
+	if err == errPatientTransferred {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Patient was transferred by someone else, reload and try again",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to transfer patient",
+		})
+	}
+
+	patient.ClinicID = toClinic.ID
+	recordAudit(h.db, c, models.AuditActionUpdate, models.AuditEntityPatient, patient.ID, patient.ID, &before, &patient)
+
+	h.db.Preload("FromClinic").Preload("ToClinic").First(&transfer, transfer.ID)
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// GetPatientTransfers lists a patient's transfer history. Clinic users can see
+// the history of patients currently registered at their clinic.
+func (h *TransferHandler) GetPatientTransfers(c *fiber.Ctx) error {
+	query := h.db.Where("id = ?", c.Params("id"))
+	if clinicID := callerClinic(c); clinicID != nil {
+		query = query.Where("clinic_id = ?", *clinicID)
+	}
+
+	var patient models.Patient
+	if err := query.First(&patient).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Patient not found",
+		})
+	}
+
+	var transfers []models.PatientTransfer
+	if err := h.db.Preload("FromClinic").Preload("ToClinic").
+		Where("patient_id = ?", patient.ID).Order("created_at, id").
+		Find(&transfers).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch transfer history",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditActionView, models.AuditEntityPatient, patient.ID, patient.ID, nil, nil)
+
+	return c.JSON(transfers)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"rural_health_management_system/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestWritableClinicVisitsRequiresCurrentClinic(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visit models.Visit
+	stmt := db.Scopes(writableClinicVisits(3)).Where("visits.id = ?", 5).First(&visit).Statement
+	sql := stmt.SQL.String()
+
+	// The old clinic recorded the visit, but the patient is now registered
+	// elsewhere, so the patient's clinic must be checked as well
+	for _, want := range []string{"JOIN patients ON patients.id = visits.patient_id", "visits.clinic_id = $", "patients.clinic_id = $"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("query %q does not contain %q", sql, want)
+		}
+	}
+	clinicArgs := 0
+	for _, v := range stmt.Vars {
+		if v == uint(3) {
+			clinicArgs++
+		}
+	}
+	if clinicArgs != 2 {
+		t.Errorf("query arguments %v, want clinic 3 for both the visit and the patient", stmt.Vars)
+	}
+}
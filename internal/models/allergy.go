@@ -0,0 +1,197 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Allergy severities
+const (
+	AllergySeverityMild     = "mild"
+	AllergySeverityModerate = "moderate"
+	AllergySeveritySevere   = "severe"
+)
+
+// Clinical list statuses
+const (
+	ClinicalStatusActive   = "active"
+	ClinicalStatusInactive = "inactive" // allergy refuted or entered in error
+	ClinicalStatusResolved = "resolved" // problem no longer current
+)
+
+// PatientAllergy is a recorded allergy or intolerance
+type PatientAllergy struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	PatientID      uint      `json:"patient_id" gorm:"not null;index" validate:"required"`
+	Substance      string    `json:"substance" gorm:"not null;size:255" validate:"required,min=2,max=255"`
+	Reaction       string    `json:"reaction" gorm:"size:500" validate:"max=500"`
+	Severity       string    `json:"severity" gorm:"not null;size:20" validate:"required,oneof=mild moderate severe"`
+	Status         string    `json:"status" gorm:"not null;size:20;default:active" validate:"oneof=active inactive"`
+	NotedByStaffID *uint     `json:"noted_by_staff_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relationships
+	NotedBy *Staff `json:"noted_by,omitempty" gorm:"foreignKey:NotedByStaffID;references:ID"`
+}
+
+// PatientProblem is an entry on the patient's problem list (chronic conditions etc.)
+type PatientProblem struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	PatientID      uint       `json:"patient_id" gorm:"not null;index" validate:"required"`
+	Description    string     `json:"description" gorm:"not null;size:500" validate:"required,min=2,max=500"`
+	Code           string     `json:"code,omitempty" gorm:"size:20" validate:"max=20"` // e.g. ICD-10
+	Status         string     `json:"status" gorm:"not null;size:20;default:active" validate:"oneof=active resolved"`
+	OnsetDate      *time.Time `json:"onset_date,omitempty"`
+	ResolvedDate   *time.Time `json:"resolved_date,omitempty"`
+	Notes          string     `json:"notes,omitempty" gorm:"size:1000" validate:"max=1000"`
+	NotedByStaffID *uint      `json:"noted_by_staff_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	NotedBy *Staff `json:"noted_by,omitempty" gorm:"foreignKey:NotedByStaffID;references:ID"`
+}
+
+// AllergyClasses groups medications that share an allergy, keyed by class name.
+// An allergy recorded against the class or any member blocks every member.
+var AllergyClasses = map[string][]string{
+	"penicillin": {
+		"penicillin", "amoxicillin", "ampicillin", "cloxacillin", "flucloxacillin", "dicloxacillin",
+		"benzylpenicillin", "phenoxymethylpenicillin", "benzathine", "piperacillin", "co-amoxiclav", "augmentin",
+	},
+	"cephalosporin": {
+		"cephalexin", "cefalexin", "cefadroxil", "cefuroxime", "cefixime", "cefpodoxime",
+		"ceftriaxone", "cefotaxime", "ceftazidime", "cefepime",
+	},
+	"sulfonamide": {
+		"sulfamethoxazole", "cotrimoxazole", "co-trimoxazole", "sulfadiazine", "sulfasalazine", "sulfadoxine",
+	},
+	"macrolide": {
+		"erythromycin", "azithromycin", "clarithromycin",
+	},
+	"fluoroquinolone": {
+		"ciprofloxacin", "ofloxacin", "levofloxacin", "norfloxacin", "moxifloxacin",
+	},
+	"tetracycline": {
+		"tetracycline", "doxycycline", "minocycline",
+	},
+	"aminoglycoside": {
+		"gentamicin", "amikacin", "streptomycin", "tobramycin",
+	},
+	"nsaid": {
+		"ibuprofen", "diclofenac", "naproxen", "aspirin", "acetylsalicylic", "ketorolac", "indomethacin",
+		"mefenamic", "piroxicam", "aceclofenac",
+	},
+	"opioid": {
+		"morphine", "codeine", "tramadol", "pethidine", "fentanyl", "oxycodone",
+	},
+}
+
+// AllergyMatch describes a recorded allergy that a medication conflicts with
+type AllergyMatch struct {
+	AllergyID uint   `json:"allergy_id"`
+	Substance string `json:"substance"`
+	Class     string `json:"class,omitempty"`
+	Severity  string `json:"severity"`
+	Reaction  string `json:"reaction,omitempty"`
+}
+
+// allergyClassOf returns the class a substance names or belongs to
+func allergyClassOf(substance string) string {
+	s := strings.ToLower(strings.TrimSpace(substance))
+	if _, ok := AllergyClasses[s]; ok {
+		return s
+	}
+	for class, members := range AllergyClasses {
+		for _, m := range members {
+			if s == m {
+				return class
+			}
+		}
+	}
+	return ""
+}
+
+// MatchAllergies returns the active allergies that a medication name conflicts
+// with, either by naming the substance directly or by sharing its allergy class.
+func MatchAllergies(medicationName string, allergies []PatientAllergy) []AllergyMatch {
+	medication := strings.ToLower(medicationName)
+
+	var matches []AllergyMatch
+	for _, a := range allergies {
+		if a.Status != "" && a.Status != ClinicalStatusActive {
+			continue
+		}
+
+		substance := strings.ToLower(strings.TrimSpace(a.Substance))
+		class := allergyClassOf(substance)
+
+		matched := substance != "" && strings.Contains(medication, substance)
+		if !matched && class != "" {
+			for _, member := range AllergyClasses[class] {
+				if strings.Contains(medication, member) {
+					matched = true
+					break
+				}
+			}
+		}
+
+		if matched {
+			matches = append(matches, AllergyMatch{
+				AllergyID: a.ID,
+				Substance: a.Substance,
+				Class:     class,
+				Severity:  a.Severity,
+				Reaction:  a.Reaction,
+			})
+		}
+	}
+	return matches
+}
+
+// RequiresAllergyOverride reports whether any match is serious enough that the
+// prescription must be blocked unless the prescriber gives an override reason.
+// Mild allergies only produce a warning.
+func RequiresAllergyOverride(matches []AllergyMatch) bool {
+	for _, m := range matches {
+		if m.Severity != AllergySeverityMild {
+			return true
+		}
+	}
+	return false
+}
+
+// Allergy and problem list DTOs
+type CreateAllergyRequest struct {
+	Substance string `json:"substance" validate:"required,min=2,max=255"`
+	Reaction  string `json:"reaction" validate:"max=500"`
+	Severity  string `json:"severity" validate:"required,oneof=mild moderate severe"`
+}
+
+type UpdateAllergyRequest struct {
+	Reaction *string `json:"reaction,omitempty" validate:"omitempty,max=500"`
+	Severity *string `json:"severity,omitempty" validate:"omitempty,oneof=mild moderate severe"`
+	Status   *string `json:"status,omitempty" validate:"omitempty,oneof=active inactive"`
+}
+
+type CreateProblemRequest struct {
+	Description string `json:"description" validate:"required,min=2,max=500"`
+	Code        string `json:"code" validate:"max=20"`
+	OnsetDate   string `json:"onset_date"` // YYYY-MM-DD
+	Notes       string `json:"notes" validate:"max=1000"`
+}
+
+type UpdateProblemRequest struct {
+	Description *string `json:"description,omitempty" validate:"omitempty,min=2,max=500"`
+	Code        *string `json:"code,omitempty" validate:"omitempty,max=20"`
+	Status      *string `json:"status,omitempty" validate:"omitempty,oneof=active resolved"`
+	Notes       *string `json:"notes,omitempty" validate:"omitempty,max=1000"`
+}
+
+// PrescriptionResponse is a created prescription with any safety warnings
+type PrescriptionResponse struct {
+	Prescription
+	AllergyWarnings     []AllergyMatch       `json:"allergy_warnings,omitempty"`
+	InteractionWarnings []InteractionWarning `json:"interaction_warnings,omitempty"`
+}
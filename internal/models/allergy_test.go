@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestMatchAllergies(t *testing.T) {
+	allergies := []PatientAllergy{
+		{ID: 1, Substance: "Penicillin", Severity: AllergySeveritySevere, Status: ClinicalStatusActive},
+		{ID: 2, Substance: "Sulfa", Severity: AllergySeverityMild, Status: ClinicalStatusActive},
+		{ID: 3, Substance: "Ibuprofen", Severity: AllergySeverityModerate, Status: ClinicalStatusInactive},
+		{ID: 4, Substance: "Doxycycline", Severity: AllergySeverityMild, Status: ClinicalStatusActive},
+	}
+
+	tests := []struct {
+		medication string
+		wantIDs    []uint
+	}{
+		{"Amoxicillin 500mg", []uint{1}},
+		{"PENICILLIN V", []uint{1}},
+		{"Tetracycline", []uint{4}}, // member of the same class as a recorded member
+		{"Ibuprofen 400mg", nil},    // allergy is inactive
+		{"Paracetamol", nil},
+		{"Co-trimoxazole (sulfamethoxazole)", []uint{2}}, // substance named within the medication
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.medication, func(t *testing.T) {
+			matches := MatchAllergies(tt.medication, allergies)
+			if len(matches) != len(tt.wantIDs) {
+				t.Fatalf("got %d matches %v, want %v", len(matches), matches, tt.wantIDs)
+			}
+			for i, m := range matches {
+				if m.AllergyID != tt.wantIDs[i] {
+					t.Errorf("match %d allergy = %d, want %d", i, m.AllergyID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRequiresAllergyOverride(t *testing.T) {
+	if RequiresAllergyOverride([]AllergyMatch{{Severity: AllergySeverityMild}}) {
+		t.Error("mild allergies should only warn")
+	}
+	if !RequiresAllergyOverride([]AllergyMatch{{Severity: AllergySeverityMild}, {Severity: AllergySeveritySevere}}) {
+		t.Error("severe allergy should require an override")
+	}
+	if RequiresAllergyOverride(nil) {
+		t.Error("no matches should not require an override")
+	}
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Appointment statuses
+const (
+	AppointmentStatusBooked    = "booked"
+	AppointmentStatusCheckedIn = "checked_in"
+	AppointmentStatusCancelled = "cancelled"
+	AppointmentStatusNoShow    = "no_show"
+)
+
+// DefaultSlotMinutes is used when a working-hours template does not set a slot length
+const DefaultSlotMinutes = 15
+
+// Appointment is a booked slot with a staff member. Checking in creates the Visit.
+type Appointment struct {
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	PatientID          uint           `json:"patient_id" gorm:"not null;index" validate:"required"`
+	ClinicID           uint           `json:"clinic_id" gorm:"not null;index" validate:"required"`
+	StaffID            uint           `json:"staff_id" gorm:"not null;index:idx_appointment_staff_start" validate:"required"`
+	StartTime          time.Time      `json:"start_time" gorm:"not null;index:idx_appointment_staff_start" validate:"required"`
+	EndTime            time.Time      `json:"end_time" gorm:"not null" validate:"required"`
+	Status             string         `json:"status" gorm:"not null;size:20;default:booked" validate:"oneof=booked checked_in cancelled no_show"`
+	Reason             string         `json:"reason" gorm:"not null;size:500" validate:"required,min=5,max=500"`
+	Notes              string         `json:"notes" gorm:"size:1000" validate:"max=1000"`
+	VisitID            *uint          `json:"visit_id,omitempty" gorm:"index"`
+	BookedByUserID     *uint          `json:"booked_by_user_id,omitempty"`
+	CheckedInAt        *time.Time     `json:"checked_in_at,omitempty"`
+	CancelledAt        *time.Time     `json:"cancelled_at,omitempty"`
+	CancellationReason string         `json:"cancellation_reason,omitempty" gorm:"size:500"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Patient *Patient `json:"patient,omitempty" gorm:"foreignKey:PatientID;references:ID"`
+	Clinic  *Clinic  `json:"clinic,omitempty" gorm:"foreignKey:ClinicID;references:ID"`
+	Staff   *Staff   `json:"staff,omitempty" gorm:"foreignKey:StaffID;references:ID"`
+	Visit   *Visit   `json:"visit,omitempty" gorm:"foreignKey:VisitID;references:ID"`
+}
+
+// HoldsSlot reports whether the appointment still occupies its time slot
+func (a Appointment) HoldsSlot() bool {
+	return a.Status == AppointmentStatusBooked || a.Status == AppointmentStatusCheckedIn
+}
+
+// StaffWorkingHours is a weekly template of when a staff member sees patients
+type StaffWorkingHours struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	StaffID     uint      `json:"staff_id" gorm:"not null;index" validate:"required"`
+	ClinicID    uint      `json:"clinic_id" gorm:"not null;index" validate:"required"`
+	Weekday     int       `json:"weekday" gorm:"not null" validate:"min=0,max=6"`        // 0 = Sunday
+	StartTime   string    `json:"start_time" gorm:"not null;size:5" validate:"required"` // HH:MM
+	EndTime     string    `json:"end_time" gorm:"not null;size:5" validate:"required"`   // HH:MM
+	SlotMinutes int       `json:"slot_minutes" gorm:"not null;default:15" validate:"min=5,max=240"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Staff *Staff `json:"staff,omitempty" gorm:"foreignKey:StaffID;references:ID"`
+}
+
+// Window returns the start and end of the working period on the given day
+func (w StaffWorkingHours) Window(day time.Time) (time.Time, time.Time, error) {
+	startMinutes, err := ParseClock(w.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endMinutes, err := ParseClock(w.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return midnight.Add(time.Duration(startMinutes) * time.Minute), midnight.Add(time.Duration(endMinutes) * time.Minute), nil
+}
+
+// SlotLength returns the configured slot length
+func (w StaffWorkingHours) SlotLength() time.Duration {
+	if w.SlotMinutes <= 0 {
+		return DefaultSlotMinutes * time.Minute
+	}
+	return time.Duration(w.SlotMinutes) * time.Minute
+}
+
+// TimeSlot is a bookable period
+type TimeSlot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// ParseClock converts an HH:MM time of day to minutes since midnight
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, use HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Overlaps reports whether [aStart, aEnd) and [bStart, bEnd) intersect
+func Overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// AvailableSlots lists the free slots on a day given a staff member's working
+// hours and existing appointments. Slots starting before notBefore are skipped.
+func AvailableSlots(hours []StaffWorkingHours, appointments []Appointment, day, notBefore time.Time) []TimeSlot {
+	slots := []TimeSlot{}
+	for _, h := range hours {
+		if h.Weekday != int(day.Weekday()) {
+			continue
+		}
+		windowStart, windowEnd, err := h.Window(day)
+		if err != nil {
+			continue
+		}
+
+		length := h.SlotLength()
+		for start := windowStart; !start.Add(length).After(windowEnd); start = start.Add(length) {
+			end := start.Add(length)
+			if start.Before(notBefore) || slotTaken(appointments, start, end) {
+				continue
+			}
+			slots = append(slots, TimeSlot{StartTime: start, EndTime: end})
+		}
+	}
+	return slots
+}
+
+// WithinWorkingHours reports whether a period falls entirely inside one working window
+func WithinWorkingHours(hours []StaffWorkingHours, start, end time.Time) bool {
+	for _, h := range hours {
+		if h.Weekday != int(start.Weekday()) {
+			continue
+		}
+		windowStart, windowEnd, err := h.Window(start)
+		if err != nil {
+			continue
+		}
+		if !start.Before(windowStart) && !end.After(windowEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func slotTaken(appointments []Appointment, start, end time.Time) bool {
+	for _, a := range appointments {
+		if a.HoldsSlot() && Overlaps(start, end, a.StartTime, a.EndTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Appointment DTOs
+type CreateAppointmentRequest struct {
+	PatientID       uint      `json:"patient_id" validate:"required"`
+	StaffID         uint      `json:"staff_id" validate:"required"`
+	StartTime       time.Time `json:"start_time" validate:"required"`
+	DurationMinutes int       `json:"duration_minutes" validate:"omitempty,min=5,max=240"`
+	Reason          string    `json:"reason" validate:"required,min=5,max=500"`
+	Notes           string    `json:"notes" validate:"max=1000"`
+}
+
+type RequestAppointmentRequest struct {
+	StaffID   uint      `json:"staff_id" validate:"required"`
+	StartTime time.Time `json:"start_time" validate:"required"`
+	Reason    string    `json:"reason" validate:"required,min=5,max=500"`
+}
+
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type WorkingHoursEntry struct {
+	Weekday     int    `json:"weekday" validate:"min=0,max=6"`
+	StartTime   string `json:"start_time" validate:"required"`
+	EndTime     string `json:"end_time" validate:"required"`
+	SlotMinutes int    `json:"slot_minutes" validate:"omitempty,min=5,max=240"`
+}
+
+type SetWorkingHoursRequest struct {
+	Hours []WorkingHoursEntry `json:"hours" validate:"dive"`
+}
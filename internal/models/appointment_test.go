@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAvailableSlots(t *testing.T) {
+	// Monday 2024-03-04
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	hours := []StaffWorkingHours{
+		{Weekday: int(time.Monday), StartTime: "09:00", EndTime: "10:00", SlotMinutes: 20},
+		{Weekday: int(time.Tuesday), StartTime: "09:00", EndTime: "17:00", SlotMinutes: 20},
+	}
+	appointments := []Appointment{
+		{StartTime: day.Add(9*time.Hour + 20*time.Minute), EndTime: day.Add(9*time.Hour + 40*time.Minute), Status: AppointmentStatusBooked},
+		{StartTime: day.Add(9 * time.Hour), EndTime: day.Add(9*time.Hour + 20*time.Minute), Status: AppointmentStatusCancelled},
+	}
+
+	slots := AvailableSlots(hours, appointments, day, time.Time{})
+	if len(slots) != 2 {
+		t.Fatalf("expected 2 free slots, got %d: %v", len(slots), slots)
+	}
+	if !slots[0].StartTime.Equal(day.Add(9*time.Hour)) || !slots[1].StartTime.Equal(day.Add(9*time.Hour+40*time.Minute)) {
+		t.Errorf("unexpected slots %v", slots)
+	}
+
+	// Slots already in the past are not offered
+	slots = AvailableSlots(hours, appointments, day, day.Add(9*time.Hour+30*time.Minute))
+	if len(slots) != 1 {
+		t.Errorf("expected 1 slot after 09:30, got %d", len(slots))
+	}
+}
+
+func TestWithinWorkingHours(t *testing.T) {
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC) // Monday
+	hours := []StaffWorkingHours{{Weekday: int(time.Monday), StartTime: "09:00", EndTime: "12:00"}}
+
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		want       bool
+	}{
+		{"inside", 10 * time.Hour, 10*time.Hour + 30*time.Minute, true},
+		{"ends at close", 11*time.Hour + 45*time.Minute, 12 * time.Hour, true},
+		{"starts before open", 8*time.Hour + 50*time.Minute, 9*time.Hour + 10*time.Minute, false},
+		{"runs past close", 11*time.Hour + 50*time.Minute, 12*time.Hour + 10*time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithinWorkingHours(hours, day.Add(tt.start), day.Add(tt.end)); got != tt.want {
+				t.Errorf("WithinWorkingHours() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if WithinWorkingHours(hours, day.Add(24*time.Hour+10*time.Hour), day.Add(24*time.Hour+11*time.Hour)) {
+		t.Error("Tuesday should be outside Monday-only working hours")
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	if m, err := ParseClock("13:45"); err != nil || m != 13*60+45 {
+		t.Errorf("ParseClock(13:45) = %d, %v", m, err)
+	}
+	if _, err := ParseClock("25:00"); err == nil {
+		t.Error("expected error for invalid hour")
+	}
+}
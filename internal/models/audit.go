@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditActionView   = "view"
+	AuditActionList   = "list"
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionMerge  = "merge"
+)
+
+// Audited entity types
+const (
+	AuditEntityPatient      = "patient"
+	AuditEntityVisit        = "visit"
+	AuditEntityDiagnosis    = "diagnosis"
+	AuditEntityPrescription = "prescription"
+	AuditEntityVitalSigns   = "vital_signs"
+	AuditEntityAllergy      = "allergy"
+	AuditEntityProblem      = "problem"
+	AuditEntityImmunization = "immunization"
+	AuditEntityPregnancy    = "pregnancy"
+	AuditEntityANCContact   = "antenatal_contact"
+	AuditEntityPostnatal    = "postnatal_visit"
+	AuditEntityGrowth       = "growth_measurement"
+	AuditEntityLabOrder     = "lab_order"
+)
+
+// AuditLog is an append-only record of a read or write of patient health data
+type AuditLog struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	UserID     *uint        `json:"user_id,omitempty" gorm:"index"`
+	UserType   string       `json:"user_type" gorm:"size:20"`
+	ClinicID   *uint        `json:"clinic_id,omitempty" gorm:"index"` // Clinic of the acting user
+	PatientID  *uint        `json:"patient_id,omitempty" gorm:"index"`
+	Action     string       `json:"action" gorm:"not null;size:20"`
+	EntityType string       `json:"entity_type" gorm:"not null;size:30;index:idx_audit_entity"`
+	EntityID   uint         `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Changes    AuditChanges `json:"changes,omitempty" gorm:"type:jsonb"`
+	Method     string       `json:"method" gorm:"size:10"`
+	Path       string       `json:"path" gorm:"size:255"`
+	IPAddress  string       `json:"ip_address" gorm:"size:45"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+// AuditChange is the before/after value of a single field
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditChanges maps field names to their before/after values, stored as JSON
+type AuditChanges map[string]AuditChange
+
+func (a AuditChanges) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (a *AuditChanges) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for AuditChanges: %T", value)
+	}
+	return json.Unmarshal(data, a)
+}
+
+// ComputeChanges returns the fields that differ between two versions of a record.
+// Pass nil as before for a create and nil as after for a delete. Nested
+// relationships and timestamps managed by the database are ignored.
+func ComputeChanges(before, after interface{}) AuditChanges {
+	beforeFields := flattenFields(before)
+	afterFields := flattenFields(after)
+
+	changes := AuditChanges{}
+	for key, newValue := range afterFields {
+		oldValue, existed := beforeFields[key]
+		if !existed || !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = AuditChange{From: oldValue, To: newValue}
+		}
+	}
+	for key, oldValue := range beforeFields {
+		if _, exists := afterFields[key]; !exists {
+			changes[key] = AuditChange{From: oldValue, To: nil}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// flattenFields converts a record to its top-level scalar JSON fields
+func flattenFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return fields
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fields
+	}
+
+	for key, value := range raw {
+		if key == "created_at" || key == "updated_at" {
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// AccessLogEntry is a patient-facing view of who accessed their record
+type AccessLogEntry struct {
+	AccessedAt time.Time `json:"accessed_at"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uint      `json:"entity_id"`
+	UserType   string    `json:"user_type"`
+	AccessedBy string    `json:"accessed_by"`
+	ClinicName string    `json:"clinic_name"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestComputeChanges(t *testing.T) {
+	before := Patient{ID: 1, FullName: "Sita Sharma", Phone: "9800000000", Address: "Ward 3, Dhulikhel", ClinicID: 2}
+	after := before
+	after.Phone = "9811111111"
+
+	changes := ComputeChanges(&before, &after)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d: %v", len(changes), changes)
+	}
+	change, ok := changes["phone"]
+	if !ok {
+		t.Fatal("expected phone to be recorded as changed")
+	}
+	if change.From != "9800000000" || change.To != "9811111111" {
+		t.Errorf("phone change = %+v, want 9800000000 -> 9811111111", change)
+	}
+}
+
+func TestComputeChangesCreateAndDelete(t *testing.T) {
+	diagnosis := Diagnosis{ID: 5, VisitID: 3, DiagnosisCode: "I10", Description: "Essential hypertension"}
+
+	created := ComputeChanges(nil, &diagnosis)
+	if created["diagnosis_code"].To != "I10" || created["diagnosis_code"].From != nil {
+		t.Errorf("create should record diagnosis_code nil -> I10, got %+v", created["diagnosis_code"])
+	}
+
+	deleted := ComputeChanges(&diagnosis, nil)
+	if deleted["diagnosis_code"].From != "I10" || deleted["diagnosis_code"].To != nil {
+		t.Errorf("delete should record diagnosis_code I10 -> nil, got %+v", deleted["diagnosis_code"])
+	}
+}
+
+func TestComputeChangesIgnoresRelationships(t *testing.T) {
+	before := Visit{ID: 1, Reason: "Fever and headache"}
+	after := before
+	after.Diagnoses = []Diagnosis{{ID: 9}}
+	after.Patient = &Patient{ID: 4}
+
+	if changes := ComputeChanges(&before, &after); changes != nil {
+		t.Errorf("expected no changes for relationship-only updates, got %v", changes)
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// MaxClaimAttempts is how many wrong dates of birth a claim code survives
+const MaxClaimAttempts = 5
+
+// claimCodeAlphabet leaves out characters easily confused when read off
+// paper or an SMS (0/O, 1/I/L)
+const claimCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const claimCodeLength = 8
+
+// PatientClaimCode is a one-time code that lets a patient registered by staff
+// create a portal login for their existing record
+type PatientClaimCode struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	PatientID      uint       `json:"patient_id" gorm:"not null;index"`
+	CodeHash       string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt         *time.Time `json:"used_at,omitempty"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	IssuedByUserID *uint      `json:"issued_by_user_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// GenerateClaimCode returns a random code formatted as XXXX-XXXX
+func GenerateClaimCode() (string, error) {
+	code := make([]byte, claimCodeLength)
+	max := big.NewInt(int64(len(claimCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = claimCodeAlphabet[n.Int64()]
+	}
+	return NormalizeClaimCode(string(code)), nil
+}
+
+// NormalizeClaimCode puts a code typed by the patient into its issued form,
+// ignoring case, spaces and dashes
+func NormalizeClaimCode(code string) string {
+	compact := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+	if len(compact) != claimCodeLength {
+		return compact
+	}
+	return compact[:4] + "-" + compact[4:]
+}
+
+// Claim DTOs
+type IssueClaimCodeRequest struct {
+	Channel string `json:"channel" validate:"omitempty,oneof=print sms"` // sms also texts the code to the patient's phone
+}
+
+type ClaimAccountRequest struct {
+	Code        string `json:"code" validate:"required"`
+	DateOfBirth string `json:"date_of_birth" validate:"required"` // YYYY-MM-DD, must match the record
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password" validate:"required,min=8"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateClaimCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		code, err := GenerateClaimCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 9 || code[4] != '-' {
+			t.Fatalf("code %q is not formatted XXXX-XXXX", code)
+		}
+		for _, r := range strings.ReplaceAll(code, "-", "") {
+			if !strings.ContainsRune(claimCodeAlphabet, r) {
+				t.Fatalf("code %q contains %q outside the alphabet", code, r)
+			}
+		}
+		if seen[code] {
+			t.Fatalf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeClaimCode(t *testing.T) {
+	tests := map[string]string{
+		"abcd-efgh":   "ABCD-EFGH",
+		" ABCD EFGH ": "ABCD-EFGH",
+		"abcdefgh":    "ABCD-EFGH",
+		"ABC":         "ABC",
+	}
+	for in, want := range tests {
+		if got := NormalizeClaimCode(in); got != want {
+			t.Errorf("NormalizeClaimCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Delegation scopes
+const (
+	DelegationScopeViewVisits        = "view_visits" // visits and diagnoses
+	DelegationScopeViewPrescriptions = "view_prescriptions"
+	DelegationScopeBookAppointments  = "book_appointments" // view, book and cancel
+)
+
+// Relationships of a delegate to the patient
+var DelegationRelationships = []string{"parent", "guardian", "caregiver", "spouse", "child", "other"}
+
+// How consent to a delegation was given
+var ConsentMethods = []string{"in_person", "written", "verbal"}
+
+// DelegationScopes is a set of delegation scopes
+type DelegationScopes = StringSet
+
+// PatientDelegation lets another patient login, typically a parent or
+// caregiver, use the patient portal on behalf of a patient. Consent is
+// recorded when the delegation is granted and it can be revoked at any time.
+type PatientDelegation struct {
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	PatientID         uint             `json:"patient_id" gorm:"not null;index"`       // Record being shared
+	DelegateUserID    uint             `json:"delegate_user_id" gorm:"not null;index"` // Patient login given access
+	Relationship      string           `json:"relationship" gorm:"not null;size:20"`
+	Scopes            DelegationScopes `json:"scopes" gorm:"not null;type:varchar(100)"`
+	ConsentGivenBy    string           `json:"consent_given_by" gorm:"not null;size:255"` // The patient, or their legal guardian
+	ConsentMethod     string           `json:"consent_method" gorm:"not null;size:20"`
+	ConsentRecordedAt time.Time        `json:"consent_recorded_at" gorm:"not null"`
+	ExpiresAt         *time.Time       `json:"expires_at,omitempty"`
+	RevokedAt         *time.Time       `json:"revoked_at,omitempty"`
+	GrantedByUserID   *uint            `json:"granted_by_user_id,omitempty"`
+	RevokedByUserID   *uint            `json:"revoked_by_user_id,omitempty"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+
+	// Relationships
+	Patient      *Patient `json:"patient,omitempty" gorm:"foreignKey:PatientID;references:ID"`
+	DelegateUser *User    `json:"delegate_user,omitempty" gorm:"foreignKey:DelegateUserID;references:ID"`
+}
+
+// IsActive reports whether the delegation is neither revoked nor expired
+func (d PatientDelegation) IsActive(now time.Time) bool {
+	return d.RevokedAt == nil && (d.ExpiresAt == nil || now.Before(*d.ExpiresAt))
+}
+
+// Allows reports whether the delegation currently grants scope
+func (d PatientDelegation) Allows(scope string, now time.Time) bool {
+	return d.IsActive(now) && d.Scopes.Has(scope)
+}
+
+// DelegationsAllow reports whether any of the delegations currently grants
+// scope. An empty scope accepts any active delegation.
+func DelegationsAllow(delegations []PatientDelegation, scope string, now time.Time) bool {
+	for _, d := range delegations {
+		if (scope == "" && d.IsActive(now)) || d.Allows(scope, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeDelegationScopes lowercases and de-duplicates requested scopes,
+// rejecting unknown ones
+func NormalizeDelegationScopes(scopes []string) (DelegationScopes, error) {
+	normalized := DelegationScopes{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch scope {
+		case DelegationScopeViewVisits, DelegationScopeViewPrescriptions, DelegationScopeBookAppointments:
+		default:
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !normalized.Has(scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return normalized, nil
+}
+
+// Delegation DTOs
+type CreateDelegationRequest struct {
+	DelegatePatientID uint     `json:"delegate_patient_id" validate:"required"` // The delegate's own patient record, which must have a portal login
+	Relationship      string   `json:"relationship" validate:"required"`
+	Scopes            []string `json:"scopes" validate:"required,min=1"`
+	ConsentGivenBy    string   `json:"consent_given_by" validate:"required,max=255"`
+	ConsentMethod     string   `json:"consent_method" validate:"required"`
+	ExpiresAt         string   `json:"expires_at,omitempty"` // YYYY-MM-DD, no expiry when empty
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeDelegationScopes(t *testing.T) {
+	scopes, err := NormalizeDelegationScopes([]string{" View_Visits", "book_appointments", "view_visits"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 2 || !scopes.Has(DelegationScopeViewVisits) || !scopes.Has(DelegationScopeBookAppointments) {
+		t.Errorf("scopes = %v, want view_visits and book_appointments", scopes)
+	}
+
+	if _, err := NormalizeDelegationScopes([]string{"view_visits", "edit_records"}); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+	if _, err := NormalizeDelegationScopes(nil); err == nil {
+		t.Error("expected an error for no scopes")
+	}
+}
+
+func TestDelegationScopesRoundTrip(t *testing.T) {
+	value, err := DelegationScopes{DelegationScopeViewVisits, DelegationScopeViewPrescriptions}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned DelegationScopes
+	if err := scanned.Scan([]byte(value.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 2 || scanned[0] != DelegationScopeViewVisits || scanned[1] != DelegationScopeViewPrescriptions {
+		t.Errorf("scanned = %v", scanned)
+	}
+}
+
+func TestPatientDelegationAllows(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.AddDate(0, 0, 1)
+	yesterday := now.AddDate(0, 0, -1)
+
+	d := PatientDelegation{Scopes: DelegationScopes{DelegationScopeViewVisits}}
+	if !d.Allows(DelegationScopeViewVisits, now) {
+		t.Error("delegation without expiry should allow its scope")
+	}
+	if d.Allows(DelegationScopeBookAppointments, now) {
+		t.Error("delegation should not allow a scope it was not granted")
+	}
+
+	d.ExpiresAt = &tomorrow
+	if !d.Allows(DelegationScopeViewVisits, now) {
+		t.Error("delegation should allow its scope before expiry")
+	}
+
+	d.ExpiresAt = &yesterday
+	if d.Allows(DelegationScopeViewVisits, now) {
+		t.Error("expired delegation should not allow access")
+	}
+
+	d.ExpiresAt = nil
+	d.RevokedAt = &yesterday
+	if d.Allows(DelegationScopeViewVisits, now) {
+		t.Error("revoked delegation should not allow access")
+	}
+}
+
+func TestDelegationsAllow(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	visitsOnly := []PatientDelegation{{Scopes: DelegationScopes{DelegationScopeViewVisits}}}
+
+	if !DelegationsAllow(visitsOnly, DelegationScopeViewVisits, now) {
+		t.Error("view_visits delegation should allow visits")
+	}
+	if DelegationsAllow(visitsOnly, DelegationScopeViewPrescriptions, now) {
+		t.Error("view_visits delegation should not allow prescriptions")
+	}
+	if !DelegationsAllow(visitsOnly, "", now) {
+		t.Error("an empty scope should accept any active delegation")
+	}
+
+	both := append(visitsOnly, PatientDelegation{Scopes: DelegationScopes{DelegationScopeViewPrescriptions}})
+	if !DelegationsAllow(both, DelegationScopeViewPrescriptions, now) {
+		t.Error("a second delegation granting prescriptions should allow them")
+	}
+	if DelegationsAllow(nil, "", now) {
+		t.Error("no delegations should allow nothing")
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Prescription dispensing statuses
+const (
+	DispensingStatusPending = "not_dispensed"
+	DispensingStatusPartial = "partially_dispensed"
+	DispensingStatusFull    = "fully_dispensed"
+	DispensingStatusNoStock = "not_in_stock"
+)
+
+// Dispensation outcomes
+const (
+	DispensationOutcomeIssued  = "dispensed"
+	DispensationOutcomeNoStock = "not_in_stock"
+)
+
+// Dispensation records medicine handed to a patient against a prescription,
+// one row per batch issued. A not-in-stock outcome records a failed attempt
+// and carries no batch or quantity.
+type Dispensation struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	PrescriptionID     uint      `json:"prescription_id" gorm:"not null;index"`
+	ClinicID           uint      `json:"clinic_id" gorm:"not null;index"`
+	Outcome            string    `json:"outcome" gorm:"not null;size:20"`
+	StockItemID        *uint     `json:"stock_item_id,omitempty"`
+	StockBatchID       *uint     `json:"stock_batch_id,omitempty"`
+	LotNumber          string    `json:"lot_number,omitempty" gorm:"size:50"`
+	QuantityDispensed  int       `json:"quantity_dispensed" gorm:"not null;default:0"`
+	DispensedByStaffID *uint     `json:"dispensed_by_staff_id,omitempty"`
+	Notes              string    `json:"notes,omitempty" gorm:"size:500"`
+	CreatedAt          time.Time `json:"created_at"`
+
+	// Relationships
+	DispensedBy *Staff `json:"dispensed_by,omitempty" gorm:"foreignKey:DispensedByStaffID;references:ID"`
+}
+
+// BatchAllocation is the quantity to take from one batch
+type BatchAllocation struct {
+	Batch    StockBatch
+	Quantity int
+}
+
+// AllocateFEFO picks stock first-expiry-first-out from unexpired batches. It
+// returns the allocations and any quantity that could not be covered.
+func AllocateFEFO(batches []StockBatch, quantity int, on time.Time) ([]BatchAllocation, int) {
+	usable := make([]StockBatch, 0, len(batches))
+	for _, b := range batches {
+		if b.QuantityOnHand > 0 && !b.IsExpired(on) {
+			usable = append(usable, b)
+		}
+	}
+	sort.SliceStable(usable, func(i, j int) bool {
+		return usable[i].ExpiryDate.Before(usable[j].ExpiryDate)
+	})
+
+	var allocations []BatchAllocation
+	remaining := quantity
+	for _, b := range usable {
+		if remaining == 0 {
+			break
+		}
+		take := b.QuantityOnHand
+		if take > remaining {
+			take = remaining
+		}
+		allocations = append(allocations, BatchAllocation{Batch: b, Quantity: take})
+		remaining -= take
+	}
+	return allocations, remaining
+}
+
+// NextDispensingStatus derives a prescription's status after a dispensing
+// event. prescribed is the prescribed quantity (nil when not specified, in
+// which case any dispensed amount completes the prescription).
+func NextDispensingStatus(prescribed *int, dispensed int, notInStock bool) string {
+	switch {
+	case dispensed == 0 && notInStock:
+		return DispensingStatusNoStock
+	case dispensed == 0:
+		return DispensingStatusPending
+	case prescribed == nil || dispensed >= *prescribed:
+		return DispensingStatusFull
+	default:
+		return DispensingStatusPartial
+	}
+}
+
+// DispenseRequest dispenses stock against a prescription
+type DispenseRequest struct {
+	Quantity     int    `json:"quantity" validate:"omitempty,min=1"` // defaults to the outstanding prescribed quantity
+	StockBatchID *uint  `json:"stock_batch_id,omitempty"`            // issue from this batch instead of first-expiry-first-out
+	NotInStock   bool   `json:"not_in_stock"`                        // record that the medicine could not be supplied
+	Notes        string `json:"notes" validate:"max=500"`
+}
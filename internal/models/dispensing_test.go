@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllocateFEFO(t *testing.T) {
+	today := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	batches := []StockBatch{
+		{ID: 1, LotNumber: "LATE", ExpiryDate: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), QuantityOnHand: 100},
+		{ID: 2, LotNumber: "EXPIRED", ExpiryDate: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), QuantityOnHand: 50},
+		{ID: 3, LotNumber: "SOON", ExpiryDate: time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC), QuantityOnHand: 12},
+		{ID: 4, LotNumber: "EMPTY", ExpiryDate: time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC), QuantityOnHand: 0},
+	}
+
+	allocations, shortfall := AllocateFEFO(batches, 30, today)
+	if shortfall != 0 {
+		t.Fatalf("shortfall = %d, want 0", shortfall)
+	}
+	if len(allocations) != 2 {
+		t.Fatalf("got %d allocations, want 2", len(allocations))
+	}
+	if allocations[0].Batch.ID != 3 || allocations[0].Quantity != 12 {
+		t.Errorf("first allocation = batch %d x %d, want batch 3 x 12", allocations[0].Batch.ID, allocations[0].Quantity)
+	}
+	if allocations[1].Batch.ID != 1 || allocations[1].Quantity != 18 {
+		t.Errorf("second allocation = batch %d x %d, want batch 1 x 18", allocations[1].Batch.ID, allocations[1].Quantity)
+	}
+
+	// Expired stock is never issued
+	_, shortfall = AllocateFEFO(batches, 150, today)
+	if shortfall != 38 {
+		t.Errorf("shortfall = %d, want 38", shortfall)
+	}
+}
+
+func TestNextDispensingStatus(t *testing.T) {
+	ten := 10
+
+	tests := []struct {
+		name       string
+		prescribed *int
+		dispensed  int
+		notInStock bool
+		want       string
+	}{
+		{"nothing yet", &ten, 0, false, DispensingStatusPending},
+		{"out of stock", &ten, 0, true, DispensingStatusNoStock},
+		{"partial", &ten, 4, false, DispensingStatusPartial},
+		{"partial then out of stock", &ten, 4, true, DispensingStatusPartial},
+		{"full", &ten, 10, false, DispensingStatusFull},
+		{"no prescribed quantity", nil, 1, false, DispensingStatusFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextDispensingStatus(tt.prescribed, tt.dispensed, tt.notInStock); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
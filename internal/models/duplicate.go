@@ -0,0 +1,170 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Duplicate match scoring. A candidate needs a name match plus a date of birth
+// or phone match to reach the threshold, so a shared phone and birthday alone
+// (twins, siblings on a parent's phone) is not flagged.
+const (
+	duplicateScoreExactName = 50
+	duplicateScoreFuzzyName = 40
+	duplicateScoreDOB       = 30
+	duplicateScorePhone     = 30
+	duplicateScoreGender    = -40
+
+	// DuplicateScoreThreshold is the lowest score reported as a candidate
+	DuplicateScoreThreshold = 70
+
+	// fuzzyNameSimilarity is the lowest name similarity counted as a match
+	fuzzyNameSimilarity = 0.8
+)
+
+// DuplicateCandidate is a patient record that may be the same person as another
+type DuplicateCandidate struct {
+	Patient Patient  `json:"patient"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// DuplicatePair is two records in a clinic that may be the same person
+type DuplicatePair struct {
+	Patient   Patient  `json:"patient"`
+	Duplicate Patient  `json:"duplicate"`
+	Score     int      `json:"score"`
+	Reasons   []string `json:"reasons"`
+}
+
+// NormalizeName lowercases a name and reduces it to letters and single spaces
+func NormalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// NormalizePhone keeps the last ten digits of a phone number so that country
+// codes and formatting do not hide a match
+func NormalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// NameSimilarity returns how alike two names are, from 0 to 1. Word order is
+// ignored, since family and given names are often entered either way round.
+func NameSimilarity(a, b string) float64 {
+	a, b = NormalizeName(a), NormalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	similarity := levenshteinRatio(a, b)
+	if sorted := levenshteinRatio(sortedWords(a), sortedWords(b)); sorted > similarity {
+		similarity = sorted
+	}
+	return similarity
+}
+
+func sortedWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// levenshteinRatio is 1 minus the edit distance over the longer length
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// ScoreDuplicate scores how likely two patient records are the same person and
+// explains the score
+func ScoreDuplicate(a, b Patient) (int, []string) {
+	score := 0
+	var reasons []string
+
+	if NormalizeName(a.FullName) == NormalizeName(b.FullName) {
+		score += duplicateScoreExactName
+		reasons = append(reasons, "same name")
+	} else if NameSimilarity(a.FullName, b.FullName) >= fuzzyNameSimilarity {
+		score += duplicateScoreFuzzyName
+		reasons = append(reasons, "similar name")
+	}
+
+	if a.DateOfBirth.Format("2006-01-02") == b.DateOfBirth.Format("2006-01-02") {
+		score += duplicateScoreDOB
+		reasons = append(reasons, "same date of birth")
+	}
+
+	if phone := NormalizePhone(a.Phone); phone != "" && phone == NormalizePhone(b.Phone) {
+		score += duplicateScorePhone
+		reasons = append(reasons, "same phone")
+	}
+
+	if !strings.EqualFold(a.Gender, b.Gender) {
+		score += duplicateScoreGender
+		reasons = append(reasons, "different gender")
+	}
+
+	return score, reasons
+}
+
+// FindDuplicateCandidates scores each record in pool against patient and
+// returns those at or above the threshold, most likely first
+func FindDuplicateCandidates(patient Patient, pool []Patient) []DuplicateCandidate {
+	candidates := []DuplicateCandidate{}
+	for _, other := range pool {
+		if other.ID == patient.ID {
+			continue
+		}
+		if score, reasons := ScoreDuplicate(patient, other); score >= DuplicateScoreThreshold {
+			candidates = append(candidates, DuplicateCandidate{Patient: other, Score: score, Reasons: reasons})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates
+}
+
+// Duplicate DTOs
+type MergePatientRequest struct {
+	DuplicatePatientID uint   `json:"duplicate_patient_id" validate:"required"` // record merged into the patient and removed
+	Reason             string `json:"reason" validate:"max=500"`
+}
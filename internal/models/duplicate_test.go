@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := map[string]string{
+		"+977-984-1234567": "9841234567",
+		"984 123 4567":     "9841234567",
+		"01-4412345":       "014412345",
+		"":                 "",
+	}
+	for in, want := range tests {
+		if got := NormalizePhone(in); got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if got := NameSimilarity("Ram Bahadur Thapa", "ram  bahadur thapa."); got != 1 {
+		t.Errorf("same name with different case and punctuation = %v, want 1", got)
+	}
+	if got := NameSimilarity("Thapa Ram", "Ram Thapa"); got != 1 {
+		t.Errorf("swapped name order = %v, want 1", got)
+	}
+	if got := NameSimilarity("Sita Kumari Shrestha", "Sita Kumari Shresta"); got < fuzzyNameSimilarity {
+		t.Errorf("misspelt name = %v, want at least %v", got, fuzzyNameSimilarity)
+	}
+	if got := NameSimilarity("Sita Shrestha", "Gopal Adhikari"); got >= fuzzyNameSimilarity {
+		t.Errorf("different names = %v, want below %v", got, fuzzyNameSimilarity)
+	}
+}
+
+func TestFindDuplicateCandidates(t *testing.T) {
+	dob := time.Date(1985, 4, 12, 0, 0, 0, 0, time.UTC)
+	patient := Patient{ID: 1, FullName: "Sita Kumari Shrestha", Gender: "Female", DateOfBirth: dob, Phone: "9841234567"}
+
+	pool := []Patient{
+		patient,
+		{ID: 2, FullName: "Sita Kumari Shresta", Gender: "Female", DateOfBirth: dob, Phone: "+977 984 1234567"},
+		{ID: 3, FullName: "Sita Shrestha Kumari", Gender: "Female", DateOfBirth: dob.AddDate(0, 0, 3), Phone: "9800000000"},
+		{ID: 4, FullName: "Gita Kumari Shrestha", Gender: "Female", DateOfBirth: dob, Phone: "9841234567"},
+		{ID: 5, FullName: "Hari Shrestha", Gender: "Male", DateOfBirth: dob, Phone: "9841234567"},
+		{ID: 6, FullName: "Sita Kumari Shrestha", Gender: "Female", DateOfBirth: dob.AddDate(-20, 0, 0), Phone: "9812345678"},
+	}
+
+	candidates := FindDuplicateCandidates(patient, pool)
+
+	got := make([]uint, len(candidates))
+	for i, c := range candidates {
+		got[i] = c.Patient.ID
+	}
+	// 2 and 4 differ by a letter and share DOB and phone.
+	// 5 shares DOB and phone only, 3 and 6 only the name.
+	want := []uint{2, 4}
+	if len(got) != len(want) {
+		t.Fatalf("candidates = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("candidates = %v, want %v", got, want)
+		}
+	}
+	if candidates[0].Score != 100 {
+		t.Errorf("score = %d, want 100", candidates[0].Score)
+	}
+	if len(candidates[0].Reasons) != 3 {
+		t.Errorf("reasons = %v, want name, date of birth and phone", candidates[0].Reasons)
+	}
+}
+
+func TestScoreDuplicateGenderMismatch(t *testing.T) {
+	dob := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := Patient{FullName: "Kamal Rai", Gender: "Male", DateOfBirth: dob, Phone: "9841000000"}
+	b := Patient{FullName: "Kamala Rai", Gender: "Female", DateOfBirth: dob, Phone: "9841000000"}
+
+	score, _ := ScoreDuplicate(a, b)
+	if score >= DuplicateScoreThreshold {
+		t.Errorf("score = %d, want below threshold %d for different genders", score, DuplicateScoreThreshold)
+	}
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FormularyItem is a medication available for prescribing. Items with no
+// clinic are part of the system-wide formulary; clinics can add their own.
+type FormularyItem struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	ClinicID         *uint          `json:"clinic_id,omitempty" gorm:"index"`
+	GenericName      string         `json:"generic_name" gorm:"not null;size:255;index" validate:"required,min=2,max=255"`
+	BrandName        string         `json:"brand_name,omitempty" gorm:"size:255" validate:"max=255"`
+	Strength         string         `json:"strength" gorm:"not null;size:50" validate:"required,max=50"` // e.g. 500 mg, 125 mg/5 ml
+	Form             string         `json:"form" gorm:"not null;size:50" validate:"required,max=50"`     // e.g. tablet, syrup
+	Route            string         `json:"route" gorm:"not null;size:30" validate:"required,max=30"`
+	DefaultFrequency string         `json:"default_frequency,omitempty" gorm:"size:10"`
+	DefaultDoseUnit  string         `json:"default_dose_unit,omitempty" gorm:"size:20"` // e.g. tablet, ml
+	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Clinic *Clinic `json:"clinic,omitempty" gorm:"foreignKey:ClinicID;references:ID"`
+}
+
+// DisplayName renders the item as it appears on a prescription, e.g. "Paracetamol 500 mg tablet"
+func (f FormularyItem) DisplayName() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", f.GenericName, f.Strength, f.Form))
+}
+
+// Frequency is a standard dosing frequency
+type Frequency struct {
+	Code        string `json:"code"`
+	Label       string `json:"label"`
+	TimesPerDay int    `json:"times_per_day"` // 0 when not scheduled (PRN)
+}
+
+// Frequencies lists the accepted frequency codes
+var Frequencies = []Frequency{
+	{Code: "OD", Label: "once daily", TimesPerDay: 1},
+	{Code: "BD", Label: "twice daily", TimesPerDay: 2},
+	{Code: "TDS", Label: "three times daily", TimesPerDay: 3},
+	{Code: "QID", Label: "four times daily", TimesPerDay: 4},
+	{Code: "HS", Label: "at bedtime", TimesPerDay: 1},
+	{Code: "STAT", Label: "immediately, once", TimesPerDay: 0},
+	{Code: "PRN", Label: "when required", TimesPerDay: 0},
+}
+
+// LookupFrequency finds a frequency by code (case-insensitive)
+func LookupFrequency(code string) (Frequency, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, f := range Frequencies {
+		if f.Code == code {
+			return f, true
+		}
+	}
+	return Frequency{}, false
+}
+
+// RouteLabels maps administration routes to the wording used in instructions
+var RouteLabels = map[string]string{
+	"oral":          "by mouth",
+	"sublingual":    "under the tongue",
+	"topical":       "to the skin",
+	"inhalation":    "by inhalation",
+	"intravenous":   "intravenously",
+	"intramuscular": "by intramuscular injection",
+	"subcutaneous":  "by subcutaneous injection",
+	"rectal":        "rectally",
+	"ophthalmic":    "into the eye",
+	"otic":          "into the ear",
+	"nasal":         "into the nose",
+	"vaginal":       "vaginally",
+}
+
+// IsValidRoute reports whether route is a known administration route
+func IsValidRoute(route string) bool {
+	_, ok := RouteLabels[strings.ToLower(route)]
+	return ok
+}
+
+// countableUnits are dose units that can be dispensed as whole items
+var countableUnits = map[string]bool{
+	"tablet": true, "tablets": true, "capsule": true, "capsules": true,
+	"sachet": true, "sachets": true, "suppository": true, "suppositories": true,
+}
+
+// FormatDose renders an amount and unit, e.g. "1 tablet", "2 tablets", "5 ml"
+func FormatDose(amount float64, unit string) string {
+	unit = strings.TrimSpace(unit)
+	amountStr := strconv.FormatFloat(amount, 'f', -1, 64)
+	if countableUnits[strings.ToLower(unit)] && amount > 1 && !strings.HasSuffix(unit, "s") {
+		unit += "s"
+	}
+	return strings.TrimSpace(amountStr + " " + unit)
+}
+
+// RenderInstruction builds the human-readable directions for a structured prescription,
+// e.g. "Take 1 tablet by mouth three times daily for 5 days"
+func RenderInstruction(doseAmount float64, doseUnit string, frequency Frequency, route string, durationDays int) string {
+	var b strings.Builder
+
+	verb := "Take"
+	switch strings.ToLower(route) {
+	case "topical":
+		verb = "Apply"
+	case "intravenous", "intramuscular", "subcutaneous":
+		verb = "Give"
+	case "ophthalmic", "otic", "nasal":
+		verb = "Instil"
+	case "inhalation":
+		verb = "Inhale"
+	}
+
+	b.WriteString(verb)
+	b.WriteString(" ")
+	b.WriteString(FormatDose(doseAmount, doseUnit))
+	if label, ok := RouteLabels[strings.ToLower(route)]; ok && verb != "Inhale" {
+		b.WriteString(" ")
+		b.WriteString(label)
+	}
+	b.WriteString(" ")
+	b.WriteString(frequency.Label)
+	if durationDays > 0 && frequency.Code != "STAT" {
+		if durationDays == 1 {
+			b.WriteString(" for 1 day")
+		} else {
+			fmt.Fprintf(&b, " for %d days", durationDays)
+		}
+	}
+	return b.String()
+}
+
+// SuggestedQuantity returns the number of countable units needed for the full
+// course, or nil when it cannot be derived (liquids, PRN doses).
+func SuggestedQuantity(doseAmount float64, doseUnit string, frequency Frequency, durationDays int) *int {
+	if !countableUnits[strings.ToLower(strings.TrimSpace(doseUnit))] || doseAmount <= 0 {
+		return nil
+	}
+
+	var total float64
+	switch {
+	case frequency.Code == "STAT":
+		total = doseAmount
+	case frequency.TimesPerDay > 0 && durationDays > 0:
+		total = doseAmount * float64(frequency.TimesPerDay) * float64(durationDays)
+	default:
+		return nil
+	}
+
+	quantity := int(math.Ceil(total))
+	return &quantity
+}
+
+// Formulary DTOs
+type CreateFormularyItemRequest struct {
+	ClinicID         *uint  `json:"clinic_id,omitempty"` // admin only; portal items always belong to the caller's clinic
+	GenericName      string `json:"generic_name" validate:"required,min=2,max=255"`
+	BrandName        string `json:"brand_name" validate:"max=255"`
+	Strength         string `json:"strength" validate:"required,max=50"`
+	Form             string `json:"form" validate:"required,max=50"`
+	Route            string `json:"route" validate:"required,max=30"`
+	DefaultFrequency string `json:"default_frequency" validate:"max=10"`
+	DefaultDoseUnit  string `json:"default_dose_unit" validate:"max=20"`
+}
+
+type UpdateFormularyItemRequest struct {
+	GenericName      *string `json:"generic_name,omitempty" validate:"omitempty,min=2,max=255"`
+	BrandName        *string `json:"brand_name,omitempty" validate:"omitempty,max=255"`
+	Strength         *string `json:"strength,omitempty" validate:"omitempty,max=50"`
+	Form             *string `json:"form,omitempty" validate:"omitempty,max=50"`
+	Route            *string `json:"route,omitempty" validate:"omitempty,max=30"`
+	DefaultFrequency *string `json:"default_frequency,omitempty" validate:"omitempty,max=10"`
+	DefaultDoseUnit  *string `json:"default_dose_unit,omitempty" validate:"omitempty,max=20"`
+	IsActive         *bool   `json:"is_active,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestRenderInstruction(t *testing.T) {
+	tds, _ := LookupFrequency("tds")
+	prn, _ := LookupFrequency("PRN")
+	stat, _ := LookupFrequency("STAT")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"oral tablets", RenderInstruction(1, "tablet", tds, "oral", 5), "Take 1 tablet by mouth three times daily for 5 days"},
+		{"plural units", RenderInstruction(2, "tablet", tds, "oral", 1), "Take 2 tablets by mouth three times daily for 1 day"},
+		{"liquid", RenderInstruction(5, "ml", prn, "oral", 3), "Take 5 ml by mouth when required for 3 days"},
+		{"single injection", RenderInstruction(1, "g", stat, "intramuscular", 1), "Give 1 g by intramuscular injection immediately, once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedQuantity(t *testing.T) {
+	bd, _ := LookupFrequency("BD")
+	prn, _ := LookupFrequency("PRN")
+
+	if q := SuggestedQuantity(1.5, "tablet", bd, 5); q == nil || *q != 15 {
+		t.Errorf("SuggestedQuantity(1.5 tablet BD x5) = %v, want 15", q)
+	}
+	if q := SuggestedQuantity(5, "ml", bd, 5); q != nil {
+		t.Errorf("liquid doses should not suggest a quantity, got %d", *q)
+	}
+	if q := SuggestedQuantity(1, "tablet", prn, 5); q != nil {
+		t.Errorf("PRN doses should not suggest a quantity, got %d", *q)
+	}
+}
+
+func TestLookupFrequency(t *testing.T) {
+	if _, ok := LookupFrequency("q6h"); ok {
+		t.Error("unknown frequency code should not be found")
+	}
+	if f, ok := LookupFrequency(" od "); !ok || f.TimesPerDay != 1 {
+		t.Errorf("LookupFrequency(od) = %+v, %v", f, ok)
+	}
+}
@@ -0,0 +1,364 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Growth indicators in the WHO reference tables. Length is measured lying
+// down under 24 months and height standing from 24 months.
+const (
+	GrowthWeightForAge    = "weight_for_age"
+	GrowthLengthForAge    = "length_for_age"
+	GrowthHeightForAge    = "height_for_age"
+	GrowthWeightForLength = "weight_for_length"
+	GrowthWeightForHeight = "weight_for_height"
+)
+
+var growthIndicators = []string{
+	GrowthWeightForAge, GrowthLengthForAge, GrowthHeightForAge, GrowthWeightForLength, GrowthWeightForHeight,
+}
+
+// Nutrition status from weight-for-height, MUAC and oedema
+const (
+	NutritionStatusSAM    = "sam" // severe acute malnutrition
+	NutritionStatusMAM    = "mam" // moderate acute malnutrition
+	NutritionStatusNormal = "normal"
+)
+
+// Growth flags from the z-scores
+const (
+	GrowthFlagStunted             = "stunted"              // height-for-age below -2
+	GrowthFlagSeverelyStunted     = "severely_stunted"     // height-for-age below -3
+	GrowthFlagUnderweight         = "underweight"          // weight-for-age below -2
+	GrowthFlagSeverelyUnderweight = "severely_underweight" // weight-for-age below -3
+	GrowthFlagOverweight          = "overweight"           // weight-for-height above 2
+)
+
+// GrowthMaxAgeDays is the oldest age covered by the WHO growth standards: the
+// tables end at 60 months, which is 1826 days at daysPerMonth
+const GrowthMaxAgeDays = 1826
+
+// daysPerMonth converts an age in days to the months the tables are indexed by
+const daysPerMonth = 30.4375
+
+// lengthHeightDifferenceCm is how much longer a child measures lying down than
+// standing, used to convert between length and height
+const lengthHeightDifferenceCm = 0.7
+
+// GrowthTable holds the L, M and S parameters of one indicator by sex, as
+// rows of [age in months or length/height in cm, L, M, S] in ascending order
+type GrowthTable struct {
+	Unit  string       `json:"unit"`
+	Boys  [][4]float64 `json:"boys"`
+	Girls [][4]float64 `json:"girls"`
+}
+
+// GrowthReference is the set of WHO growth standard tables
+type GrowthReference struct {
+	Source     string                 `json:"source"`
+	Indicators map[string]GrowthTable `json:"indicators"`
+}
+
+// LoadGrowthReference reads the growth standard tables from a JSON file
+func LoadGrowthReference(path string) (*GrowthReference, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseGrowthReference(f)
+}
+
+// ParseGrowthReference parses and checks the growth standard tables
+func ParseGrowthReference(r io.Reader) (*GrowthReference, error) {
+	var ref GrowthReference
+	if err := json.NewDecoder(r).Decode(&ref); err != nil {
+		return nil, err
+	}
+
+	for _, indicator := range growthIndicators {
+		table, ok := ref.Indicators[indicator]
+		if !ok {
+			return nil, fmt.Errorf("%s table is missing", indicator)
+		}
+		for sex, rows := range map[string][][4]float64{"boys": table.Boys, "girls": table.Girls} {
+			if len(rows) < 2 {
+				return nil, fmt.Errorf("%s %s needs at least two rows", indicator, sex)
+			}
+			for i, row := range rows {
+				if row[2] <= 0 || row[3] <= 0 {
+					return nil, fmt.Errorf("%s %s row %d has a non-positive M or S", indicator, sex, i+1)
+				}
+				if i > 0 && row[0] <= rows[i-1][0] {
+					return nil, fmt.Errorf("%s %s rows are not in ascending order at row %d", indicator, sex, i+1)
+				}
+			}
+		}
+	}
+	return &ref, nil
+}
+
+// lms interpolates the L, M and S parameters at x, reporting false when x is
+// outside the table
+func (t GrowthTable) lms(gender string, x float64) (l, m, s float64, ok bool) {
+	rows := t.Girls
+	if gender == "Male" {
+		rows = t.Boys
+	}
+	if len(rows) == 0 || x < rows[0][0] || x > rows[len(rows)-1][0] {
+		return 0, 0, 0, false
+	}
+	for i := 1; i < len(rows); i++ {
+		if x <= rows[i][0] {
+			lo, hi := rows[i-1], rows[i]
+			f := (x - lo[0]) / (hi[0] - lo[0])
+			return lo[1] + f*(hi[1]-lo[1]), lo[2] + f*(hi[2]-lo[2]), lo[3] + f*(hi[3]-lo[3]), true
+		}
+	}
+	last := rows[len(rows)-1]
+	return last[1], last[2], last[3], true
+}
+
+// LMSZScore is the z-score of y given the Box-Cox power L, median M and
+// coefficient of variation S
+func LMSZScore(y, l, m, s float64) float64 {
+	if l == 0 {
+		return math.Log(y/m) / s
+	}
+	return (math.Pow(y/m, l) - 1) / (l * s)
+}
+
+// lmsValue is the measurement at z-score z
+func lmsValue(z, l, m, s float64) float64 {
+	if l == 0 {
+		return m * math.Exp(s*z)
+	}
+	return m * math.Pow(1+l*s*z, 1/l)
+}
+
+// weightZScore applies the WHO restricted application of the LMS method to
+// weight-based indicators: beyond ±3 the z-score is extrapolated using the
+// distance between the 2 and 3 SD curves, so the skewed tail is not compressed
+func weightZScore(y, l, m, s float64) float64 {
+	z := LMSZScore(y, l, m, s)
+	switch {
+	case z > 3:
+		sd3 := lmsValue(3, l, m, s)
+		return 3 + (y-sd3)/(sd3-lmsValue(2, l, m, s))
+	case z < -3:
+		sd3 := lmsValue(-3, l, m, s)
+		return -3 + (y-sd3)/(lmsValue(-2, l, m, s)-sd3)
+	}
+	return z
+}
+
+// GrowthInput is one set of measurements of a child
+type GrowthInput struct {
+	WeightKg      *float64
+	HeightCm      *float64
+	MeasuredLying *bool // recumbent length rather than standing height; defaults by age
+	MUACCm        *float64
+	Oedema        bool // bilateral pitting oedema
+}
+
+// GrowthAssessment is the z-scores and nutrition status worked out from a measurement
+type GrowthAssessment struct {
+	AgeDays         int
+	MeasuredLying   bool
+	WAZ             *float64 // weight-for-age
+	HAZ             *float64 // length/height-for-age
+	WHZ             *float64 // weight-for-length/height
+	NutritionStatus string
+	Flags           ClinicalFlags
+}
+
+// Assess works out a child's z-scores and nutrition status. Length measured
+// standing under 24 months, or height measured lying from 24 months, is
+// converted by 0.7 cm as WHO recommends. A z-score outside the WHO
+// plausibility limits is reported as an error since it almost always means a
+// mis-measured or mistyped value.
+func (ref *GrowthReference) Assess(gender string, dob, measuredOn time.Time, in GrowthInput) (GrowthAssessment, error) {
+	var a GrowthAssessment
+
+	a.AgeDays = int(dateOnly(measuredOn).Sub(dateOnly(dob)).Hours() / 24)
+	if a.AgeDays < 0 || a.AgeDays > GrowthMaxAgeDays {
+		return a, fmt.Errorf("growth standards cover children from birth to 5 years")
+	}
+	months := float64(a.AgeDays) / daysPerMonth
+	under2 := a.AgeDays < 731
+	a.MeasuredLying = under2
+	if in.MeasuredLying != nil {
+		a.MeasuredLying = *in.MeasuredLying
+	}
+
+	if in.WeightKg != nil {
+		l, m, s, ok := ref.Indicators[GrowthWeightForAge].lms(gender, months)
+		if ok {
+			z := round2(weightZScore(*in.WeightKg, l, m, s))
+			if z < -6 || z > 5 {
+				return a, fmt.Errorf("weight gives an implausible weight-for-age z-score of %.1f; check the measurement", z)
+			}
+			a.WAZ = &z
+		}
+	}
+
+	if in.HeightCm != nil {
+		height := *in.HeightCm
+		switch {
+		case under2 && !a.MeasuredLying:
+			height += lengthHeightDifferenceCm
+		case !under2 && a.MeasuredLying:
+			height -= lengthHeightDifferenceCm
+		}
+
+		ageTable, sizeTable := GrowthHeightForAge, GrowthWeightForHeight
+		if under2 {
+			ageTable, sizeTable = GrowthLengthForAge, GrowthWeightForLength
+		}
+		if l, m, s, ok := ref.Indicators[ageTable].lms(gender, months); ok {
+			z := round2(LMSZScore(height, l, m, s))
+			if z < -6 || z > 6 {
+				return a, fmt.Errorf("length or height gives an implausible z-score of %.1f; check the measurement", z)
+			}
+			a.HAZ = &z
+		}
+		if in.WeightKg != nil {
+			if l, m, s, ok := ref.Indicators[sizeTable].lms(gender, height); ok {
+				z := round2(weightZScore(*in.WeightKg, l, m, s))
+				if z < -5 || z > 5 {
+					return a, fmt.Errorf("weight and length or height give an implausible weight-for-height z-score of %.1f; check the measurements", z)
+				}
+				a.WHZ = &z
+			}
+		}
+	}
+
+	a.NutritionStatus = ClassifyAcuteMalnutrition(a.WHZ, in.MUACCm, in.Oedema, a.AgeDays)
+	a.Flags = growthFlags(a)
+	return a, nil
+}
+
+// ClassifyAcuteMalnutrition applies the WHO criteria: severe when
+// weight-for-height is below -3, MUAC below 11.5 cm or there is bilateral
+// oedema; moderate when weight-for-height is below -2 or MUAC below 12.5 cm.
+// MUAC is only used from 6 months. The status is empty when neither
+// weight-for-height nor MUAC is available and there is no oedema.
+func ClassifyAcuteMalnutrition(whz, muacCm *float64, oedema bool, ageDays int) string {
+	if ageDays < 183 {
+		muacCm = nil
+	}
+	switch {
+	case oedema || (whz != nil && *whz < -3) || (muacCm != nil && *muacCm < 11.5):
+		return NutritionStatusSAM
+	case (whz != nil && *whz < -2) || (muacCm != nil && *muacCm < 12.5):
+		return NutritionStatusMAM
+	case whz != nil || muacCm != nil:
+		return NutritionStatusNormal
+	}
+	return ""
+}
+
+func growthFlags(a GrowthAssessment) ClinicalFlags {
+	flags := ClinicalFlags{}
+	if a.HAZ != nil {
+		if *a.HAZ < -3 {
+			flags = append(flags, GrowthFlagSeverelyStunted)
+		} else if *a.HAZ < -2 {
+			flags = append(flags, GrowthFlagStunted)
+		}
+	}
+	if a.WAZ != nil {
+		if *a.WAZ < -3 {
+			flags = append(flags, GrowthFlagSeverelyUnderweight)
+		} else if *a.WAZ < -2 {
+			flags = append(flags, GrowthFlagUnderweight)
+		}
+	}
+	if a.WHZ != nil && *a.WHZ > 2 {
+		flags = append(flags, GrowthFlagOverweight)
+	}
+	return flags
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// GrowthMeasurement is a child's weight, length or height and MUAC on one
+// day, with the z-scores and nutrition status worked out when recorded
+type GrowthMeasurement struct {
+	ID                uint          `json:"id" gorm:"primaryKey"`
+	PatientID         uint          `json:"patient_id" gorm:"not null;index:idx_growth_patient_measured"`
+	ClinicID          uint          `json:"clinic_id" gorm:"not null;index"`
+	VisitID           *uint         `json:"visit_id,omitempty" gorm:"index"`
+	MeasuredOn        time.Time     `json:"measured_on" gorm:"not null;type:date;index:idx_growth_patient_measured"`
+	AgeDays           int           `json:"age_days" gorm:"not null"`
+	WeightKg          *float64      `json:"weight_kg,omitempty"`
+	HeightCm          *float64      `json:"height_cm,omitempty"`
+	MeasuredLying     bool          `json:"measured_lying"`
+	MUACCm            *float64      `json:"muac_cm,omitempty"` // mid-upper arm circumference
+	Oedema            bool          `json:"oedema"`
+	WAZ               *float64      `json:"weight_for_age_z,omitempty"`
+	HAZ               *float64      `json:"height_for_age_z,omitempty"`
+	WHZ               *float64      `json:"weight_for_height_z,omitempty"`
+	NutritionStatus   string        `json:"nutrition_status,omitempty" gorm:"size:10;index"`
+	Flags             ClinicalFlags `json:"flags" gorm:"type:varchar(100)"`
+	RecordedByStaffID *uint         `json:"recorded_by_staff_id,omitempty"`
+	Notes             string        `json:"notes,omitempty" gorm:"size:500"`
+	CreatedAt         time.Time     `json:"created_at"`
+
+	// Relationships
+	RecordedBy *Staff `json:"recorded_by,omitempty" gorm:"foreignKey:RecordedByStaffID;references:ID"`
+}
+
+// Growth DTOs
+type RecordGrowthRequest struct {
+	MeasuredOn    string   `json:"measured_on,omitempty"` // YYYY-MM-DD, defaults to today
+	VisitID       *uint    `json:"visit_id,omitempty"`
+	WeightKg      *float64 `json:"weight_kg,omitempty"`
+	HeightCm      *float64 `json:"height_cm,omitempty"`
+	MeasuredLying *bool    `json:"measured_lying,omitempty"` // Defaults to lying under 24 months and standing after
+	MUACCm        *float64 `json:"muac_cm,omitempty"`
+	Oedema        bool     `json:"oedema"`
+	Notes         string   `json:"notes,omitempty" validate:"max=500"`
+}
+
+// Validate checks at least one measurement is given and each is plausible
+func (r RecordGrowthRequest) Validate() error {
+	if r.WeightKg == nil && r.HeightCm == nil && r.MUACCm == nil && !r.Oedema {
+		return fmt.Errorf("at least one of weight_kg, height_cm, muac_cm or oedema is required")
+	}
+	if r.WeightKg != nil {
+		if err := checkFloatRange("weight_kg", *r.WeightKg, 0.5, 40); err != nil {
+			return err
+		}
+	}
+	if r.HeightCm != nil {
+		if err := checkFloatRange("height_cm", *r.HeightCm, 35, 130); err != nil {
+			return err
+		}
+	}
+	if r.MUACCm != nil {
+		if err := checkFloatRange("muac_cm", *r.MUACCm, 5, 30); err != nil {
+			return err
+		}
+	}
+	if len(r.Notes) > 500 {
+		return fmt.Errorf("notes must be at most 500 characters")
+	}
+	return nil
+}
+
+// GrowthSeries is a child's measurements over time, for growth charts
+type GrowthSeries struct {
+	PatientID    uint                `json:"patient_id"`
+	Gender       string              `json:"gender"`
+	DateOfBirth  time.Time           `json:"date_of_birth"`
+	Measurements []GrowthMeasurement `json:"measurements"`
+	Latest       *GrowthMeasurement  `json:"latest,omitempty"`
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadBundledGrowthReference(t *testing.T) *GrowthReference {
+	t.Helper()
+	f, err := os.Open("../../data/who_growth_standards.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	ref, err := ParseGrowthReference(f)
+	if err != nil {
+		t.Fatalf("bundled growth standards: %v", err)
+	}
+	return ref
+}
+
+func TestLMSZScore(t *testing.T) {
+	// WHO weight-for-age, boys at 12 months: L 0.0644, M 9.6479, S 0.10925
+	l, m, s := 0.0644, 9.6479, 0.10925
+	if z := LMSZScore(m, l, m, s); math.Abs(z) > 1e-9 {
+		t.Errorf("median z = %f, want 0", z)
+	}
+	for _, z := range []float64{-2, -1, 1, 2} {
+		if got := LMSZScore(lmsValue(z, l, m, s), l, m, s); math.Abs(got-z) > 1e-9 {
+			t.Errorf("round trip z = %f, want %f", got, z)
+		}
+	}
+	if z := LMSZScore(lmsValue(1.5, 0, m, s), 0, m, s); math.Abs(z-1.5) > 1e-9 {
+		t.Errorf("L=0 round trip z = %f, want 1.5", z)
+	}
+}
+
+func TestWeightZScoreBeyondThreeSD(t *testing.T) {
+	l, m, s := -0.3521, 7.4, 0.0814
+	sd2, sd3 := lmsValue(-2, l, m, s), lmsValue(-3, l, m, s)
+	// One 2-3 SD gap below the -3 SD curve is -4
+	if z := weightZScore(sd3-(sd2-sd3), l, m, s); math.Abs(z+4) > 1e-9 {
+		t.Errorf("z = %f, want -4", z)
+	}
+	if z := weightZScore(m, l, m, s); math.Abs(z) > 1e-9 {
+		t.Errorf("median z = %f, want 0", z)
+	}
+}
+
+func TestParseGrowthReferenceRejectsBadTables(t *testing.T) {
+	if _, err := ParseGrowthReference(strings.NewReader(`{"indicators": {}}`)); err == nil {
+		t.Error("expected error for missing tables")
+	}
+}
+
+func TestAssessGrowth(t *testing.T) {
+	ref := loadBundledGrowthReference(t)
+	dob := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Boy at the weight-for-age and length-for-age medians at 12 months (365 days is 11.99 months)
+	weight, length := 9.6479, 75.7488
+	a, err := ref.Assess("Male", dob, dob.AddDate(1, 0, 0), GrowthInput{WeightKg: &weight, HeightCm: &length})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.WAZ == nil || math.Abs(*a.WAZ) > 0.05 || a.HAZ == nil || math.Abs(*a.HAZ) > 0.05 {
+		t.Errorf("expected z-scores near 0, got WAZ %v HAZ %v", a.WAZ, a.HAZ)
+	}
+	if a.WHZ == nil || a.NutritionStatus != NutritionStatusNormal || len(a.Flags) != 0 {
+		t.Errorf("expected a well-nourished child, got %+v", a)
+	}
+
+	// Wasted girl at 30 months: 8.4 kg at 88 cm standing
+	weight, height := 8.4, 88.0
+	a, err = ref.Assess("Female", dob, dob.AddDate(2, 6, 0), GrowthInput{WeightKg: &weight, HeightCm: &height})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.WHZ == nil || *a.WHZ >= -3 || a.NutritionStatus != NutritionStatusSAM {
+		t.Errorf("expected severe wasting, got WHZ %v status %q", a.WHZ, a.NutritionStatus)
+	}
+	if !a.Flags.Has(GrowthFlagSeverelyUnderweight) {
+		t.Errorf("expected severely underweight, got %v", a.Flags)
+	}
+
+	// Length measured standing under 2 years is converted to recumbent length
+	length = 75.0
+	lying, _ := ref.Assess("Male", dob, dob.AddDate(1, 0, 0), GrowthInput{HeightCm: &length})
+	standing, _ := ref.Assess("Male", dob, dob.AddDate(1, 0, 0), GrowthInput{HeightCm: &length, MeasuredLying: new(bool)})
+	if *standing.HAZ <= *lying.HAZ {
+		t.Errorf("standing measurement should convert upward: lying %v standing %v", *lying.HAZ, *standing.HAZ)
+	}
+
+	weight = 30
+	if _, err := ref.Assess("Male", dob, dob.AddDate(1, 0, 0), GrowthInput{WeightKg: &weight}); err == nil {
+		t.Error("expected an implausible weight-for-age to be rejected")
+	}
+	if _, err := ref.Assess("Male", dob, dob.AddDate(6, 0, 0), GrowthInput{WeightKg: &weight}); err == nil {
+		t.Error("expected a child over 5 to be rejected")
+	}
+
+	// Every accepted age falls within the tables
+	weight = 18
+	a, err = ref.Assess("Male", dob, dob.AddDate(0, 0, GrowthMaxAgeDays), GrowthInput{WeightKg: &weight})
+	if err != nil || a.WAZ == nil {
+		t.Errorf("expected a weight-for-age z-score at the oldest age, got %v, %v", a.WAZ, err)
+	}
+	if _, err := ref.Assess("Male", dob, dob.AddDate(0, 0, GrowthMaxAgeDays+1), GrowthInput{WeightKg: &weight}); err == nil {
+		t.Error("expected an age past the tables to be rejected")
+	}
+}
+
+func TestClassifyAcuteMalnutrition(t *testing.T) {
+	tests := []struct {
+		name    string
+		whz     *float64
+		muac    *float64
+		oedema  bool
+		ageDays int
+		want    string
+	}{
+		{"not assessed", nil, nil, false, 400, ""},
+		{"oedema", nil, nil, true, 400, NutritionStatusSAM},
+		{"whz below -3", floatPtr(-3.2), nil, false, 400, NutritionStatusSAM},
+		{"muac below 11.5", floatPtr(-1), floatPtr(11.2), false, 400, NutritionStatusSAM},
+		{"whz below -2", floatPtr(-2.5), nil, false, 400, NutritionStatusMAM},
+		{"muac below 12.5", nil, floatPtr(12.0), false, 400, NutritionStatusMAM},
+		{"muac ignored under 6 months", nil, floatPtr(11.0), false, 120, ""},
+		{"normal", floatPtr(-0.5), floatPtr(14), false, 400, NutritionStatusNormal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyAcuteMalnutrition(tt.whz, tt.muac, tt.oedema, tt.ageDays); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package models
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ICD10Code is a code in the ICD-10 catalog that diagnoses are validated
+// against. Codes dropped from the catalog file are deactivated rather than
+// deleted so that existing diagnoses keep their description and chapter.
+type ICD10Code struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Code        string    `json:"code" gorm:"not null;size:10;uniqueIndex"` // e.g. J06.9
+	Description string    `json:"description" gorm:"not null;size:255"`
+	Category    string    `json:"category" gorm:"not null;size:3;index"` // Three-character category, e.g. J06
+	Chapter     int       `json:"chapter" gorm:"not null;index"`
+	Active      bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName keeps the table name readable; GORM would otherwise name it i_c_d10_codes
+func (ICD10Code) TableName() string {
+	return "icd10_codes"
+}
+
+// ICD10Chapter is a chapter of the classification, covering a range of categories
+type ICD10Chapter struct {
+	Number int    `json:"number"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Title  string `json:"title"`
+}
+
+// Range renders the chapter's categories, e.g. J00-J99
+func (ch ICD10Chapter) Range() string {
+	return ch.From + "-" + ch.To
+}
+
+// ICD10Chapters are the chapters of WHO ICD-10 in order
+var ICD10Chapters = []ICD10Chapter{
+	{1, "A00", "B99", "Certain infectious and parasitic diseases"},
+	{2, "C00", "D48", "Neoplasms"},
+	{3, "D50", "D89", "Diseases of the blood and blood-forming organs and certain disorders involving the immune mechanism"},
+	{4, "E00", "E90", "Endocrine, nutritional and metabolic diseases"},
+	{5, "F00", "F99", "Mental and behavioural disorders"},
+	{6, "G00", "G99", "Diseases of the nervous system"},
+	{7, "H00", "H59", "Diseases of the eye and adnexa"},
+	{8, "H60", "H95", "Diseases of the ear and mastoid process"},
+	{9, "I00", "I99", "Diseases of the circulatory system"},
+	{10, "J00", "J99", "Diseases of the respiratory system"},
+	{11, "K00", "K93", "Diseases of the digestive system"},
+	{12, "L00", "L99", "Diseases of the skin and subcutaneous tissue"},
+	{13, "M00", "M99", "Diseases of the musculoskeletal system and connective tissue"},
+	{14, "N00", "N99", "Diseases of the genitourinary system"},
+	{15, "O00", "O99", "Pregnancy, childbirth and the puerperium"},
+	{16, "P00", "P96", "Certain conditions originating in the perinatal period"},
+	{17, "Q00", "Q99", "Congenital malformations, deformations and chromosomal abnormalities"},
+	{18, "R00", "R99", "Symptoms, signs and abnormal clinical and laboratory findings, not elsewhere classified"},
+	{19, "S00", "T98", "Injury, poisoning and certain other consequences of external causes"},
+	{20, "V01", "Y98", "External causes of morbidity and mortality"},
+	{21, "Z00", "Z99", "Factors influencing health status and contact with health services"},
+	{22, "U00", "U85", "Codes for special purposes"},
+}
+
+var icd10CodePattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z](\.[0-9A-Z]{1,4})?$`)
+
+// NormalizeICD10Code upper-cases a code, restores the dot after the category
+// when it was left out (J069 becomes J06.9) and checks its shape
+func NormalizeICD10Code(code string) (string, error) {
+	code = strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	if len(code) > 3 && !strings.Contains(code, ".") {
+		code = code[:3] + "." + code[3:]
+	}
+	if !icd10CodePattern.MatchString(code) {
+		return "", fmt.Errorf("%q is not an ICD-10 code", code)
+	}
+	return code, nil
+}
+
+// ICD10Category returns the three-character category of a code, e.g. J06 for J06.9
+func ICD10Category(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) < 3 {
+		return code
+	}
+	return code[:3]
+}
+
+// ICD10ChapterFor returns the chapter a code or category falls in, or nil
+// for anything outside the classification
+func ICD10ChapterFor(code string) *ICD10Chapter {
+	category := ICD10Category(code)
+	if len(category) != 3 {
+		return nil
+	}
+	for i := range ICD10Chapters {
+		ch := &ICD10Chapters[i]
+		if category >= ch.From && category <= ch.To {
+			return ch
+		}
+	}
+	return nil
+}
+
+// Normalize tidies the code and description and fills in the category and chapter
+func (c *ICD10Code) Normalize() error {
+	code, err := NormalizeICD10Code(c.Code)
+	if err != nil {
+		return err
+	}
+	c.Code = code
+	c.Description = strings.TrimSpace(c.Description)
+	if c.Description == "" {
+		return fmt.Errorf("%s needs a description", c.Code)
+	}
+	if len(c.Description) > 255 {
+		return fmt.Errorf("%s description is longer than 255 characters", c.Code)
+	}
+
+	chapter := ICD10ChapterFor(c.Code)
+	if chapter == nil {
+		return fmt.Errorf("%s is not in any ICD-10 chapter", c.Code)
+	}
+	c.Category = ICD10Category(c.Code)
+	c.Chapter = chapter.Number
+	return nil
+}
+
+// LoadICD10Codes reads the ICD-10 catalog from a CSV file
+func LoadICD10Codes(path string) ([]ICD10Code, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseICD10CodesCSV(f)
+}
+
+// ParseICD10CodesCSV parses codes from CSV with a header row naming the
+// columns code and description. Every row must have as many fields as the header.
+func ParseICD10CodesCSV(r io.Reader) ([]ICD10Code, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"code", "description"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var codes []ICD10Code
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		code := ICD10Code{
+			Code:        record[columns["code"]],
+			Description: record[columns["description"]],
+			Active:      true,
+		}
+		if err := code.Normalize(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if seen[code.Code] {
+			return nil, fmt.Errorf("line %d: %s is listed twice", line, code.Code)
+		}
+		seen[code.Code] = true
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// DiagnosisRollup is a count of diagnoses for one ICD-10 chapter or category
+type DiagnosisRollup struct {
+	Code       string  `json:"code"` // Chapter range (e.g. J00-J99) or category (e.g. J06)
+	Chapter    int     `json:"chapter"`
+	Title      string  `json:"title"`
+	Count      int64   `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// UnclassifiedDiagnosisTitle heads the roll-up of codes outside every chapter,
+// recorded before codes were validated
+const UnclassifiedDiagnosisTitle = "Unclassified codes"
+
+// RollUpDiagnosisChapters totals diagnosis counts per category into chapters,
+// largest first. Categories outside the classification are totalled as
+// chapter 0.
+func RollUpDiagnosisChapters(categoryCounts map[string]int64) []DiagnosisRollup {
+	byChapter := make(map[int]*DiagnosisRollup)
+	var total int64
+	for category, count := range categoryCounts {
+		total += count
+		number := 0
+		rollup := DiagnosisRollup{Title: UnclassifiedDiagnosisTitle}
+		if ch := ICD10ChapterFor(category); ch != nil {
+			number = ch.Number
+			rollup = DiagnosisRollup{Code: ch.Range(), Chapter: ch.Number, Title: ch.Title}
+		}
+		if existing, ok := byChapter[number]; ok {
+			existing.Count += count
+			continue
+		}
+		rollup.Count = count
+		byChapter[number] = &rollup
+	}
+
+	rollups := make([]DiagnosisRollup, 0, len(byChapter))
+	for _, r := range byChapter {
+		if total > 0 {
+			r.Percentage = float64(r.Count) / float64(total) * 100
+		}
+		rollups = append(rollups, *r)
+	}
+	sort.Slice(rollups, func(i, j int) bool {
+		if rollups[i].Count != rollups[j].Count {
+			return rollups[i].Count > rollups[j].Count
+		}
+		return rollups[i].Chapter < rollups[j].Chapter
+	})
+	return rollups
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeICD10Code(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"j069", "J06.9"},
+		{" r05 ", "R05"},
+		{"Z00.00", "Z00.00"},
+		{"o9a.11", "O9A.11"},
+		{"B 54", "B54"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeICD10Code(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeICD10Code(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "123", "J0", "J06.", "J06.12345", "JJ6.9"} {
+		if _, err := NormalizeICD10Code(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestICD10ChapterFor(t *testing.T) {
+	tests := map[string]int{
+		"J06.9": 10,
+		"O99.0": 15,
+		"C4A.1": 2,
+		"D50":   3,
+		"T14.8": 19,
+		"Y98":   20,
+		"U07.1": 22,
+	}
+	for code, want := range tests {
+		ch := ICD10ChapterFor(code)
+		if ch == nil || ch.Number != want {
+			t.Errorf("ICD10ChapterFor(%q) = %+v, want chapter %d", code, ch, want)
+		}
+	}
+	for _, code := range []string{"", "W9", "123", "D49", "O9A"} {
+		if ch := ICD10ChapterFor(code); ch != nil {
+			t.Errorf("ICD10ChapterFor(%q) = %+v, want nil", code, ch)
+		}
+	}
+}
+
+func TestParseICD10CodesCSV(t *testing.T) {
+	codes, err := ParseICD10CodesCSV(strings.NewReader(`# comment
+code,description
+J06,"Acute upper respiratory infections of multiple and unspecified sites"
+j069, Acute upper respiratory infection, unspecified
+`))
+	if err == nil {
+		t.Fatal("expected an error for an unquoted comma")
+	}
+
+	codes, err = ParseICD10CodesCSV(strings.NewReader(`# comment
+Description,Code
+"Acute upper respiratory infections of multiple and unspecified sites",J06
+"Acute upper respiratory infection, unspecified",j069
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 2 {
+		t.Fatalf("got %d codes, want 2", len(codes))
+	}
+	if c := codes[1]; c.Code != "J06.9" || c.Category != "J06" || c.Chapter != 10 || !c.Active {
+		t.Errorf("code = %+v, want J06.9 in chapter 10", c)
+	}
+
+	bad := []string{
+		"code\nJ06\n",
+		"code,description\nJ06.9,\n",
+		"code,description\n123,Not a code\n",
+		"code,description\nJ06.9,One\nJ069,Two\n",
+	}
+	for _, input := range bad {
+		if _, err := ParseICD10CodesCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestBundledICD10Codes(t *testing.T) {
+	f, err := os.Open("../../data/icd10_codes.csv")
+	if err != nil {
+		t.Skip("bundled codes not found")
+	}
+	defer f.Close()
+
+	codes, err := ParseICD10CodesCSV(f)
+	if err != nil {
+		t.Fatalf("bundled codes do not parse: %v", err)
+	}
+	listed := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		listed[c.Code] = true
+	}
+	// Subdivisions validate through their category, so every category
+	// must be listed for its subdivisions to be accepted
+	for _, c := range codes {
+		if !listed[c.Category] {
+			t.Errorf("bundled codes list %s but not its category %s", c.Code, c.Category)
+		}
+	}
+	// Codes used by the seed data, and common ones reported as rejected
+	for _, code := range []string{"J06.9", "A09", "I10", "E11.9", "M54.5", "R05", "Z00.00", "J03.90",
+		"K35.8", "O14.1", "P07.3", "S06.0", "T14.9", "R10.4"} {
+		if !listed[ICD10Category(code)] {
+			t.Errorf("bundled codes are missing the category of %s", code)
+		}
+	}
+}
+
+func TestRollUpDiagnosisChapters(t *testing.T) {
+	rollups := RollUpDiagnosisChapters(map[string]int64{
+		"J06": 5,
+		"J18": 3,
+		"A09": 4,
+		"X":   1,
+	})
+	if len(rollups) != 3 {
+		t.Fatalf("got %d chapters, want 3: %+v", len(rollups), rollups)
+	}
+	if r := rollups[0]; r.Chapter != 10 || r.Code != "J00-J99" || r.Count != 8 || r.Percentage != 8.0/13*100 {
+		t.Errorf("first = %+v, want respiratory with 8", r)
+	}
+	if r := rollups[2]; r.Chapter != 0 || r.Title != UnclassifiedDiagnosisTitle || r.Count != 1 {
+		t.Errorf("last = %+v, want unclassified with 1", r)
+	}
+	if rollups := RollUpDiagnosisChapters(nil); len(rollups) != 0 {
+		t.Errorf("got %+v for no diagnoses", rollups)
+	}
+}
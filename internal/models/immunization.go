@@ -0,0 +1,326 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status of a scheduled vaccine dose for a patient
+const (
+	DoseStatusGiven    = "given"
+	DoseStatusDue      = "due"      // due date reached, within the grace period
+	DoseStatusOverdue  = "overdue"  // grace period passed
+	DoseStatusUpcoming = "upcoming" // due date not reached yet
+	DoseStatusMissed   = "missed"   // patient is past the age the dose is given at
+)
+
+// ImmunizationGraceDays is how long after its due date a dose counts as due
+// rather than overdue
+const ImmunizationGraceDays = 28
+
+// DefaultMaxAgeDays is the catch-up limit for doses whose schedule does not set one
+const DefaultMaxAgeDays = 5 * 365
+
+// Injection sites (or route) recorded for an immunization
+var ImmunizationSites = []string{"left_arm", "right_arm", "left_thigh", "right_thigh", "oral", "other"}
+
+// Vaccine is a vaccine in the immunization schedule. Vaccines dropped from
+// the schedule file are deactivated rather than deleted so that recorded
+// immunizations keep their vaccine.
+type Vaccine struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"not null;size:20;uniqueIndex"` // e.g. BCG, OPV, MR
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	Diseases  string    `json:"diseases,omitempty" gorm:"size:255"`
+	Route     string    `json:"route,omitempty" gorm:"size:30"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	Doses []VaccineScheduleDose `json:"doses" gorm:"foreignKey:VaccineID"`
+}
+
+// VaccineScheduleDose is when one dose of a vaccine is given, by age
+type VaccineScheduleDose struct {
+	ID              uint `json:"id" gorm:"primaryKey"`
+	VaccineID       uint `json:"vaccine_id" gorm:"not null;uniqueIndex:idx_vaccine_dose"`
+	DoseNumber      int  `json:"dose_number" gorm:"not null;uniqueIndex:idx_vaccine_dose"`
+	AgeDays         int  `json:"age_days" gorm:"not null"`                    // Age the dose is due at
+	MinIntervalDays int  `json:"min_interval_days" gorm:"not null;default:0"` // Minimum gap after the previous dose
+	MaxAgeDays      *int `json:"max_age_days,omitempty"`                      // Not given after this age
+}
+
+// Immunization is a vaccine dose given to a patient
+type Immunization struct {
+	ID                    uint      `json:"id" gorm:"primaryKey"`
+	PatientID             uint      `json:"patient_id" gorm:"not null;index"`
+	VaccineID             uint      `json:"vaccine_id" gorm:"not null;index"`
+	DoseNumber            int       `json:"dose_number" gorm:"not null"` // Each dose is recorded once per patient, see EnsureDoseIndex
+	AdministeredOn        time.Time `json:"administered_on" gorm:"not null;type:date"`
+	BatchNumber           string    `json:"batch_number,omitempty" gorm:"size:50"`
+	Site                  string    `json:"site,omitempty" gorm:"size:20"`
+	AdministeredByStaffID *uint     `json:"administered_by_staff_id,omitempty"` // Empty for doses transcribed from a vaccination card
+	ClinicID              uint      `json:"clinic_id" gorm:"not null;index"`
+	RecordedByUserID      *uint     `json:"recorded_by_user_id,omitempty"`
+	Notes                 string    `json:"notes,omitempty" gorm:"size:500"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+
+	// Relationships
+	Vaccine        *Vaccine `json:"vaccine,omitempty" gorm:"foreignKey:VaccineID;references:ID"`
+	AdministeredBy *Staff   `json:"administered_by,omitempty" gorm:"foreignKey:AdministeredByStaffID;references:ID"`
+}
+
+// Normalize upper-cases the vaccine code, orders the doses and validates the schedule
+func (v *Vaccine) Normalize() error {
+	v.Code = strings.ToUpper(strings.TrimSpace(v.Code))
+	v.Name = strings.TrimSpace(v.Name)
+	v.Diseases = strings.TrimSpace(v.Diseases)
+	v.Route = strings.ToLower(strings.TrimSpace(v.Route))
+
+	if v.Code == "" || v.Name == "" {
+		return fmt.Errorf("code and name are required")
+	}
+	if len(v.Code) > 20 {
+		return fmt.Errorf("code %q is longer than 20 characters", v.Code)
+	}
+	if len(v.Doses) == 0 {
+		return fmt.Errorf("%s has no doses", v.Code)
+	}
+
+	sort.Slice(v.Doses, func(i, j int) bool { return v.Doses[i].DoseNumber < v.Doses[j].DoseNumber })
+	for i := range v.Doses {
+		d := &v.Doses[i]
+		d.ID, d.VaccineID = 0, 0
+		if d.DoseNumber != i+1 {
+			return fmt.Errorf("%s doses must be numbered 1 to %d", v.Code, len(v.Doses))
+		}
+		if d.AgeDays < 0 || d.MinIntervalDays < 0 {
+			return fmt.Errorf("%s dose %d has a negative age or interval", v.Code, d.DoseNumber)
+		}
+		if d.MaxAgeDays != nil && *d.MaxAgeDays < d.AgeDays {
+			return fmt.Errorf("%s dose %d has max_age_days before age_days", v.Code, d.DoseNumber)
+		}
+	}
+	return nil
+}
+
+// LoadImmunizationSchedule reads vaccines and their doses from a JSON file
+func LoadImmunizationSchedule(path string) ([]Vaccine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseImmunizationSchedule(f)
+}
+
+// ParseImmunizationSchedule parses a JSON object with a "vaccines" array
+func ParseImmunizationSchedule(r io.Reader) ([]Vaccine, error) {
+	var schedule struct {
+		Vaccines []Vaccine `json:"vaccines"`
+	}
+	if err := json.NewDecoder(r).Decode(&schedule); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(schedule.Vaccines))
+	for i := range schedule.Vaccines {
+		v := &schedule.Vaccines[i]
+		v.ID = 0
+		v.Active = true
+		if err := v.Normalize(); err != nil {
+			return nil, fmt.Errorf("vaccine %d: %w", i+1, err)
+		}
+		if seen[v.Code] {
+			return nil, fmt.Errorf("vaccine %s is listed twice", v.Code)
+		}
+		seen[v.Code] = true
+	}
+	return schedule.Vaccines, nil
+}
+
+// DoseStatus is where a patient stands on one scheduled dose
+type DoseStatus struct {
+	VaccineID      uint       `json:"vaccine_id"`
+	VaccineCode    string     `json:"vaccine_code"`
+	VaccineName    string     `json:"vaccine_name"`
+	DoseNumber     int        `json:"dose_number"`
+	DueDate        time.Time  `json:"due_date"`
+	Status         string     `json:"status"`
+	GivenOn        *time.Time `json:"given_on,omitempty"`
+	ImmunizationID *uint      `json:"immunization_id,omitempty"`
+}
+
+// ComputeDoseStatuses works out every scheduled dose's status for a patient
+// born on dob, given the immunizations already recorded. A dose falls due at
+// its schedule age, but no sooner than its minimum interval after the
+// previous dose, whether that was given or is itself still due.
+func ComputeDoseStatuses(dob time.Time, vaccines []Vaccine, given []Immunization, now time.Time) []DoseStatus {
+	birth := dateOnly(dob)
+	today := dateOnly(now)
+
+	type doseKey struct {
+		vaccineID uint
+		dose      int
+	}
+	recorded := make(map[doseKey]Immunization, len(given))
+	for _, imm := range given {
+		key := doseKey{imm.VaccineID, imm.DoseNumber}
+		if existing, ok := recorded[key]; !ok || imm.AdministeredOn.Before(existing.AdministeredOn) {
+			recorded[key] = imm
+		}
+	}
+
+	var statuses []DoseStatus
+	for _, v := range vaccines {
+		var previous time.Time
+		for i, d := range v.Doses {
+			due := birth.AddDate(0, 0, d.AgeDays)
+			if i > 0 {
+				if earliest := previous.AddDate(0, 0, d.MinIntervalDays); earliest.After(due) {
+					due = earliest
+				}
+			}
+
+			status := DoseStatus{
+				VaccineID:   v.ID,
+				VaccineCode: v.Code,
+				VaccineName: v.Name,
+				DoseNumber:  d.DoseNumber,
+				DueDate:     due,
+			}
+
+			if imm, ok := recorded[doseKey{v.ID, d.DoseNumber}]; ok {
+				givenOn := dateOnly(imm.AdministeredOn)
+				id := imm.ID
+				status.Status = DoseStatusGiven
+				status.GivenOn = &givenOn
+				status.ImmunizationID = &id
+				previous = givenOn
+			} else {
+				maxAge := DefaultMaxAgeDays
+				if d.MaxAgeDays != nil {
+					maxAge = *d.MaxAgeDays
+				}
+				switch {
+				case today.After(birth.AddDate(0, 0, maxAge)):
+					status.Status = DoseStatusMissed
+				case today.Before(due):
+					status.Status = DoseStatusUpcoming
+				case today.Before(due.AddDate(0, 0, ImmunizationGraceDays)):
+					status.Status = DoseStatusDue
+				default:
+					status.Status = DoseStatusOverdue
+				}
+				previous = due
+			}
+
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// DosesDueBy returns the doses that are due or overdue, or will fall due on
+// or before until, earliest first
+func DosesDueBy(statuses []DoseStatus, until time.Time) []DoseStatus {
+	limit := dateOnly(until)
+	var due []DoseStatus
+	for _, s := range statuses {
+		switch s.Status {
+		case DoseStatusDue, DoseStatusOverdue:
+			due = append(due, s)
+		case DoseStatusUpcoming:
+			if !s.DueDate.After(limit) {
+				due = append(due, s)
+			}
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].DueDate.Before(due[j].DueDate) })
+	return due
+}
+
+// ScheduleMaxAgeDays is the oldest age at which any scheduled dose can still
+// be given; older patients have nothing left to be due
+func ScheduleMaxAgeDays(vaccines []Vaccine) int {
+	oldest := 0
+	for _, v := range vaccines {
+		for _, d := range v.Doses {
+			maxAge := DefaultMaxAgeDays
+			if d.MaxAgeDays != nil {
+				maxAge = *d.MaxAgeDays
+			}
+			oldest = max(oldest, maxAge)
+		}
+	}
+	return oldest
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DuplicateDose is a patient's vaccine dose recorded more than once, with
+// every copy so that someone can decide which one is correct
+type DuplicateDose struct {
+	PatientID  uint           `json:"patient_id"`
+	VaccineID  uint           `json:"vaccine_id"`
+	DoseNumber int            `json:"dose_number"`
+	Records    []Immunization `json:"records"`
+}
+
+// EnsureDoseIndex creates the unique index that keeps each patient's vaccine
+// dose to one record. Doses recorded twice before the index existed must be
+// reconciled first, so while any remain the index is left out and false is
+// returned.
+func EnsureDoseIndex(db *gorm.DB) (bool, error) {
+	var duplicates int64
+	if err := db.Raw(`SELECT COUNT(*) FROM (SELECT 1 FROM immunizations
+		GROUP BY patient_id, vaccine_id, dose_number HAVING COUNT(*) > 1) d`).Scan(&duplicates).Error; err != nil {
+		return false, err
+	}
+	if duplicates > 0 {
+		return false, nil
+	}
+	err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_patient_vaccine_dose
+		ON immunizations (patient_id, vaccine_id, dose_number)`).Error
+	return err == nil, err
+}
+
+// Immunization DTOs
+type RecordImmunizationRequest struct {
+	VaccineCode           string `json:"vaccine_code" validate:"required"`
+	DoseNumber            int    `json:"dose_number" validate:"required,min=1"`
+	AdministeredOn        string `json:"administered_on,omitempty"` // YYYY-MM-DD, defaults to today
+	BatchNumber           string `json:"batch_number,omitempty" validate:"max=50"`
+	Site                  string `json:"site,omitempty"`
+	AdministeredByStaffID *uint  `json:"administered_by_staff_id,omitempty"` // Defaults to the recording doctor or nurse
+	Notes                 string `json:"notes,omitempty" validate:"max=500"`
+}
+
+// PatientImmunizations is a patient's immunization history and schedule
+type PatientImmunizations struct {
+	PatientID     uint           `json:"patient_id"`
+	DateOfBirth   time.Time      `json:"date_of_birth"`
+	Immunizations []Immunization `json:"immunizations"`
+	Schedule      []DoseStatus   `json:"schedule"`
+}
+
+// ImmunizationDueEntry is a patient on a clinic's due list
+type ImmunizationDueEntry struct {
+	PatientID   uint         `json:"patient_id"`
+	MRN         *string      `json:"mrn,omitempty"`
+	FullName    string       `json:"full_name"`
+	DateOfBirth time.Time    `json:"date_of_birth"`
+	Phone       string       `json:"phone"`
+	Doses       []DoseStatus `json:"doses"`
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSchedule() []Vaccine {
+	return []Vaccine{
+		{ID: 1, Code: "BCG", Name: "BCG", Doses: []VaccineScheduleDose{
+			{DoseNumber: 1, AgeDays: 0, MaxAgeDays: intPtr(365)},
+		}},
+		{ID: 2, Code: "OPV", Name: "Oral polio vaccine", Doses: []VaccineScheduleDose{
+			{DoseNumber: 1, AgeDays: 42},
+			{DoseNumber: 2, AgeDays: 70, MinIntervalDays: 28},
+			{DoseNumber: 3, AgeDays: 98, MinIntervalDays: 28},
+		}},
+	}
+}
+
+func statusOf(statuses []DoseStatus, code string, dose int) DoseStatus {
+	for _, s := range statuses {
+		if s.VaccineCode == code && s.DoseNumber == dose {
+			return s
+		}
+	}
+	return DoseStatus{}
+}
+
+func TestComputeDoseStatuses(t *testing.T) {
+	dob := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := dob.AddDate(0, 0, 80) // 80 days old
+
+	given := []Immunization{
+		{ID: 10, VaccineID: 1, DoseNumber: 1, AdministeredOn: dob},
+		// OPV 1 given late, at 60 days, which pushes dose 2 to day 88
+		{ID: 11, VaccineID: 2, DoseNumber: 1, AdministeredOn: dob.AddDate(0, 0, 60)},
+	}
+	statuses := ComputeDoseStatuses(dob, testSchedule(), given, now)
+	if len(statuses) != 4 {
+		t.Fatalf("got %d statuses, want 4", len(statuses))
+	}
+
+	if s := statusOf(statuses, "BCG", 1); s.Status != DoseStatusGiven || s.ImmunizationID == nil || *s.ImmunizationID != 10 {
+		t.Errorf("BCG = %+v, want given by immunization 10", s)
+	}
+	opv2 := statusOf(statuses, "OPV", 2)
+	if opv2.Status != DoseStatusUpcoming || !opv2.DueDate.Equal(dob.AddDate(0, 0, 88)) {
+		t.Errorf("OPV 2 = %s due %s, want upcoming due day 88", opv2.Status, opv2.DueDate)
+	}
+	if opv3 := statusOf(statuses, "OPV", 3); !opv3.DueDate.Equal(dob.AddDate(0, 0, 116)) {
+		t.Errorf("OPV 3 due %s, want day 116 (28 days after dose 2)", opv3.DueDate)
+	}
+}
+
+func TestComputeDoseStatusesDueOverdueMissed(t *testing.T) {
+	dob := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	statuses := ComputeDoseStatuses(dob, testSchedule(), nil, dob.AddDate(0, 0, 50))
+	if s := statusOf(statuses, "OPV", 1); s.Status != DoseStatusDue {
+		t.Errorf("OPV 1 at 50 days = %s, want due", s.Status)
+	}
+	if s := statusOf(statuses, "BCG", 1); s.Status != DoseStatusOverdue {
+		t.Errorf("BCG at 50 days = %s, want overdue", s.Status)
+	}
+
+	statuses = ComputeDoseStatuses(dob, testSchedule(), nil, dob.AddDate(2, 0, 0))
+	if s := statusOf(statuses, "BCG", 1); s.Status != DoseStatusMissed {
+		t.Errorf("BCG at 2 years = %s, want missed", s.Status)
+	}
+	if s := statusOf(statuses, "OPV", 3); s.Status != DoseStatusOverdue {
+		t.Errorf("OPV 3 at 2 years = %s, want overdue", s.Status)
+	}
+}
+
+func TestDosesDueBy(t *testing.T) {
+	dob := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := dob.AddDate(0, 0, 38)
+	statuses := ComputeDoseStatuses(dob, testSchedule(), nil, now)
+
+	due := DosesDueBy(statuses, now.AddDate(0, 0, 7))
+	if len(due) != 2 || due[0].VaccineCode != "BCG" || due[1].VaccineCode != "OPV" || due[1].DoseNumber != 1 {
+		t.Errorf("due this week = %+v, want BCG then OPV 1", due)
+	}
+}
+
+func TestParseImmunizationSchedule(t *testing.T) {
+	vaccines, err := ParseImmunizationSchedule(strings.NewReader(`{"vaccines": [
+		{"code": " mr ", "name": "Measles-rubella", "doses": [
+			{"dose_number": 2, "age_days": 450, "min_interval_days": 28},
+			{"dose_number": 1, "age_days": 270}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaccines[0].Code != "MR" || vaccines[0].Doses[0].DoseNumber != 1 || !vaccines[0].Active {
+		t.Errorf("vaccine = %+v, want code MR with doses ordered", vaccines[0])
+	}
+
+	if _, err := ParseImmunizationSchedule(strings.NewReader(`{"vaccines": [
+		{"code": "MR", "name": "Measles-rubella", "doses": [{"dose_number": 2, "age_days": 270}]}
+	]}`)); err == nil {
+		t.Error("expected an error for doses not numbered from 1")
+	}
+	if _, err := ParseImmunizationSchedule(strings.NewReader(`{"vaccines": [
+		{"code": "BCG", "name": "BCG", "doses": [{"dose_number": 1, "age_days": 0}]},
+		{"code": "bcg", "name": "BCG", "doses": [{"dose_number": 1, "age_days": 0}]}
+	]}`)); err == nil {
+		t.Error("expected an error for a vaccine listed twice")
+	}
+}
+
+func TestBundledImmunizationSchedule(t *testing.T) {
+	f, err := os.Open("../../data/immunization_schedule.json")
+	if err != nil {
+		t.Skip("bundled schedule not found")
+	}
+	defer f.Close()
+
+	vaccines, err := ParseImmunizationSchedule(f)
+	if err != nil {
+		t.Fatalf("bundled schedule does not parse: %v", err)
+	}
+	if len(vaccines) == 0 {
+		t.Error("bundled schedule has no vaccines")
+	}
+	if ScheduleMaxAgeDays(vaccines) < 365 {
+		t.Errorf("ScheduleMaxAgeDays = %d, want at least a year", ScheduleMaxAgeDays(vaccines))
+	}
+}
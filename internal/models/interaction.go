@@ -0,0 +1,244 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Drug interaction severities
+const (
+	InteractionSeverityMinor    = "minor"
+	InteractionSeverityModerate = "moderate"
+	InteractionSeveritySevere   = "severe"
+)
+
+// DrugInteraction is a rule that two drugs interact. Drug names are stored
+// lower-case with DrugA sorting before DrugB so each pair is stored once.
+type DrugInteraction struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DrugA       string    `json:"drug_a" gorm:"not null;size:100;uniqueIndex:idx_drug_interaction_pair"`
+	DrugB       string    `json:"drug_b" gorm:"not null;size:100;uniqueIndex:idx_drug_interaction_pair"`
+	Severity    string    `json:"severity" gorm:"not null;size:20"`
+	Description string    `json:"description" gorm:"size:1000"`
+	Management  string    `json:"management,omitempty" gorm:"size:1000"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Normalize lower-cases the drug names, orders the pair and validates the rule
+func (d *DrugInteraction) Normalize() error {
+	d.DrugA = strings.ToLower(strings.TrimSpace(d.DrugA))
+	d.DrugB = strings.ToLower(strings.TrimSpace(d.DrugB))
+	d.Severity = strings.ToLower(strings.TrimSpace(d.Severity))
+	d.Description = strings.TrimSpace(d.Description)
+	d.Management = strings.TrimSpace(d.Management)
+
+	if d.DrugA == "" || d.DrugB == "" {
+		return fmt.Errorf("drug_a and drug_b are required")
+	}
+	if d.DrugA == d.DrugB {
+		return fmt.Errorf("drug_a and drug_b must differ (%s)", d.DrugA)
+	}
+	switch d.Severity {
+	case InteractionSeverityMinor, InteractionSeverityModerate, InteractionSeveritySevere:
+	default:
+		return fmt.Errorf("invalid severity %q for %s/%s", d.Severity, d.DrugA, d.DrugB)
+	}
+	if d.DrugA > d.DrugB {
+		d.DrugA, d.DrugB = d.DrugB, d.DrugA
+	}
+	return nil
+}
+
+// LoadInteractionRules reads interaction rules from a .csv or .json file
+func LoadInteractionRules(path string) ([]DrugInteraction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseInteractionRulesCSV(f)
+	case ".json":
+		return ParseInteractionRulesJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported interaction rule file %s (expected .csv or .json)", path)
+	}
+}
+
+// ParseInteractionRulesCSV parses rules from CSV with a header row naming the
+// columns drug_a, drug_b, severity, description and (optionally) management
+func ParseInteractionRulesCSV(r io.Reader) ([]DrugInteraction, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"drug_a", "drug_b", "severity"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rules []DrugInteraction
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rule := DrugInteraction{
+			DrugA:       field(record, "drug_a"),
+			DrugB:       field(record, "drug_b"),
+			Severity:    field(record, "severity"),
+			Description: field(record, "description"),
+			Management:  field(record, "management"),
+		}
+		if err := rule.Normalize(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseInteractionRulesJSON parses rules from a JSON array of objects
+func ParseInteractionRulesJSON(r io.Reader) ([]DrugInteraction, error) {
+	var rules []DrugInteraction
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rules[i].ID = 0
+		if err := rules[i].Normalize(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+// ActiveMedication is a prescription that is still being taken
+type ActiveMedication struct {
+	PrescriptionID uint      `json:"prescription_id"`
+	VisitID        uint      `json:"visit_id"`
+	MedicationName string    `json:"medication_name"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+// ActiveMedications returns the prescriptions whose course (visit date plus
+// DurationDays) is still running on the given day. Prescriptions must have
+// their Visit loaded.
+func ActiveMedications(prescriptions []Prescription, on time.Time) []ActiveMedication {
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, on.Location())
+
+	var active []ActiveMedication
+	for _, p := range prescriptions {
+		if p.Visit == nil {
+			continue
+		}
+		start := p.Visit.VisitDate.In(on.Location())
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, on.Location())
+		end := start.AddDate(0, 0, p.DurationDays)
+		if start.After(day) || !end.After(day) {
+			continue
+		}
+		active = append(active, ActiveMedication{
+			PrescriptionID: p.ID,
+			VisitID:        p.VisitID,
+			MedicationName: p.MedicationName,
+			StartDate:      start,
+			EndDate:        end,
+		})
+	}
+	return active
+}
+
+// InteractionWarning describes an interaction between a new medication and one
+// the patient is already taking
+type InteractionWarning struct {
+	Severity                  string `json:"severity"`
+	InteractingMedication     string `json:"interacting_medication"`
+	InteractingPrescriptionID uint   `json:"interacting_prescription_id"`
+	DrugA                     string `json:"drug_a"`
+	DrugB                     string `json:"drug_b"`
+	Description               string `json:"description,omitempty"`
+	Management                string `json:"management,omitempty"`
+}
+
+var interactionSeverityRank = map[string]int{
+	InteractionSeverityMinor:    1,
+	InteractionSeverityModerate: 2,
+	InteractionSeveritySevere:   3,
+}
+
+// MatchInteractions checks a medication name against the patient's active
+// medications. A rule matches when one drug name appears in the new medication
+// and the other in an active one. Warnings are ordered most severe first.
+func MatchInteractions(medicationName string, active []ActiveMedication, rules []DrugInteraction) []InteractionWarning {
+	medication := strings.ToLower(medicationName)
+
+	var warnings []InteractionWarning
+	for _, m := range active {
+		current := strings.ToLower(m.MedicationName)
+		for _, rule := range rules {
+			forward := strings.Contains(medication, rule.DrugA) && strings.Contains(current, rule.DrugB)
+			reverse := strings.Contains(medication, rule.DrugB) && strings.Contains(current, rule.DrugA)
+			if !forward && !reverse {
+				continue
+			}
+			warnings = append(warnings, InteractionWarning{
+				Severity:                  rule.Severity,
+				InteractingMedication:     m.MedicationName,
+				InteractingPrescriptionID: m.PrescriptionID,
+				DrugA:                     rule.DrugA,
+				DrugB:                     rule.DrugB,
+				Description:               rule.Description,
+				Management:                rule.Management,
+			})
+		}
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return interactionSeverityRank[warnings[i].Severity] > interactionSeverityRank[warnings[j].Severity]
+	})
+	return warnings
+}
+
+// RequiresInteractionOverride reports whether any warning is severe enough to
+// block the prescription unless the prescriber gives an override reason
+func RequiresInteractionOverride(warnings []InteractionWarning) bool {
+	for _, w := range warnings {
+		if w.Severity == InteractionSeveritySevere {
+			return true
+		}
+	}
+	return false
+}
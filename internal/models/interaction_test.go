@@ -0,0 +1,139 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInteractionRulesCSV(t *testing.T) {
+	input := `drug_a,drug_b,severity,description,management
+# comment lines are skipped
+Warfarin, Aspirin, SEVERE, "Bleeding risk", Avoid
+ciprofloxacin,antacid,moderate,Reduced absorption,
+`
+	rules, err := ParseInteractionRulesCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	// Pair is lower-cased and ordered
+	if rules[0].DrugA != "aspirin" || rules[0].DrugB != "warfarin" || rules[0].Severity != InteractionSeveritySevere {
+		t.Errorf("unexpected first rule %+v", rules[0])
+	}
+	if rules[1].DrugA != "antacid" || rules[1].Management != "" {
+		t.Errorf("unexpected second rule %+v", rules[1])
+	}
+
+	if _, err := ParseInteractionRulesCSV(strings.NewReader("drug_a,severity\nx,minor\n")); err == nil {
+		t.Error("expected an error for a missing drug_b column")
+	}
+	if _, err := ParseInteractionRulesCSV(strings.NewReader("drug_a,drug_b,severity\nx,y,fatal\n")); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+func TestParseInteractionRulesJSON(t *testing.T) {
+	input := `[{"drug_a": "Simvastatin", "drug_b": "Clarithromycin", "severity": "severe", "description": "Myopathy"}]`
+	rules, err := ParseInteractionRulesJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].DrugA != "clarithromycin" || rules[0].DrugB != "simvastatin" {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	if _, err := ParseInteractionRulesJSON(strings.NewReader(`[{"drug_a": "x", "drug_b": "x", "severity": "minor"}]`)); err == nil {
+		t.Error("expected an error for a drug interacting with itself")
+	}
+}
+
+func TestActiveMedications(t *testing.T) {
+	today := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	visit := func(daysAgo int) *Visit {
+		return &Visit{VisitDate: today.AddDate(0, 0, -daysAgo)}
+	}
+
+	prescriptions := []Prescription{
+		{ID: 1, MedicationName: "Warfarin", DurationDays: 30, Visit: visit(10)},
+		{ID: 2, MedicationName: "Amoxicillin", DurationDays: 5, Visit: visit(5)}, // course ended yesterday
+		{ID: 3, MedicationName: "Paracetamol", DurationDays: 1, Visit: visit(0)}, // started today
+		{ID: 4, MedicationName: "Metformin", DurationDays: 30},                   // visit not loaded
+	}
+
+	active := ActiveMedications(prescriptions, today)
+	if len(active) != 2 {
+		t.Fatalf("got %d active medications %+v, want 2", len(active), active)
+	}
+	if active[0].PrescriptionID != 1 || active[1].PrescriptionID != 3 {
+		t.Errorf("unexpected active prescriptions %+v", active)
+	}
+	if want := time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC); !active[0].EndDate.Equal(want) {
+		t.Errorf("end date = %v, want %v", active[0].EndDate, want)
+	}
+}
+
+func TestMatchInteractions(t *testing.T) {
+	rules := []DrugInteraction{
+		{DrugA: "aspirin", DrugB: "warfarin", Severity: InteractionSeveritySevere},
+		{DrugA: "ibuprofen", DrugB: "warfarin", Severity: InteractionSeveritySevere},
+		{DrugA: "metformin", DrugB: "prednisolone", Severity: InteractionSeverityModerate},
+		{DrugA: "amlodipine", DrugB: "simvastatin", Severity: InteractionSeverityMinor},
+	}
+	active := []ActiveMedication{
+		{PrescriptionID: 1, MedicationName: "Amlodipine 5 mg tablet"},
+		{PrescriptionID: 2, MedicationName: "Warfarin 5mg"},
+		{PrescriptionID: 3, MedicationName: "Metformin 500 mg tablet"},
+	}
+
+	tests := []struct {
+		medication string
+		want       []uint
+	}{
+		{"Aspirin 75 mg tablet", []uint{2}},
+		{"SIMVASTATIN 20mg", []uint{1}},
+		{"Paracetamol 500 mg", nil},
+		{"Prednisolone 5 mg", []uint{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.medication, func(t *testing.T) {
+			warnings := MatchInteractions(tt.medication, active, rules)
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("got %d warnings %+v, want %v", len(warnings), warnings, tt.want)
+			}
+			for i, w := range warnings {
+				if w.InteractingPrescriptionID != tt.want[i] {
+					t.Errorf("warning %d prescription = %d, want %d", i, w.InteractingPrescriptionID, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// Most severe warnings come first
+	warnings := MatchInteractions("Simvastatin + Aspirin", active, rules)
+	if len(warnings) != 2 || warnings[0].Severity != InteractionSeveritySevere || warnings[1].Severity != InteractionSeverityMinor {
+		t.Errorf("unexpected ordering %+v", warnings)
+	}
+}
+
+func TestRequiresInteractionOverride(t *testing.T) {
+	if RequiresInteractionOverride([]InteractionWarning{{Severity: InteractionSeverityModerate}}) {
+		t.Error("moderate interactions should only warn")
+	}
+	if !RequiresInteractionOverride([]InteractionWarning{{Severity: InteractionSeverityMinor}, {Severity: InteractionSeveritySevere}}) {
+		t.Error("severe interaction should require an override")
+	}
+}
+
+func TestBundledInteractionRules(t *testing.T) {
+	rules, err := LoadInteractionRules("../../data/drug_interactions.csv")
+	if err != nil {
+		t.Fatalf("bundled rules failed to load: %v", err)
+	}
+	if len(rules) == 0 {
+		t.Fatal("bundled rules file is empty")
+	}
+}
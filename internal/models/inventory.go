@@ -0,0 +1,198 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stock ledger transaction types
+const (
+	StockTransactionReceipt    = "receipt"
+	StockTransactionAdjustment = "adjustment"
+	StockTransactionDispense   = "dispense"
+)
+
+// Stock adjustment reasons
+var StockAdjustmentReasons = []string{
+	"count_correction", // physical count differs from the system
+	"damaged",
+	"expired",
+	"lost",
+	"returned", // returned to supplier or by a patient
+	"other",
+}
+
+// StockItem is a medication a clinic keeps in stock. QuantityOnHand is the sum
+// of its batches and is maintained together with them.
+type StockItem struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ClinicID        uint      `json:"clinic_id" gorm:"not null;uniqueIndex:idx_stock_item_clinic_name"`
+	FormularyItemID *uint     `json:"formulary_item_id,omitempty" gorm:"index"`
+	MedicationName  string    `json:"medication_name" gorm:"not null;size:255;uniqueIndex:idx_stock_item_clinic_name" validate:"required,min=2,max=255"`
+	Unit            string    `json:"unit" gorm:"not null;size:30" validate:"required,max=30"` // e.g. tablet, bottle, vial
+	ReorderLevel    int       `json:"reorder_level" gorm:"not null;default:0" validate:"min=0"`
+	QuantityOnHand  int       `json:"quantity_on_hand" gorm:"not null;default:0"`
+	IsActive        bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relationships
+	FormularyItem *FormularyItem `json:"formulary_item,omitempty" gorm:"foreignKey:FormularyItemID;references:ID"`
+	Batches       []StockBatch   `json:"batches,omitempty" gorm:"foreignKey:StockItemID"`
+}
+
+// IsLowStock reports whether the item is at or below its reorder level
+func (s StockItem) IsLowStock() bool {
+	return s.QuantityOnHand <= s.ReorderLevel
+}
+
+// StockBatch is a lot of a stock item with its own expiry date
+type StockBatch struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	StockItemID      uint      `json:"stock_item_id" gorm:"not null;uniqueIndex:idx_stock_batch_lot"`
+	LotNumber        string    `json:"lot_number" gorm:"not null;size:50;uniqueIndex:idx_stock_batch_lot"`
+	ExpiryDate       time.Time `json:"expiry_date" gorm:"type:date;not null;index"`
+	QuantityReceived int       `json:"quantity_received" gorm:"not null"`
+	QuantityOnHand   int       `json:"quantity_on_hand" gorm:"not null"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relationships
+	StockItem *StockItem `json:"stock_item,omitempty" gorm:"foreignKey:StockItemID;references:ID"`
+}
+
+// IsExpired reports whether the batch is past its expiry date on the given day
+func (b StockBatch) IsExpired(on time.Time) bool {
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(b.ExpiryDate.Year(), b.ExpiryDate.Month(), b.ExpiryDate.Day(), 0, 0, 0, 0, time.UTC)
+	return expiry.Before(day)
+}
+
+// ExpiresWithin reports whether the batch expires within the given number of days
+func (b StockBatch) ExpiresWithin(on time.Time, days int) bool {
+	return b.IsExpired(on.AddDate(0, 0, days))
+}
+
+// StockReceipt records a delivery of stock into the clinic
+type StockReceipt struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	ClinicID         uint      `json:"clinic_id" gorm:"not null;index"`
+	Supplier         string    `json:"supplier" gorm:"size:255"`
+	ReferenceNumber  string    `json:"reference_number,omitempty" gorm:"size:100"` // delivery note or invoice number
+	ReceivedAt       time.Time `json:"received_at" gorm:"not null"`
+	Notes            string    `json:"notes,omitempty" gorm:"size:1000"`
+	ReceivedByUserID *uint     `json:"received_by_user_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+
+	// Relationships
+	Lines []StockReceiptLine `json:"lines,omitempty" gorm:"foreignKey:StockReceiptID"`
+}
+
+// StockReceiptLine is one batch received on a stock receipt
+type StockReceiptLine struct {
+	ID             uint `json:"id" gorm:"primaryKey"`
+	StockReceiptID uint `json:"stock_receipt_id" gorm:"not null;index"`
+	StockItemID    uint `json:"stock_item_id" gorm:"not null"`
+	StockBatchID   uint `json:"stock_batch_id" gorm:"not null"`
+	Quantity       int  `json:"quantity" gorm:"not null"`
+
+	// Relationships
+	StockItem  *StockItem  `json:"stock_item,omitempty" gorm:"foreignKey:StockItemID;references:ID"`
+	StockBatch *StockBatch `json:"stock_batch,omitempty" gorm:"foreignKey:StockBatchID;references:ID"`
+}
+
+// StockTransaction is an entry in the stock ledger. Quantity is signed: stock
+// in is positive, stock out negative. Balances are the item's and batch's
+// quantity on hand after the transaction.
+type StockTransaction struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	ClinicID          uint      `json:"clinic_id" gorm:"not null;index"`
+	StockItemID       uint      `json:"stock_item_id" gorm:"not null;index"`
+	StockBatchID      *uint     `json:"stock_batch_id,omitempty" gorm:"index"`
+	Type              string    `json:"type" gorm:"not null;size:20;index"`
+	Quantity          int       `json:"quantity" gorm:"not null"`
+	BalanceAfter      int       `json:"balance_after" gorm:"not null"`
+	BatchBalanceAfter *int      `json:"batch_balance_after,omitempty"`
+	Reason            string    `json:"reason,omitempty" gorm:"size:30"`
+	Notes             string    `json:"notes,omitempty" gorm:"size:500"`
+	ReferenceID       *uint     `json:"reference_id,omitempty"` // receipt ID for receipts, prescription ID for dispensing
+	UserID            *uint     `json:"user_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at" gorm:"index"`
+
+	// Relationships
+	StockItem  *StockItem  `json:"stock_item,omitempty" gorm:"foreignKey:StockItemID;references:ID"`
+	StockBatch *StockBatch `json:"stock_batch,omitempty" gorm:"foreignKey:StockBatchID;references:ID"`
+}
+
+// IsValidAdjustmentReason reports whether reason is a known adjustment reason
+func IsValidAdjustmentReason(reason string) bool {
+	for _, r := range StockAdjustmentReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExpiryDate accepts YYYY-MM-DD, or YYYY-MM as printed on many packs,
+// which is taken to mean the last day of that month
+func ParseExpiryDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01", value); err == nil {
+		return t.AddDate(0, 1, -1), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry date %q, use YYYY-MM-DD or YYYY-MM", value)
+}
+
+// ApplyStockChange returns the batch and item balances after a signed change,
+// rejecting changes that would take the batch below zero
+func ApplyStockChange(batchOnHand, itemOnHand, change int) (int, int, error) {
+	if change == 0 {
+		return batchOnHand, itemOnHand, errors.New("quantity change must not be zero")
+	}
+	if batchOnHand+change < 0 {
+		return batchOnHand, itemOnHand, fmt.Errorf("insufficient stock: batch has %d, change is %d", batchOnHand, change)
+	}
+	return batchOnHand + change, itemOnHand + change, nil
+}
+
+// Inventory DTOs
+type CreateStockItemRequest struct {
+	FormularyItemID *uint  `json:"formulary_item_id,omitempty"`
+	MedicationName  string `json:"medication_name" validate:"max=255"` // taken from the formulary item when set
+	Unit            string `json:"unit" validate:"required,max=30"`
+	ReorderLevel    int    `json:"reorder_level" validate:"min=0"`
+}
+
+type UpdateStockItemRequest struct {
+	Unit         *string `json:"unit,omitempty" validate:"omitempty,max=30"`
+	ReorderLevel *int    `json:"reorder_level,omitempty" validate:"omitempty,min=0"`
+	IsActive     *bool   `json:"is_active,omitempty"`
+}
+
+type ReceiveStockLine struct {
+	StockItemID uint   `json:"stock_item_id" validate:"required"`
+	LotNumber   string `json:"lot_number" validate:"required,max=50"`
+	ExpiryDate  string `json:"expiry_date" validate:"required"` // YYYY-MM-DD or YYYY-MM
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+}
+
+type ReceiveStockRequest struct {
+	Supplier        string             `json:"supplier" validate:"max=255"`
+	ReferenceNumber string             `json:"reference_number" validate:"max=100"`
+	ReceivedAt      string             `json:"received_at"` // YYYY-MM-DD, defaults to today
+	Notes           string             `json:"notes" validate:"max=1000"`
+	Lines           []ReceiveStockLine `json:"lines" validate:"required,min=1,dive"`
+}
+
+type StockAdjustmentRequest struct {
+	StockBatchID uint   `json:"stock_batch_id" validate:"required"`
+	Quantity     int    `json:"quantity" validate:"required"` // signed change, e.g. -5 for five damaged units
+	Reason       string `json:"reason" validate:"required"`
+	Notes        string `json:"notes" validate:"max=500"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpiryDate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2025-06-15", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), false},
+		{"2025-06", time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), false},
+		{"2024-02", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"06/2025", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseExpiryDate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStockBatchExpiry(t *testing.T) {
+	today := time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)
+	batch := StockBatch{ExpiryDate: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)}
+
+	if batch.IsExpired(today) {
+		t.Error("batch expiring today should still be usable today")
+	}
+	if !batch.IsExpired(today.AddDate(0, 0, 1)) {
+		t.Error("batch should be expired the day after its expiry date")
+	}
+
+	later := StockBatch{ExpiryDate: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}
+	if later.ExpiresWithin(today, 30) {
+		t.Error("batch expiring in 52 days is not within 30 days")
+	}
+	if !later.ExpiresWithin(today, 60) {
+		t.Error("batch expiring in 52 days is within 60 days")
+	}
+}
+
+func TestApplyStockChange(t *testing.T) {
+	batch, item, err := ApplyStockChange(10, 25, -4)
+	if err != nil || batch != 6 || item != 21 {
+		t.Errorf("got (%d, %d, %v), want (6, 21, nil)", batch, item, err)
+	}
+
+	if _, _, err := ApplyStockChange(3, 25, -4); err == nil {
+		t.Error("expected an error when removing more than the batch holds")
+	}
+	if _, _, err := ApplyStockChange(3, 25, 0); err == nil {
+		t.Error("expected an error for a zero change")
+	}
+}
+
+func TestStockItemIsLowStock(t *testing.T) {
+	if !(StockItem{QuantityOnHand: 20, ReorderLevel: 20}).IsLowStock() {
+		t.Error("item at its reorder level is low")
+	}
+	if (StockItem{QuantityOnHand: 21, ReorderLevel: 20}).IsLowStock() {
+		t.Error("item above its reorder level is not low")
+	}
+}
@@ -0,0 +1,544 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status of a lab order
+const (
+	LabOrderStatusOrdered   = "ordered"
+	LabOrderStatusCollected = "collected" // specimen taken, awaiting result
+	LabOrderStatusResulted  = "resulted"  // results recorded and final
+	LabOrderStatusCancelled = "cancelled"
+)
+
+// Lab order priorities
+const (
+	LabPriorityRoutine = "routine"
+	LabPriorityUrgent  = "urgent"
+)
+
+// Kinds of value a lab test component reports
+const (
+	LabValueNumeric = "numeric"
+	LabValueCoded   = "coded" // one of the component's options, e.g. negative or 2+
+	LabValueText    = "text"
+)
+
+// Flags set on a lab result against its reference range or normal options
+const (
+	LabFlagNormal       = "normal"
+	LabFlagLow          = "low"
+	LabFlagHigh         = "high"
+	LabFlagCriticalLow  = "critical_low"
+	LabFlagCriticalHigh = "critical_high"
+	LabFlagAbnormal     = "abnormal" // a coded value outside the normal options
+)
+
+// LabTest is a test in the lab catalog. Tests dropped from the catalog file
+// are deactivated rather than deleted so that existing orders keep their test.
+type LabTest struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"not null;size:20;uniqueIndex"` // e.g. MRDT, HB
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	Specimen  string    `json:"specimen,omitempty" gorm:"size:50"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	Components []LabTestComponent `json:"components,omitempty" gorm:"foreignKey:LabTestID"`
+}
+
+// LabTestComponent is one value a test reports, e.g. the protein line of a
+// urine dipstick
+type LabTestComponent struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	LabTestID     uint          `json:"lab_test_id" gorm:"not null;uniqueIndex:idx_lab_test_component"`
+	Code          string        `json:"code" gorm:"not null;size:30;uniqueIndex:idx_lab_test_component"`
+	Name          string        `json:"name" gorm:"not null;size:100"`
+	Position      int           `json:"position" gorm:"not null;default:0"`
+	ValueType     string        `json:"value_type" gorm:"not null;size:10"`
+	Unit          string        `json:"unit,omitempty" gorm:"size:20"`
+	Options       ClinicalFlags `json:"options,omitempty" gorm:"type:varchar(255)"`        // Coded values that can be reported
+	NormalOptions ClinicalFlags `json:"normal_options,omitempty" gorm:"type:varchar(255)"` // Coded values that are not flagged abnormal
+
+	// Relationships
+	Ranges []LabReferenceRange `json:"ranges,omitempty" gorm:"foreignKey:ComponentID"`
+}
+
+// LabReferenceRange is the normal range of a numeric component, optionally
+// for one gender or age band. The first range that matches a patient applies.
+type LabReferenceRange struct {
+	ID           uint     `json:"id" gorm:"primaryKey"`
+	ComponentID  uint     `json:"component_id" gorm:"not null;index"`
+	Gender       string   `json:"gender,omitempty" gorm:"size:10"` // Male, Female or empty for any
+	MinAgeDays   int      `json:"min_age_days" gorm:"not null;default:0"`
+	MaxAgeDays   *int     `json:"max_age_days,omitempty"` // Exclusive
+	Low          *float64 `json:"low,omitempty"`
+	High         *float64 `json:"high,omitempty"`
+	CriticalLow  *float64 `json:"critical_low,omitempty"`
+	CriticalHigh *float64 `json:"critical_high,omitempty"`
+}
+
+// LabOrder is a test ordered during a visit
+type LabOrder struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	VisitID            uint       `json:"visit_id" gorm:"not null;index"`
+	PatientID          uint       `json:"patient_id" gorm:"not null;index"`
+	ClinicID           uint       `json:"clinic_id" gorm:"not null;index:idx_lab_orders_clinic_status"`
+	LabTestID          uint       `json:"lab_test_id" gorm:"not null"`
+	Status             string     `json:"status" gorm:"not null;size:20;default:ordered;index:idx_lab_orders_clinic_status"`
+	Priority           string     `json:"priority" gorm:"not null;size:10;default:routine"`
+	ClinicalNotes      string     `json:"clinical_notes,omitempty" gorm:"size:500"`
+	OrderedByStaffID   uint       `json:"ordered_by_staff_id" gorm:"not null"`
+	OrderedAt          time.Time  `json:"ordered_at" gorm:"not null"`
+	CollectedByStaffID *uint      `json:"collected_by_staff_id,omitempty"`
+	CollectedAt        *time.Time `json:"collected_at,omitempty"`
+	ResultedByStaffID  *uint      `json:"resulted_by_staff_id,omitempty"`
+	ResultedAt         *time.Time `json:"resulted_at,omitempty"`
+	ResultNotes        string     `json:"result_notes,omitempty" gorm:"size:1000"`
+	Abnormal           bool       `json:"abnormal" gorm:"not null;default:false"`
+	Critical           bool       `json:"critical" gorm:"not null;default:false"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CancelReason       string     `json:"cancel_reason,omitempty" gorm:"size:500"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relationships
+	LabTest     *LabTest    `json:"lab_test,omitempty" gorm:"foreignKey:LabTestID;references:ID"`
+	Patient     *Patient    `json:"patient,omitempty" gorm:"foreignKey:PatientID;references:ID"`
+	OrderedBy   *Staff      `json:"ordered_by,omitempty" gorm:"foreignKey:OrderedByStaffID;references:ID"`
+	CollectedBy *Staff      `json:"collected_by,omitempty" gorm:"foreignKey:CollectedByStaffID;references:ID"`
+	ResultedBy  *Staff      `json:"resulted_by,omitempty" gorm:"foreignKey:ResultedByStaffID;references:ID"`
+	Results     []LabResult `json:"results,omitempty" gorm:"foreignKey:LabOrderID"`
+}
+
+// LabResult is one reported value of a lab order. The component's name, unit
+// and reference range are copied so results read the same after the catalog
+// changes.
+type LabResult struct {
+	ID             uint     `json:"id" gorm:"primaryKey"`
+	LabOrderID     uint     `json:"lab_order_id" gorm:"not null;index"`
+	ComponentCode  string   `json:"component_code" gorm:"not null;size:30"`
+	ComponentName  string   `json:"component_name" gorm:"not null;size:100"`
+	Position       int      `json:"position" gorm:"not null;default:0"`
+	ValueNumeric   *float64 `json:"value_numeric,omitempty"`
+	ValueText      string   `json:"value_text,omitempty" gorm:"size:500"`
+	Unit           string   `json:"unit,omitempty" gorm:"size:20"`
+	ReferenceRange string   `json:"reference_range,omitempty" gorm:"size:100"`
+	Flag           string   `json:"flag,omitempty" gorm:"size:20"`
+}
+
+// Normalize tidies the test's codes, numbers its components and validates them
+func (t *LabTest) Normalize() error {
+	t.Code = strings.ToUpper(strings.TrimSpace(t.Code))
+	t.Name = strings.TrimSpace(t.Name)
+	t.Specimen = strings.ToLower(strings.TrimSpace(t.Specimen))
+
+	if t.Code == "" || t.Name == "" {
+		return fmt.Errorf("code and name are required")
+	}
+	if len(t.Code) > 20 {
+		return fmt.Errorf("code %q is longer than 20 characters", t.Code)
+	}
+	if len(t.Components) == 0 {
+		return fmt.Errorf("%s has no components", t.Code)
+	}
+
+	seen := make(map[string]bool, len(t.Components))
+	for i := range t.Components {
+		comp := &t.Components[i]
+		comp.ID, comp.LabTestID = 0, 0
+		comp.Position = i + 1
+		if err := comp.normalize(); err != nil {
+			return fmt.Errorf("%s component %d: %w", t.Code, i+1, err)
+		}
+		if seen[comp.Code] {
+			return fmt.Errorf("%s component %s is listed twice", t.Code, comp.Code)
+		}
+		seen[comp.Code] = true
+	}
+	return nil
+}
+
+func (comp *LabTestComponent) normalize() error {
+	comp.Code = strings.ToLower(strings.TrimSpace(comp.Code))
+	comp.Name = strings.TrimSpace(comp.Name)
+	comp.ValueType = strings.ToLower(strings.TrimSpace(comp.ValueType))
+	comp.Unit = strings.TrimSpace(comp.Unit)
+
+	if comp.Code == "" || comp.Name == "" {
+		return fmt.Errorf("code and name are required")
+	}
+
+	options := ClinicalFlags{}
+	for _, option := range comp.Options {
+		option = strings.ToLower(strings.TrimSpace(option))
+		if option == "" || strings.Contains(option, ",") {
+			return fmt.Errorf("%s has an empty option or one containing a comma", comp.Code)
+		}
+		if !options.Has(option) {
+			options = append(options, option)
+		}
+	}
+	comp.Options = options
+	normal, err := NormalizeClinicalFlags(comp.NormalOptions, comp.Options)
+	if err != nil {
+		return fmt.Errorf("%s normal options: %w", comp.Code, err)
+	}
+	comp.NormalOptions = normal
+
+	switch comp.ValueType {
+	case LabValueNumeric:
+		if len(comp.Options) > 0 {
+			return fmt.Errorf("%s is numeric and cannot have options", comp.Code)
+		}
+	case LabValueCoded:
+		if len(comp.Options) == 0 {
+			return fmt.Errorf("%s is coded and needs options", comp.Code)
+		}
+	case LabValueText:
+		if len(comp.Options) > 0 {
+			return fmt.Errorf("%s is free text and cannot have options", comp.Code)
+		}
+	default:
+		return fmt.Errorf("%s has unknown value_type %q", comp.Code, comp.ValueType)
+	}
+	if comp.ValueType != LabValueNumeric && len(comp.Ranges) > 0 {
+		return fmt.Errorf("%s has reference ranges but is not numeric", comp.Code)
+	}
+
+	for i := range comp.Ranges {
+		r := &comp.Ranges[i]
+		r.ID, r.ComponentID = 0, 0
+		switch strings.ToLower(strings.TrimSpace(r.Gender)) {
+		case "":
+			r.Gender = ""
+		case "male":
+			r.Gender = "Male"
+		case "female":
+			r.Gender = "Female"
+		default:
+			return fmt.Errorf("%s range %d has unknown gender %q", comp.Code, i+1, r.Gender)
+		}
+		if r.MinAgeDays < 0 || (r.MaxAgeDays != nil && *r.MaxAgeDays <= r.MinAgeDays) {
+			return fmt.Errorf("%s range %d has an empty age band", comp.Code, i+1)
+		}
+		if r.Low == nil && r.High == nil {
+			return fmt.Errorf("%s range %d needs low or high", comp.Code, i+1)
+		}
+		if r.Low != nil && r.High != nil && *r.Low > *r.High {
+			return fmt.Errorf("%s range %d has low above high", comp.Code, i+1)
+		}
+		if r.CriticalLow != nil && r.Low != nil && *r.CriticalLow > *r.Low {
+			return fmt.Errorf("%s range %d has critical_low above low", comp.Code, i+1)
+		}
+		if r.CriticalHigh != nil && r.High != nil && *r.CriticalHigh < *r.High {
+			return fmt.Errorf("%s range %d has critical_high below high", comp.Code, i+1)
+		}
+	}
+	return nil
+}
+
+// LoadLabCatalog reads lab tests and their components from a JSON file
+func LoadLabCatalog(path string) ([]LabTest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLabCatalog(f)
+}
+
+// ParseLabCatalog parses a JSON object with a "tests" array
+func ParseLabCatalog(r io.Reader) ([]LabTest, error) {
+	var catalog struct {
+		Tests []LabTest `json:"tests"`
+	}
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(catalog.Tests))
+	for i := range catalog.Tests {
+		t := &catalog.Tests[i]
+		t.ID = 0
+		t.Active = true
+		if err := t.Normalize(); err != nil {
+			return nil, fmt.Errorf("test %d: %w", i+1, err)
+		}
+		if seen[t.Code] {
+			return nil, fmt.Errorf("test %s is listed twice", t.Code)
+		}
+		seen[t.Code] = true
+	}
+	return catalog.Tests, nil
+}
+
+// ReferenceRange returns the first range that applies to a patient of the
+// given gender and age, or nil when the component has none
+func (comp LabTestComponent) ReferenceRange(gender string, ageDays int) *LabReferenceRange {
+	for i := range comp.Ranges {
+		r := &comp.Ranges[i]
+		if r.Gender != "" && r.Gender != gender {
+			continue
+		}
+		if ageDays < r.MinAgeDays || (r.MaxAgeDays != nil && ageDays >= *r.MaxAgeDays) {
+			continue
+		}
+		return r
+	}
+	return nil
+}
+
+// String renders the range as it is printed on a report, e.g. "12-15.5"
+func (r LabReferenceRange) String() string {
+	switch {
+	case r.Low != nil && r.High != nil:
+		return fmt.Sprintf("%g-%g", *r.Low, *r.High)
+	case r.Low != nil:
+		return fmt.Sprintf(">= %g", *r.Low)
+	case r.High != nil:
+		return fmt.Sprintf("<= %g", *r.High)
+	}
+	return ""
+}
+
+// Flag classifies a value against the range, critical limits first
+func (r LabReferenceRange) Flag(value float64) string {
+	switch {
+	case r.CriticalLow != nil && value < *r.CriticalLow:
+		return LabFlagCriticalLow
+	case r.CriticalHigh != nil && value > *r.CriticalHigh:
+		return LabFlagCriticalHigh
+	case r.Low != nil && value < *r.Low:
+		return LabFlagLow
+	case r.High != nil && value > *r.High:
+		return LabFlagHigh
+	}
+	return LabFlagNormal
+}
+
+// Evaluate checks a reported value against the component and flags it using
+// the reference range for the patient's gender and age. Coded components
+// without normal options, and numeric components without a matching range,
+// are left unflagged.
+func (comp LabTestComponent) Evaluate(value string, gender string, ageDays int) (LabResult, error) {
+	result := LabResult{
+		ComponentCode: comp.Code,
+		ComponentName: comp.Name,
+		Position:      comp.Position,
+		Unit:          comp.Unit,
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return result, fmt.Errorf("%s needs a value", comp.Code)
+	}
+
+	switch comp.ValueType {
+	case LabValueNumeric:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return result, fmt.Errorf("%s must be a number", comp.Code)
+		}
+		if number < 0 {
+			return result, fmt.Errorf("%s cannot be negative", comp.Code)
+		}
+		result.ValueNumeric = &number
+		if r := comp.ReferenceRange(gender, ageDays); r != nil {
+			result.ReferenceRange = r.String()
+			result.Flag = r.Flag(number)
+		}
+	case LabValueCoded:
+		value = strings.ToLower(value)
+		if !comp.Options.Has(value) {
+			return result, fmt.Errorf("%s must be one of: %s", comp.Code, strings.Join(comp.Options, ", "))
+		}
+		result.ValueText = value
+		if len(comp.NormalOptions) > 0 {
+			result.ReferenceRange = strings.Join(comp.NormalOptions, ", ")
+			result.Flag = LabFlagAbnormal
+			if comp.NormalOptions.Has(value) {
+				result.Flag = LabFlagNormal
+			}
+		}
+	default:
+		if len(value) > 500 {
+			return result, fmt.Errorf("%s must be at most 500 characters", comp.Code)
+		}
+		result.ValueText = value
+	}
+	return result, nil
+}
+
+// IsCriticalLabFlag reports whether a result flag needs urgent attention
+func IsCriticalLabFlag(flag string) bool {
+	return flag == LabFlagCriticalLow || flag == LabFlagCriticalHigh
+}
+
+// CheckLabOrderTransition reports whether an order can move from one status
+// to another. Point-of-care tests can be resulted straight from ordered.
+func CheckLabOrderTransition(from, to string) error {
+	switch to {
+	case LabOrderStatusCollected:
+		if from == LabOrderStatusOrdered {
+			return nil
+		}
+	case LabOrderStatusResulted, LabOrderStatusCancelled:
+		if from == LabOrderStatusOrdered || from == LabOrderStatusCollected {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot change a %s order to %s", from, to)
+}
+
+// CreateLabOrdersRequest orders one or more catalog tests for a visit
+type CreateLabOrdersRequest struct {
+	TestCodes     []string `json:"test_codes"`
+	Priority      string   `json:"priority"`
+	ClinicalNotes string   `json:"clinical_notes"`
+}
+
+// Normalize upper-cases and de-duplicates the test codes and defaults the priority
+func (r *CreateLabOrdersRequest) Normalize() error {
+	codes := make([]string, 0, len(r.TestCodes))
+	for _, code := range r.TestCodes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return fmt.Errorf("test_codes must name at least one test")
+	}
+	r.TestCodes = codes
+
+	r.Priority = strings.ToLower(strings.TrimSpace(r.Priority))
+	if r.Priority == "" {
+		r.Priority = LabPriorityRoutine
+	}
+	if r.Priority != LabPriorityRoutine && r.Priority != LabPriorityUrgent {
+		return fmt.Errorf("priority must be routine or urgent")
+	}
+
+	r.ClinicalNotes = strings.TrimSpace(r.ClinicalNotes)
+	if len(r.ClinicalNotes) > 500 {
+		return fmt.Errorf("clinical_notes must be at most 500 characters")
+	}
+	return nil
+}
+
+// LabValue is a reported result value, sent as a JSON string or number
+type LabValue string
+
+func (v *LabValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = LabValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("value must be a string or number")
+	}
+	*v = LabValue(n.String())
+	return nil
+}
+
+// LabResultInput is one component's reported value
+type LabResultInput struct {
+	ComponentCode string   `json:"component_code"`
+	Value         LabValue `json:"value"`
+}
+
+// RecordLabResultsRequest reports the results of an order, which finalizes it
+type RecordLabResultsRequest struct {
+	Results []LabResultInput `json:"results"`
+	Notes   string           `json:"notes"`
+}
+
+// EvaluateLabResults checks the reported values against the test's components
+// and returns the results in component order, flagged for the patient's gender
+// and age on the day they are resulted. Components may be left out, but at
+// least one must be reported.
+func EvaluateLabResults(test LabTest, gender string, dob, on time.Time, inputs []LabResultInput) ([]LabResult, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("results must report at least one component")
+	}
+	ageDays := int(dateOnly(on).Sub(dateOnly(dob)).Hours() / 24)
+
+	components := make(map[string]LabTestComponent, len(test.Components))
+	for _, comp := range test.Components {
+		components[comp.Code] = comp
+	}
+
+	results := make([]LabResult, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for _, in := range inputs {
+		code := strings.ToLower(strings.TrimSpace(in.ComponentCode))
+		comp, ok := components[code]
+		if !ok {
+			return nil, fmt.Errorf("%s has no component %q", test.Code, in.ComponentCode)
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("%s is reported twice", code)
+		}
+		seen[code] = true
+
+		result, err := comp.Evaluate(string(in.Value), gender, ageDays)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Position < results[j].Position })
+	return results, nil
+}
+
+// SummarizeLabResults reports whether any result is flagged abnormal and
+// whether any is critical
+func SummarizeLabResults(results []LabResult) (abnormal, critical bool) {
+	for _, r := range results {
+		if r.Flag != "" && r.Flag != LabFlagNormal {
+			abnormal = true
+		}
+		if IsCriticalLabFlag(r.Flag) {
+			critical = true
+		}
+	}
+	return abnormal, critical
+}
+
+// CancelLabOrderRequest gives the reason an order is cancelled
+type CancelLabOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// LabWorklistEntry is a pending order on the clinic's lab worklist
+type LabWorklistEntry struct {
+	LabOrderID    uint       `json:"lab_order_id"`
+	VisitID       uint       `json:"visit_id"`
+	PatientID     uint       `json:"patient_id"`
+	MRN           *string    `json:"mrn,omitempty"`
+	FullName      string     `json:"full_name"`
+	TestCode      string     `json:"test_code"`
+	TestName      string     `json:"test_name"`
+	Specimen      string     `json:"specimen,omitempty"`
+	Status        string     `json:"status"`
+	Priority      string     `json:"priority"`
+	ClinicalNotes string     `json:"clinical_notes,omitempty"`
+	OrderedBy     string     `json:"ordered_by,omitempty"`
+	OrderedAt     time.Time  `json:"ordered_at"`
+	CollectedAt   *time.Time `json:"collected_at,omitempty"`
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLabCatalog(t *testing.T) {
+	tests, err := ParseLabCatalog(strings.NewReader(`{"tests": [
+		{"code": " hb ", "name": "Haemoglobin", "components": [
+			{"code": "HB", "name": "Haemoglobin", "value_type": "numeric", "unit": "g/dL", "ranges": [
+				{"gender": "female", "low": 12, "high": 15.5}
+			]}
+		]},
+		{"code": "NITRITE", "name": "Nitrite", "components": [
+			{"code": "nitrite", "name": "Nitrite", "value_type": "coded", "options": ["Negative", "Positive"], "normal_options": ["negative"]}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb := tests[0]
+	if hb.Code != "HB" || !hb.Active || hb.Components[0].Code != "hb" || hb.Components[0].Position != 1 || hb.Components[0].Ranges[0].Gender != "Female" {
+		t.Errorf("test = %+v, want normalized codes and gender", hb)
+	}
+	if opts := tests[1].Components[0].Options; len(opts) != 2 || opts[0] != "negative" {
+		t.Errorf("options = %v, want lowercased", opts)
+	}
+
+	bad := []string{
+		`{"tests": [{"code": "X", "name": "X", "components": []}]}`,
+		`{"tests": [{"code": "X", "name": "X", "components": [{"code": "a", "name": "A", "value_type": "coded"}]}]}`,
+		`{"tests": [{"code": "X", "name": "X", "components": [{"code": "a", "name": "A", "value_type": "coded", "options": ["neg"], "normal_options": ["negative"]}]}]}`,
+		`{"tests": [{"code": "X", "name": "X", "components": [{"code": "a", "name": "A", "value_type": "numeric", "ranges": [{"low": 5, "high": 1}]}]}]}`,
+		`{"tests": [{"code": "X", "name": "X", "components": [{"code": "a", "name": "A", "value_type": "text", "ranges": [{"low": 1}]}]}]}`,
+		`{"tests": [{"code": "X", "name": "X", "components": [{"code": "a", "name": "A", "value_type": "text"}, {"code": "A", "name": "A", "value_type": "text"}]}]}`,
+	}
+	for _, catalog := range bad {
+		if _, err := ParseLabCatalog(strings.NewReader(catalog)); err == nil {
+			t.Errorf("expected an error for %s", catalog)
+		}
+	}
+}
+
+func TestBundledLabCatalog(t *testing.T) {
+	f, err := os.Open("../../data/lab_tests.json")
+	if err != nil {
+		t.Skip("bundled catalog not found")
+	}
+	defer f.Close()
+
+	tests, err := ParseLabCatalog(f)
+	if err != nil {
+		t.Fatalf("bundled catalog does not parse: %v", err)
+	}
+	if len(tests) == 0 {
+		t.Error("bundled catalog has no tests")
+	}
+}
+
+func TestEvaluateLabResults(t *testing.T) {
+	hb := LabTest{Code: "HB", Components: []LabTestComponent{{
+		Code: "hb", Name: "Haemoglobin", Position: 1, ValueType: LabValueNumeric, Unit: "g/dL",
+		Ranges: []LabReferenceRange{
+			{MinAgeDays: 180, MaxAgeDays: intPtr(1826), Low: floatPtr(11), High: floatPtr(14), CriticalLow: floatPtr(7)},
+			{Gender: "Male", MinAgeDays: 5479, Low: floatPtr(13), High: floatPtr(17.5), CriticalLow: floatPtr(7)},
+			{MinAgeDays: 5479, Low: floatPtr(12), High: floatPtr(17.5), CriticalLow: floatPtr(7)},
+		},
+	}}}
+	on := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	adult := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		gender string
+		dob    time.Time
+		value  LabValue
+		flag   string
+		rng    string
+	}{
+		{"adult man low", "Male", adult, "12.5", LabFlagLow, "13-17.5"},
+		{"adult woman normal", "Female", adult, "12.5", LabFlagNormal, "12-17.5"},
+		{"severe anaemia", "Female", adult, "6.2", LabFlagCriticalLow, "12-17.5"},
+		{"child high", "Male", time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), "14.6", LabFlagHigh, "11-14"},
+		{"infant has no range", "Male", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "10", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := EvaluateLabResults(hb, tt.gender, tt.dob, on, []LabResultInput{{ComponentCode: "HB", Value: tt.value}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if results[0].Flag != tt.flag || results[0].ReferenceRange != tt.rng {
+				t.Errorf("flag %q range %q, want %q %q", results[0].Flag, results[0].ReferenceRange, tt.flag, tt.rng)
+			}
+		})
+	}
+
+	if _, err := EvaluateLabResults(hb, "Male", adult, on, []LabResultInput{{ComponentCode: "hb", Value: "low"}}); err == nil {
+		t.Error("expected an error for a non-numeric value")
+	}
+	if _, err := EvaluateLabResults(hb, "Male", adult, on, []LabResultInput{{ComponentCode: "wbc", Value: "5"}}); err == nil {
+		t.Error("expected an error for an unknown component")
+	}
+	if _, err := EvaluateLabResults(hb, "Male", adult, on, nil); err == nil {
+		t.Error("expected an error for no results")
+	}
+}
+
+func TestEvaluateCodedLabResults(t *testing.T) {
+	dipstick := LabTest{Code: "URINE", Components: []LabTestComponent{
+		{Code: "protein", Name: "Protein", Position: 1, ValueType: LabValueCoded, Options: ClinicalFlags{"negative", "trace", "1+", "2+"}, NormalOptions: ClinicalFlags{"negative", "trace"}},
+		{Code: "hcg", Name: "hCG", Position: 2, ValueType: LabValueCoded, Options: ClinicalFlags{"negative", "positive"}},
+	}}
+	dob := time.Date(1995, 1, 1, 0, 0, 0, 0, time.UTC)
+	on := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	results, err := EvaluateLabResults(dipstick, "Female", dob, on, []LabResultInput{
+		{ComponentCode: "hcg", Value: "Positive"},
+		{ComponentCode: "protein", Value: "2+"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ComponentCode != "protein" || results[0].Flag != LabFlagAbnormal || results[0].ReferenceRange != "negative, trace" {
+		t.Errorf("protein = %+v, want abnormal and listed first", results[0])
+	}
+	if results[1].ValueText != "positive" || results[1].Flag != "" {
+		t.Errorf("hcg = %+v, want unflagged", results[1])
+	}
+	if abnormal, critical := SummarizeLabResults(results); !abnormal || critical {
+		t.Errorf("abnormal %v critical %v, want abnormal only", abnormal, critical)
+	}
+
+	if _, err := EvaluateLabResults(dipstick, "Female", dob, on, []LabResultInput{{ComponentCode: "protein", Value: "5+"}}); err == nil {
+		t.Error("expected an error for a value outside the options")
+	}
+	if _, err := EvaluateLabResults(dipstick, "Female", dob, on, []LabResultInput{
+		{ComponentCode: "protein", Value: "trace"}, {ComponentCode: "protein", Value: "1+"},
+	}); err == nil {
+		t.Error("expected an error for a component reported twice")
+	}
+}
+
+func TestLabValueUnmarshal(t *testing.T) {
+	var in []LabResultInput
+	if err := json.Unmarshal([]byte(`[{"component_code": "hb", "value": 11.5}, {"component_code": "pf", "value": "negative"}]`), &in); err != nil {
+		t.Fatal(err)
+	}
+	if in[0].Value != "11.5" || in[1].Value != "negative" {
+		t.Errorf("values = %q %q", in[0].Value, in[1].Value)
+	}
+}
+
+func TestCheckLabOrderTransition(t *testing.T) {
+	allowed := [][2]string{
+		{LabOrderStatusOrdered, LabOrderStatusCollected},
+		{LabOrderStatusOrdered, LabOrderStatusResulted},
+		{LabOrderStatusCollected, LabOrderStatusResulted},
+		{LabOrderStatusCollected, LabOrderStatusCancelled},
+	}
+	for _, tr := range allowed {
+		if err := CheckLabOrderTransition(tr[0], tr[1]); err != nil {
+			t.Errorf("%s -> %s: %v", tr[0], tr[1], err)
+		}
+	}
+	denied := [][2]string{
+		{LabOrderStatusCollected, LabOrderStatusCollected},
+		{LabOrderStatusResulted, LabOrderStatusCancelled},
+		{LabOrderStatusCancelled, LabOrderStatusResulted},
+	}
+	for _, tr := range denied {
+		if err := CheckLabOrderTransition(tr[0], tr[1]); err == nil {
+			t.Errorf("%s -> %s should be refused", tr[0], tr[1])
+		}
+	}
+}
+
+func TestCreateLabOrdersRequest(t *testing.T) {
+	req := CreateLabOrdersRequest{TestCodes: []string{" mrdt", "HB", "MRDT", ""}}
+	if err := req.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.TestCodes) != 2 || req.TestCodes[0] != "MRDT" || req.Priority != LabPriorityRoutine {
+		t.Errorf("req = %+v", req)
+	}
+	if err := (&CreateLabOrdersRequest{}).Normalize(); err == nil {
+		t.Error("expected an error for no tests")
+	}
+	if err := (&CreateLabOrdersRequest{TestCodes: []string{"HB"}, Priority: "stat"}).Normalize(); err == nil {
+		t.Error("expected an error for an unknown priority")
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Auth event types
+const (
+	AuthEventLoginSuccess    = "login_success"
+	AuthEventLoginFailed     = "login_failed"
+	AuthEventMFAFailed       = "mfa_failed"
+	AuthEventLoginBlocked    = "login_blocked" // Attempt while the account was locked
+	AuthEventAccountLocked   = "account_locked"
+	AuthEventAccountUnlocked = "account_unlocked"
+)
+
+// AuthEvent is an append-only record of a sign-in attempt or lockout change.
+// UserID is empty when the email did not match an account.
+type AuthEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    *uint     `json:"user_id,omitempty" gorm:"index"`
+	Email     string    `json:"email" gorm:"size:255;index"`
+	EventType string    `json:"event_type" gorm:"not null;size:30;index"`
+	IPAddress string    `json:"ip_address" gorm:"size:45;index"`
+	UserAgent string    `json:"user_agent" gorm:"size:255"`
+	Detail    string    `json:"detail,omitempty" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// LockoutPolicy controls when repeated failed sign-ins lock an account. Each
+// lockout since the last successful sign-in doubles the duration, up to
+// MaxDuration.
+type LockoutPolicy struct {
+	MaxAttempts  int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+// Duration returns how long to lock an account that has already been locked
+// previousLockouts times since its last successful sign-in
+func (p LockoutPolicy) Duration(previousLockouts int) time.Duration {
+	d := p.BaseDuration
+	for i := 0; i < previousLockouts && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDuration)
+}
+
+// IsLocked reports whether the user is locked out at the given time
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyDuration(t *testing.T) {
+	policy := LockoutPolicy{MaxAttempts: 5, BaseDuration: 15 * time.Minute, MaxDuration: 2 * time.Hour}
+
+	tests := []struct {
+		previous int
+		want     time.Duration
+	}{
+		{0, 15 * time.Minute},
+		{1, 30 * time.Minute},
+		{2, time.Hour},
+		{3, 2 * time.Hour},
+		{4, 2 * time.Hour},
+		{100, 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := policy.Duration(tt.previous); got != tt.want {
+			t.Errorf("Duration(%d) = %s, want %s", tt.previous, got, tt.want)
+		}
+	}
+}
+
+func TestUserIsLocked(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute)
+	earlier := now.Add(-time.Minute)
+
+	if (&User{}).IsLocked(now) {
+		t.Error("user without a lockout should not be locked")
+	}
+	if !(&User{LockedUntil: &later}).IsLocked(now) {
+		t.Error("user should be locked until LockedUntil")
+	}
+	if (&User{LockedUntil: &earlier}).IsLocked(now) {
+		t.Error("lockout should lapse after LockedUntil")
+	}
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238, "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; the last six digits are the 6-digit code
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range tests {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("TOTPCode at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := TOTPCode(rfc6238Secret, now)
+
+	step, ok := VerifyTOTP(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("current code should verify")
+	}
+
+	if _, ok := VerifyTOTP(rfc6238Secret, code, now, step); ok {
+		t.Error("a code should not verify twice")
+	}
+
+	previous, _ := TOTPCode(rfc6238Secret, now.Add(-30*time.Second))
+	if _, ok := VerifyTOTP(rfc6238Secret, previous, now, 0); !ok {
+		t.Error("code from the previous period should verify to allow for clock drift")
+	}
+
+	stale, _ := TOTPCode(rfc6238Secret, now.Add(-5*time.Minute))
+	if _, ok := VerifyTOTP(rfc6238Secret, stale, now, 0); ok {
+		t.Error("code from five minutes ago should not verify")
+	}
+
+	if _, ok := VerifyTOTP(rfc6238Secret, "12345", now, 0); ok {
+		t.Error("short code should not verify")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret length = %d, want 32", len(secret))
+	}
+	if _, err := TOTPCode(secret, time.Now()); err != nil {
+		t.Errorf("generated secret does not produce codes: %v", err)
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("JBSWY3DPEHPK3PXP", "Rural Health", "dr.sharma@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Rural%20Health:dr.sharma@example.com?") {
+		t.Errorf("uri = %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Rural+Health", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("uri %s is missing %s", uri, part)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), RecoveryCodeCount)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q issued twice", code)
+		}
+		seen[code] = true
+		if got := NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))); got != code {
+			t.Errorf("NormalizeRecoveryCode = %q, want %q", got, code)
+		}
+	}
+}
@@ -11,12 +11,17 @@ type User struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Email     string         `json:"email" gorm:"not null;size:255;uniqueIndex" validate:"required,email"`
 	Password  string         `json:"-" gorm:"not null;size:255" validate:"required,min=8"`
-	UserType  string         `json:"user_type" gorm:"not null;size:20" validate:"required,oneof=patient clinic_staff doctor nurse admin"`
+	UserType  string         `json:"user_type" gorm:"not null;size:20" validate:"required,oneof=patient clinic_staff clinic_admin doctor nurse pharmacist admin"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Failed sign-in tracking, reset by a successful sign-in or an administrator
+	FailedLoginAttempts int        `json:"failed_login_attempts" gorm:"not null;default:0"`
+	LockoutCount        int        `json:"-" gorm:"not null;default:0"` // Lockouts since the last successful sign-in
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
 	// Relationships
 	PatientProfile *Patient `json:"patient_profile,omitempty" gorm:"foreignKey:UserID"`
 	ClinicProfile  *Clinic  `json:"clinic_profile,omitempty" gorm:"foreignKey:UserID"`
@@ -29,7 +34,8 @@ type Clinic struct {
 	Address       string         `json:"address" gorm:"not null;size:500" validate:"required,min=5,max=500"`
 	ContactNumber string         `json:"contact_number" gorm:"not null;size:20" validate:"required,min=10,max=20"`
 	District      string         `json:"district" gorm:"not null;size:100" validate:"required,min=2,max=100"`
-	UserID        *uint          `json:"user_id,omitempty" gorm:"index"` // Link to User for authentication
+	UserID        *uint          `json:"user_id,omitempty" gorm:"index"`            // Link to User for authentication
+	RequireMFA    bool           `json:"require_mfa" gorm:"not null;default:false"` // Staff logins must use an authenticator app
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
@@ -43,6 +49,7 @@ type Clinic struct {
 
 type Patient struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
+	MRN         *string        `json:"mrn,omitempty" gorm:"size:20;uniqueIndex"` // Medical record number, assigned on create
 	FullName    string         `json:"full_name" gorm:"not null;size:255" validate:"required,min=2,max=255"`
 	Gender      string         `json:"gender" gorm:"not null;size:10" validate:"required,oneof=Male Female Other"`
 	DateOfBirth time.Time      `json:"date_of_birth" gorm:"not null" validate:"required"`
@@ -55,9 +62,11 @@ type Patient struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	User   *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Clinic *Clinic `json:"clinic,omitempty" gorm:"foreignKey:ClinicID;references:ID"`
-	Visits []Visit `json:"visits,omitempty" gorm:"foreignKey:PatientID"`
+	User      *User            `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Clinic    *Clinic          `json:"clinic,omitempty" gorm:"foreignKey:ClinicID;references:ID"`
+	Visits    []Visit          `json:"visits,omitempty" gorm:"foreignKey:PatientID"`
+	Allergies []PatientAllergy `json:"allergies,omitempty" gorm:"foreignKey:PatientID"`
+	Problems  []PatientProblem `json:"problems,omitempty" gorm:"foreignKey:PatientID"`
 }
 
 type Staff struct {
@@ -91,12 +100,17 @@ type Visit struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// ReadOnly is set when the visit was recorded at another clinic or its patient has transferred away
+	ReadOnly bool `json:"read_only,omitempty" gorm:"-"`
+
 	// Relationships
 	Patient       *Patient       `json:"patient,omitempty" gorm:"foreignKey:PatientID;references:ID"`
 	Clinic        *Clinic        `json:"clinic,omitempty" gorm:"foreignKey:ClinicID;references:ID"`
 	Staff         *Staff         `json:"staff,omitempty" gorm:"foreignKey:StaffID;references:ID"`
 	Diagnoses     []Diagnosis    `json:"diagnoses,omitempty" gorm:"foreignKey:VisitID"`
 	Prescriptions []Prescription `json:"prescriptions,omitempty" gorm:"foreignKey:VisitID"`
+	VitalSigns    []VitalSigns   `json:"vital_signs,omitempty" gorm:"foreignKey:VisitID"`
+	LabOrders     []LabOrder     `json:"lab_orders,omitempty" gorm:"foreignKey:VisitID"`
 }
 
 type Diagnosis struct {
@@ -113,18 +127,30 @@ type Diagnosis struct {
 }
 
 type Prescription struct {
-	ID             uint           `json:"id" gorm:"primaryKey"`
-	VisitID        uint           `json:"visit_id" gorm:"not null" validate:"required"`
-	MedicationName string         `json:"medication_name" gorm:"not null;size:255" validate:"required,min=2,max=255"`
-	Dosage         string         `json:"dosage" gorm:"not null;size:100" validate:"required,min=2,max=100"`
-	Instructions   string         `json:"instructions" gorm:"not null;size:500" validate:"required,min=5,max=500"`
-	DurationDays   int            `json:"duration_days" gorm:"not null" validate:"required,min=1,max=365"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                        uint           `json:"id" gorm:"primaryKey"`
+	VisitID                   uint           `json:"visit_id" gorm:"not null" validate:"required"`
+	MedicationName            string         `json:"medication_name" gorm:"not null;size:255" validate:"required,min=2,max=255"`
+	Dosage                    string         `json:"dosage" gorm:"not null;size:100" validate:"required,min=2,max=100"`
+	Instructions              string         `json:"instructions" gorm:"not null;size:500" validate:"required,min=5,max=500"`
+	DurationDays              int            `json:"duration_days" gorm:"not null" validate:"required,min=1,max=365"`
+	FormularyItemID           *uint          `json:"formulary_item_id,omitempty" gorm:"index"`
+	DoseAmount                *float64       `json:"dose_amount,omitempty"`
+	DoseUnit                  string         `json:"dose_unit,omitempty" gorm:"size:20"`
+	Frequency                 string         `json:"frequency,omitempty" gorm:"size:10"`
+	Route                     string         `json:"route,omitempty" gorm:"size:30"`
+	Quantity                  *int           `json:"quantity,omitempty"`
+	AllergyOverrideReason     string         `json:"allergy_override_reason,omitempty" gorm:"size:500"`     // Why it was prescribed despite a recorded allergy
+	InteractionOverrideReason string         `json:"interaction_override_reason,omitempty" gorm:"size:500"` // Why it was prescribed despite a severe interaction
+	DispensingStatus          string         `json:"dispensing_status" gorm:"size:20;not null;default:not_dispensed;index"`
+	QuantityDispensed         int            `json:"quantity_dispensed" gorm:"not null;default:0"`
+	CreatedAt                 time.Time      `json:"created_at"`
+	UpdatedAt                 time.Time      `json:"updated_at"`
+	DeletedAt                 gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Visit *Visit `json:"visit,omitempty" gorm:"foreignKey:VisitID;references:ID"`
+	Visit         *Visit         `json:"visit,omitempty" gorm:"foreignKey:VisitID;references:ID"`
+	FormularyItem *FormularyItem `json:"formulary_item,omitempty" gorm:"foreignKey:FormularyItemID;references:ID"`
+	Dispensations []Dispensation `json:"dispensations,omitempty" gorm:"foreignKey:PrescriptionID"`
 }
 
 // Request/Response DTOs
@@ -171,11 +197,33 @@ type CreateDiagnosisRequest struct {
 }
 
 type CreatePrescriptionRequest struct {
-	VisitID        uint   `json:"visit_id" validate:"required"`
-	MedicationName string `json:"medication_name" validate:"required,min=2,max=255"`
-	Dosage         string `json:"dosage" validate:"required,min=2,max=100"`
-	Instructions   string `json:"instructions" validate:"required,min=5,max=500"`
-	DurationDays   int    `json:"duration_days" validate:"required,min=1,max=365"`
+	VisitID                   uint   `json:"visit_id" validate:"required"`
+	MedicationName            string `json:"medication_name" validate:"required,min=2,max=255"`
+	Dosage                    string `json:"dosage" validate:"required,min=2,max=100"`
+	Instructions              string `json:"instructions" validate:"required,min=5,max=500"`
+	DurationDays              int    `json:"duration_days" validate:"required,min=1,max=365"`
+	AllergyOverrideReason     string `json:"allergy_override_reason" validate:"max=500"`     // Required when a moderate or severe allergy matches
+	InteractionOverrideReason string `json:"interaction_override_reason" validate:"max=500"` // Required when a severe interaction is found
+
+	// Structured dosing. When FormularyItemID is set the medication name, dosage
+	// and instructions are rendered from these fields.
+	FormularyItemID *uint    `json:"formulary_item_id,omitempty"`
+	DoseAmount      *float64 `json:"dose_amount,omitempty"`
+	DoseUnit        string   `json:"dose_unit,omitempty" validate:"max=20"`
+	Frequency       string   `json:"frequency,omitempty" validate:"max=10"`
+	Route           string   `json:"route,omitempty" validate:"max=30"`
+	Quantity        *int     `json:"quantity,omitempty" validate:"omitempty,min=1"`
+}
+
+// UpdatePrescriptionRequest changes a free-text prescription. Changing the
+// medication runs the allergy and interaction checks again.
+type UpdatePrescriptionRequest struct {
+	MedicationName            *string `json:"medication_name,omitempty" validate:"omitempty,min=2,max=255"`
+	Dosage                    *string `json:"dosage,omitempty" validate:"omitempty,min=2,max=100"`
+	Instructions              *string `json:"instructions,omitempty" validate:"omitempty,min=5,max=500"`
+	DurationDays              *int    `json:"duration_days,omitempty" validate:"omitempty,min=1,max=365"`
+	AllergyOverrideReason     string  `json:"allergy_override_reason" validate:"max=500"`     // Required when the new medication matches a moderate or severe allergy
+	InteractionOverrideReason string  `json:"interaction_override_reason" validate:"max=500"` // Required when the new medication has a severe interaction
 }
 
 type PaginationResponse struct {
@@ -235,9 +283,11 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token    string      `json:"token"`
-	UserType string      `json:"user_type"`
-	User     interface{} `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+	ExpiresIn    int64       `json:"expires_in,omitempty"` // Access token lifetime in seconds
+	UserType     string      `json:"user_type"`
+	User         interface{} `json:"user"`
 }
 
 type ChangePasswordRequest struct {
@@ -246,6 +296,7 @@ type ChangePasswordRequest struct {
 }
 
 type JWTClaims struct {
+	SessionID uint    `json:"sid"`
 	UserID    uint    `json:"user_id"`
 	Email     string  `json:"email"`
 	UserType  string  `json:"user_type"`
@@ -253,7 +304,10 @@ type JWTClaims struct {
 	ClinicID  *uint   `json:"clinic_id,omitempty"`
 	StaffID   *uint   `json:"staff_id,omitempty"`
 	StaffRole *string `json:"staff_role,omitempty"`
-	Exp       int64   `json:"exp"`
+	// Set when the clinic requires MFA and the user has not enrolled; the
+	// token is then only accepted on the /auth endpoints
+	MFAEnrollmentRequired bool  `json:"mfa_enrollment_required,omitempty"`
+	Exp                   int64 `json:"exp"`
 }
 
 // Role-based permissions structure
@@ -295,14 +349,37 @@ const (
 	PermissionManageInventory Permission = "manage_inventory"
 )
 
-// Role definitions with their permissions
+// AllPermissions lists every permission that can be granted to a role
+var AllPermissions = []Permission{
+	PermissionCreatePatient, PermissionUpdatePatient, PermissionViewPatient, PermissionDeletePatient,
+	PermissionCreateStaff, PermissionUpdateStaff, PermissionViewStaff, PermissionDeleteStaff,
+	PermissionCreateVisit, PermissionUpdateVisit, PermissionViewVisit, PermissionDeleteVisit,
+	PermissionCreateDiagnosis, PermissionUpdateDiagnosis, PermissionViewDiagnosis, PermissionDeleteDiagnosis,
+	PermissionCreatePrescription, PermissionUpdatePrescription, PermissionViewPrescription, PermissionDeletePrescription,
+	PermissionManageClinic, PermissionViewReports, PermissionManageInventory,
+}
+
+// IsValidPermission reports whether p is a known permission
+func IsValidPermission(p Permission) bool {
+	for _, known := range AllPermissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Role definitions with their permissions. These are the defaults seeded into the
+// roles tables; at runtime permissions are resolved from the database. A
+// permission added to an existing role must also be listed in
+// AddedRolePermissions to reach databases that were already seeded.
 var RolePermissions = map[string][]Permission{
 	"clinic_staff": {
 		PermissionCreatePatient, PermissionUpdatePatient, PermissionViewPatient, PermissionDeletePatient,
 		PermissionCreateStaff, PermissionUpdateStaff, PermissionViewStaff, PermissionDeleteStaff,
 		PermissionCreateVisit, PermissionUpdateVisit, PermissionViewVisit, PermissionDeleteVisit,
 		PermissionViewDiagnosis, PermissionViewPrescription,
-		PermissionManageClinic, PermissionViewReports,
+		PermissionManageClinic, PermissionViewReports, PermissionManageInventory,
 	},
 	"doctor": {
 		PermissionViewPatient,
@@ -318,12 +395,26 @@ var RolePermissions = map[string][]Permission{
 		PermissionCreateVisit, PermissionUpdateVisit, PermissionViewVisit,
 		PermissionViewDiagnosis, PermissionViewPrescription,
 	},
+	"pharmacist": {
+		PermissionViewPatient,
+		PermissionViewVisit,
+		PermissionViewPrescription,
+		PermissionManageInventory,
+	},
+	"clinic_admin": {
+		PermissionCreatePatient, PermissionUpdatePatient, PermissionViewPatient,
+		PermissionCreateStaff, PermissionUpdateStaff, PermissionViewStaff,
+		PermissionCreateVisit, PermissionUpdateVisit, PermissionViewVisit,
+		PermissionViewDiagnosis, PermissionViewPrescription,
+		PermissionViewReports,
+	},
 }
 
 // Permission check helper function
 func HasPermission(userType string, staffRole *string, permission Permission) bool {
 	// For staff users, check their specific role permissions
-	if userType == "clinic_staff" || userType == "doctor" || userType == "nurse" {
+	if userType == "clinic_staff" || userType == "doctor" || userType == "nurse" ||
+		userType == "pharmacist" || userType == "clinic_admin" {
 		var roleKey string
 		if userType == "clinic_staff" {
 			roleKey = "clinic_staff"
@@ -347,12 +438,13 @@ func HasPermission(userType string, staffRole *string, permission Permission) bo
 type ClinicLoginType string
 
 const (
-	ClinicLoginStaff   ClinicLoginType = "staff"
-	ClinicLoginMedical ClinicLoginType = "medical"
+	ClinicLoginStaff    ClinicLoginType = "staff"
+	ClinicLoginMedical  ClinicLoginType = "medical"
+	ClinicLoginPharmacy ClinicLoginType = "pharmacy"
 )
 
 type ClinicLoginRequest struct {
 	Email     string          `json:"email" validate:"required,email"`
 	Password  string          `json:"password" validate:"required"`
-	LoginType ClinicLoginType `json:"login_type" validate:"required,oneof=staff medical"`
+	LoginType ClinicLoginType `json:"login_type" validate:"required,oneof=staff medical pharmacy"`
 }
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// mrnAlphabet is the character set of the Luhn mod 36 check character
+const mrnAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// mrnSearchPattern matches a record number, or the start of one, once its
+// separators are removed: the district prefix and at least one digit
+var mrnSearchPattern = regexp.MustCompile(`^[A-Z]{3}[0-9]+[0-9A-Z]?$`)
+
+// MRNSequence holds the last number issued for a medical record number prefix
+type MRNSequence struct {
+	Prefix    string `gorm:"primaryKey;size:3"`
+	LastValue uint   `gorm:"not null"`
+}
+
+// BeforeCreate gives every new patient a medical record number, whichever
+// path creates it
+func (p *Patient) BeforeCreate(tx *gorm.DB) error {
+	if p.MRN != nil && *p.MRN != "" {
+		return nil
+	}
+	mrn, err := NextMRN(tx.Session(&gorm.Session{NewDB: true}), p.ClinicID)
+	if err != nil {
+		return err
+	}
+	p.MRN = &mrn
+	return nil
+}
+
+// NextMRN issues the next medical record number for a clinic. The sequence is
+// per district prefix and is bumped in the caller's transaction, so a failed
+// registration does not use up a number.
+func NextMRN(tx *gorm.DB, clinicID uint) (string, error) {
+	var district string
+	if err := tx.Model(&Clinic{}).Select("district").Where("id = ?", clinicID).Scan(&district).Error; err != nil {
+		return "", err
+	}
+	prefix := MRNPrefix(district)
+
+	var seq uint
+	if err := tx.Raw(`INSERT INTO mrn_sequences (prefix, last_value) VALUES (?, 1)
+		ON CONFLICT (prefix) DO UPDATE SET last_value = mrn_sequences.last_value + 1
+		RETURNING last_value`, prefix).Scan(&seq).Error; err != nil {
+		return "", err
+	}
+	return FormatMRN(prefix, seq), nil
+}
+
+// MRNPrefix is the first three letters of a district, padded with X
+func MRNPrefix(district string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(district) {
+		if r <= unicode.MaxASCII && unicode.IsLetter(r) {
+			b.WriteRune(r)
+			if b.Len() == 3 {
+				break
+			}
+		}
+	}
+	for b.Len() < 3 {
+		b.WriteByte('X')
+	}
+	return b.String()
+}
+
+// FormatMRN builds a record number such as KTM-000123-4 from a prefix and
+// sequence number
+func FormatMRN(prefix string, seq uint) string {
+	number := fmt.Sprintf("%06d", seq)
+	return prefix + "-" + number + "-" + string(mrnCheckChar(prefix+number))
+}
+
+// NormalizeMRN puts a record number typed by hand into its stored form,
+// accepting lower case and missing or extra separators
+func NormalizeMRN(value string) string {
+	compact := strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, value)
+	if len(compact) < 5 {
+		return compact
+	}
+	return compact[:3] + "-" + compact[3:len(compact)-1] + "-" + compact[len(compact)-1:]
+}
+
+// LooksLikeMRN reports whether a search term is a record number or the start
+// of one, rather than a name or phone fragment
+func LooksLikeMRN(value string) bool {
+	return mrnSearchPattern.MatchString(strings.ReplaceAll(NormalizeMRN(value), "-", ""))
+}
+
+// ValidMRN reports whether a record number is well formed and its check
+// character matches, catching most misread or transposed characters
+func ValidMRN(value string) bool {
+	parts := strings.Split(NormalizeMRN(value), "-")
+	if len(parts) != 3 || len(parts[0]) != 3 || len(parts[1]) < 6 || len(parts[2]) != 1 {
+		return false
+	}
+	for _, r := range parts[0] {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	for _, r := range parts[1] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return mrnCheckChar(parts[0]+parts[1]) == parts[2][0]
+}
+
+// mrnCheckChar computes the Luhn mod 36 check character of an upper-case
+// alphanumeric payload
+func mrnCheckChar(payload string) byte {
+	n := len(mrnAlphabet)
+	sum := 0
+	double := true
+	for i := len(payload) - 1; i >= 0; i-- {
+		value := strings.IndexByte(mrnAlphabet, payload[i])
+		if double {
+			value *= 2
+		}
+		sum += value/n + value%n
+		double = !double
+	}
+	return mrnAlphabet[(n-sum%n)%n]
+}
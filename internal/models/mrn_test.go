@@ -0,0 +1,91 @@
+package models
+
+import "testing"
+
+func TestMRNPrefix(t *testing.T) {
+	tests := map[string]string{
+		"Kathmandu": "KAT",
+		"  dolakha": "DOL",
+		"Ilam":      "ILA",
+		"Jh":        "JHX",
+		"":          "XXX",
+	}
+	for district, want := range tests {
+		if got := MRNPrefix(district); got != want {
+			t.Errorf("MRNPrefix(%q) = %q, want %q", district, got, want)
+		}
+	}
+}
+
+func TestFormatMRN(t *testing.T) {
+	mrn := FormatMRN("KAT", 123)
+	if len(mrn) != len("KAT-000123-0") || mrn[:11] != "KAT-000123-" {
+		t.Fatalf("FormatMRN = %q, want KAT-000123-<check>", mrn)
+	}
+	if !ValidMRN(mrn) {
+		t.Errorf("ValidMRN(%q) = false, want true", mrn)
+	}
+	if got := FormatMRN("KAT", 1234567); got[:12] != "KAT-1234567-" {
+		t.Errorf("FormatMRN with a long sequence = %q", got)
+	}
+}
+
+func TestValidMRNDetectsErrors(t *testing.T) {
+	mrn := FormatMRN("DOL", 48213)
+
+	if !ValidMRN(" dol 048213 " + mrn[len(mrn)-1:]) {
+		t.Errorf("hand-typed form of %q should be valid", mrn)
+	}
+
+	// Every single-character substitution in the number must be caught
+	for i := 4; i < 10; i++ {
+		for d := byte('0'); d <= '9'; d++ {
+			if d == mrn[i] {
+				continue
+			}
+			typo := mrn[:i] + string(d) + mrn[i+1:]
+			if ValidMRN(typo) {
+				t.Errorf("ValidMRN(%q) = true for a mistyped %q", typo, mrn)
+			}
+		}
+	}
+
+	// As must adjacent transpositions
+	for i := 4; i < 9; i++ {
+		if mrn[i] == mrn[i+1] {
+			continue
+		}
+		swapped := mrn[:i] + string(mrn[i+1]) + string(mrn[i]) + mrn[i+2:]
+		if ValidMRN(swapped) {
+			t.Errorf("ValidMRN(%q) = true for transposed %q", swapped, mrn)
+		}
+	}
+
+	for _, bad := range []string{"", "DOL-048213", "D0L-048213-1", "DOL-04821A-1", "DOL-0482-1"} {
+		if ValidMRN(bad) {
+			t.Errorf("ValidMRN(%q) = true, want false", bad)
+		}
+	}
+}
+
+func TestNormalizeMRN(t *testing.T) {
+	if got := NormalizeMRN("kat 000123 x"); got != "KAT-000123-X" {
+		t.Errorf("NormalizeMRN = %q, want KAT-000123-X", got)
+	}
+	if got := NormalizeMRN("KAT-000123-X"); got != "KAT-000123-X" {
+		t.Errorf("NormalizeMRN of stored form = %q, want unchanged", got)
+	}
+}
+
+func TestLooksLikeMRN(t *testing.T) {
+	for _, term := range []string{"KAT-000123-X", "kat 000123 x", "kat0001", "KAT-0001"} {
+		if !LooksLikeMRN(term) {
+			t.Errorf("LooksLikeMRN(%q) = false, want true", term)
+		}
+	}
+	for _, term := range []string{"", "kat", "ram", "123", "9841", "Ram Bahadur", "ka1"} {
+		if LooksLikeMRN(term) {
+			t.Errorf("LooksLikeMRN(%q) = true, want false", term)
+		}
+	}
+}
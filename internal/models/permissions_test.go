@@ -75,6 +75,55 @@ func TestHasPermission(t *testing.T) {
 			permission: PermissionManageClinic,
 			expected:   false,
 		},
+		{
+			name:       "Pharmacist can view prescriptions",
+			userType:   "pharmacist",
+			staffRole:  nil,
+			permission: PermissionViewPrescription,
+			expected:   true,
+		},
+		{
+			name:       "Pharmacist can manage inventory",
+			userType:   "pharmacist",
+			staffRole:  nil,
+			permission: PermissionManageInventory,
+			expected:   true,
+		},
+		{
+			name:       "Pharmacist cannot create prescriptions",
+			userType:   "pharmacist",
+			staffRole:  nil,
+			permission: PermissionCreatePrescription,
+			expected:   false,
+		},
+		{
+			name:       "Clinic admin can create patients",
+			userType:   "clinic_admin",
+			staffRole:  nil,
+			permission: PermissionCreatePatient,
+			expected:   true,
+		},
+		{
+			name:       "Clinic admin can create staff",
+			userType:   "clinic_admin",
+			staffRole:  nil,
+			permission: PermissionCreateStaff,
+			expected:   true,
+		},
+		{
+			name:       "Clinic admin cannot manage clinic",
+			userType:   "clinic_admin",
+			staffRole:  nil,
+			permission: PermissionManageClinic,
+			expected:   false,
+		},
+		{
+			name:       "Patient has no staff permissions",
+			userType:   "patient",
+			staffRole:  nil,
+			permission: PermissionViewPatient,
+			expected:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -136,3 +185,17 @@ func contains(perms []Permission, target Permission) bool {
 	}
 	return false
 }
+
+func TestAddedRolePermissionsAreDefaults(t *testing.T) {
+	for _, grant := range AddedRolePermissions {
+		found := false
+		for _, p := range RolePermissions[grant.RoleName] {
+			if p == grant.Permission {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s is granted to existing %s roles but is not one of its defaults", grant.Permission, grant.RoleName)
+		}
+	}
+}
@@ -0,0 +1,605 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pregnancy statuses
+const (
+	PregnancyStatusActive    = "active"
+	PregnancyStatusDelivered = "delivered" // live birth or stillbirth
+	PregnancyStatusEnded     = "ended"     // miscarriage, abortion or ectopic pregnancy
+)
+
+// Pregnancy outcomes
+const (
+	PregnancyOutcomeLiveBirth   = "live_birth"
+	PregnancyOutcomeStillbirth  = "stillbirth"
+	PregnancyOutcomeMiscarriage = "miscarriage"
+	PregnancyOutcomeAbortion    = "abortion"
+	PregnancyOutcomeEctopic     = "ectopic"
+)
+
+// Risk flags worked out from the patient's age, obstetric history and
+// antenatal measurements
+const (
+	RiskAdolescent              = "adolescent"            // under 18 at LMP
+	RiskAdvancedMaternalAge     = "advanced_maternal_age" // 35 or over at LMP
+	RiskGrandMultipara          = "grand_multipara"       // five or more previous births
+	RiskHypertension            = "hypertension"          // 140/90 or above
+	RiskPreeclampsia            = "preeclampsia"          // hypertension with proteinuria after 20 weeks
+	RiskAnemia                  = "anemia"                // Hb below 11 g/dL
+	RiskSevereAnemia            = "severe_anemia"         // Hb below 7 g/dL
+	RiskAbnormalFetalHeartRate  = "abnormal_fetal_heart_rate"
+	RiskMalpresentationAtTerm   = "malpresentation_at_term" // not cephalic from 36 weeks
+	RiskPreviousCesarean        = "previous_cesarean"
+	RiskPreviousStillbirth      = "previous_stillbirth"
+	RiskPreviousPPH             = "previous_pph"
+	RiskPreviousPreeclampsia    = "previous_preeclampsia"
+	RiskMultiplePregnancy       = "multiple_pregnancy"
+	RiskChronicHypertension     = "chronic_hypertension"
+	RiskDiabetes                = "diabetes"
+	RiskHIV                     = "hiv"
+	RiskHeartDisease            = "heart_disease"
+	RiskAntepartumHemorrhage    = "antepartum_hemorrhage"
+	RiskShortStature            = "short_stature" // under 145 cm
+	RiskPreviousPretermDelivery = "previous_preterm_delivery"
+)
+
+// ReportedPregnancyRisks are the risk flags a clinician records from the
+// history or examination; the rest are worked out by AssessPregnancyRisk
+var ReportedPregnancyRisks = []string{
+	RiskPreviousCesarean, RiskPreviousStillbirth, RiskPreviousPPH, RiskPreviousPreeclampsia,
+	RiskPreviousPretermDelivery, RiskMultiplePregnancy, RiskChronicHypertension, RiskDiabetes,
+	RiskHIV, RiskHeartDisease, RiskAntepartumHemorrhage, RiskShortStature,
+}
+
+// PregnancyTermDays is the length of a pregnancy from the first day of the
+// last menstrual period to the estimated due date (Naegele's rule)
+const PregnancyTermDays = 280
+
+// MaxGestationalAgeDays is the longest plausible gestation; an LMP further
+// back than this is a data entry error
+const MaxGestationalAgeDays = 44 * 7
+
+// ANCContactWeeks are the gestational ages, in weeks, of the eight antenatal
+// contacts in the WHO 2016 ANC model
+var ANCContactWeeks = []int{12, 20, 26, 30, 34, 36, 38, 40}
+
+// ANCGraceDays is how long after its due date an antenatal contact counts as overdue
+const ANCGraceDays = 14
+
+// PostnatalPeriodDays is the length of the postnatal period after delivery
+const PostnatalPeriodDays = 42
+
+var (
+	DeliveryModes        = []string{"vaginal", "assisted_vaginal", "cesarean"}
+	DeliveryPlaces       = []string{"facility", "home", "in_transit", "other"}
+	FetalPresentations   = []string{"cephalic", "breech", "transverse", "unknown"}
+	UrineProteinResults  = []string{"negative", "trace", "1+", "2+", "3+", "4+"}
+	PostnatalDangerSigns = []string{
+		"heavy_bleeding", "fever", "foul_discharge", "severe_headache", "convulsions",
+		"breathing_difficulty", "breast_problem", "calf_pain", "depression",
+	}
+)
+
+// ClinicalFlags is a set of flags such as risk factors or danger signs
+type ClinicalFlags = StringSet
+
+// NormalizeClinicalFlags lowercases and de-duplicates flags, rejecting any
+// not in allowed
+func NormalizeClinicalFlags(flags []string, allowed []string) (ClinicalFlags, error) {
+	normalized := ClinicalFlags{}
+	for _, flag := range flags {
+		flag = strings.ToLower(strings.TrimSpace(flag))
+		if !slices.Contains(allowed, flag) {
+			return nil, fmt.Errorf("unknown flag %q", flag)
+		}
+		if !normalized.Has(flag) {
+			normalized = append(normalized, flag)
+		}
+	}
+	return normalized, nil
+}
+
+// Pregnancy is one pregnancy episode of a patient, from registration through
+// antenatal care to its outcome and the postnatal period. RiskFlags, HighRisk
+// and NextANCDue are derived and kept up to date by Refresh.
+type Pregnancy struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	PatientID     uint          `json:"patient_id" gorm:"not null;index"`
+	ClinicID      uint          `json:"clinic_id" gorm:"not null;index"` // Clinic the pregnancy was registered at
+	LMP           time.Time     `json:"lmp" gorm:"not null;type:date"`   // First day of the last menstrual period
+	EDD           time.Time     `json:"edd" gorm:"not null;type:date"`   // Estimated due date
+	Gravida       int           `json:"gravida" gorm:"not null"`         // Pregnancies including this one
+	Parity        int           `json:"parity" gorm:"not null"`          // Previous births at 28 weeks or later
+	Status        string        `json:"status" gorm:"not null;size:20;default:active;index"`
+	ReportedRisks ClinicalFlags `json:"reported_risks" gorm:"type:varchar(500)"`
+	RiskFlags     ClinicalFlags `json:"risk_flags" gorm:"type:varchar(500)"`
+	HighRisk      bool          `json:"high_risk" gorm:"not null;default:false;index"`
+	NextANCDue    *time.Time    `json:"next_anc_due,omitempty" gorm:"type:date;index"`
+	Notes         string        `json:"notes,omitempty" gorm:"size:500"`
+
+	// Outcome, set once the pregnancy ends
+	OutcomeDate            *time.Time `json:"outcome_date,omitempty" gorm:"type:date"`
+	Outcome                string     `json:"outcome,omitempty" gorm:"size:20"`
+	GestationAtOutcomeDays *int       `json:"gestation_at_outcome_days,omitempty"`
+	DeliveryMode           string     `json:"delivery_mode,omitempty" gorm:"size:20"`
+	DeliveryPlace          string     `json:"delivery_place,omitempty" gorm:"size:20"`
+	LiveBirths             int        `json:"live_births"`
+	Stillbirths            int        `json:"stillbirths"`
+	BirthWeightGrams       *int       `json:"birth_weight_grams,omitempty"` // First baby's weight
+	MaternalComplications  string     `json:"maternal_complications,omitempty" gorm:"size:500"`
+
+	RegisteredByUserID      *uint     `json:"registered_by_user_id,omitempty"`
+	OutcomeRecordedByUserID *uint     `json:"outcome_recorded_by_user_id,omitempty"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+
+	// Relationships
+	Patient           *Patient           `json:"patient,omitempty" gorm:"foreignKey:PatientID;references:ID"`
+	AntenatalContacts []AntenatalContact `json:"antenatal_contacts,omitempty" gorm:"foreignKey:PregnancyID"`
+	PostnatalVisits   []PostnatalVisit   `json:"postnatal_visits,omitempty" gorm:"foreignKey:PregnancyID"`
+}
+
+// AntenatalContact is an antenatal check-up during a pregnancy, optionally
+// tied to the visit it was recorded in
+type AntenatalContact struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	PregnancyID        uint      `json:"pregnancy_id" gorm:"not null;index"`
+	PatientID          uint      `json:"patient_id" gorm:"not null;index"`
+	ClinicID           uint      `json:"clinic_id" gorm:"not null;index"`
+	VisitID            *uint     `json:"visit_id,omitempty" gorm:"index"`
+	ContactDate        time.Time `json:"contact_date" gorm:"not null;type:date"`
+	GestationalAgeDays int       `json:"gestational_age_days" gorm:"not null"`
+	GestationalAge     string    `json:"gestational_age" gorm:"-"` // e.g. 32w4d
+	WeightKg           *float64  `json:"weight_kg,omitempty"`
+	SystolicBP         *int      `json:"systolic_bp,omitempty"`
+	DiastolicBP        *int      `json:"diastolic_bp,omitempty"`
+	FundalHeightCm     *float64  `json:"fundal_height_cm,omitempty"`
+	FetalHeartRate     *int      `json:"fetal_heart_rate,omitempty"` // beats per minute
+	FetalPresentation  string    `json:"fetal_presentation,omitempty" gorm:"size:20"`
+	HemoglobinGdL      *float64  `json:"hemoglobin_g_dl,omitempty"`
+	UrineProtein       string    `json:"urine_protein,omitempty" gorm:"size:10"`
+	IronFolicAcidGiven bool      `json:"iron_folic_acid_given"`
+	Notes              string    `json:"notes,omitempty" gorm:"size:500"`
+	RecordedByStaffID  *uint     `json:"recorded_by_staff_id,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+
+	// Relationships
+	RecordedBy *Staff `json:"recorded_by,omitempty" gorm:"foreignKey:RecordedByStaffID;references:ID"`
+}
+
+// PostnatalVisit is a check-up of the mother within the postnatal period
+type PostnatalVisit struct {
+	ID                       uint          `json:"id" gorm:"primaryKey"`
+	PregnancyID              uint          `json:"pregnancy_id" gorm:"not null;index"`
+	PatientID                uint          `json:"patient_id" gorm:"not null;index"`
+	ClinicID                 uint          `json:"clinic_id" gorm:"not null;index"`
+	VisitID                  *uint         `json:"visit_id,omitempty" gorm:"index"`
+	VisitDate                time.Time     `json:"visit_date" gorm:"not null;type:date"`
+	DaysAfterDelivery        int           `json:"days_after_delivery" gorm:"not null"`
+	SystolicBP               *int          `json:"systolic_bp,omitempty"`
+	DiastolicBP              *int          `json:"diastolic_bp,omitempty"`
+	TemperatureC             *float64      `json:"temperature_c,omitempty"`
+	HemoglobinGdL            *float64      `json:"hemoglobin_g_dl,omitempty"`
+	Breastfeeding            *bool         `json:"breastfeeding,omitempty"`
+	BabyWeightGrams          *int          `json:"baby_weight_grams,omitempty"`
+	DangerSigns              ClinicalFlags `json:"danger_signs" gorm:"type:varchar(255)"`
+	FamilyPlanningCounselled bool          `json:"family_planning_counselled"`
+	Notes                    string        `json:"notes,omitempty" gorm:"size:500"`
+	RecordedByStaffID        *uint         `json:"recorded_by_staff_id,omitempty"`
+	CreatedAt                time.Time     `json:"created_at"`
+
+	// Relationships
+	RecordedBy *Staff `json:"recorded_by,omitempty" gorm:"foreignKey:RecordedByStaffID;references:ID"`
+}
+
+// EstimatedDueDate applies Naegele's rule: 280 days after the LMP
+func EstimatedDueDate(lmp time.Time) time.Time {
+	return dateOnly(lmp).AddDate(0, 0, PregnancyTermDays)
+}
+
+// GestationalAgeDays is the number of days from the LMP to on
+func GestationalAgeDays(lmp, on time.Time) int {
+	return int(dateOnly(on).Sub(dateOnly(lmp)).Hours() / 24)
+}
+
+// FormatGestationalAge formats a gestational age in days as weeks and days, e.g. 32w4d
+func FormatGestationalAge(days int) string {
+	return fmt.Sprintf("%dw%dd", days/7, days%7)
+}
+
+// NextANCDueDate is when the next contact of the WHO eight-contact schedule
+// falls due: the first scheduled week after the gestational age at the last
+// contact, or 12 weeks when there has been none. It is nil once the schedule
+// is complete.
+func NextANCDueDate(lmp time.Time, lastContactGADays *int) *time.Time {
+	for _, week := range ANCContactWeeks {
+		if lastContactGADays == nil || week*7 > *lastContactGADays {
+			due := dateOnly(lmp).AddDate(0, 0, week*7)
+			return &due
+		}
+	}
+	return nil
+}
+
+// ANCOverdue reports whether an antenatal contact due on due is more than
+// ANCGraceDays late
+func ANCOverdue(due *time.Time, now time.Time) bool {
+	return due != nil && dateOnly(now).After(due.AddDate(0, 0, ANCGraceDays))
+}
+
+// AssessPregnancyRisk combines the clinician-reported risks with those worked
+// out from the mother's age at LMP, parity and antenatal measurements
+func AssessPregnancyRisk(p *Pregnancy, dob time.Time, contacts []AntenatalContact) ClinicalFlags {
+	flags := ClinicalFlags{}
+	add := func(flag string) {
+		if !flags.Has(flag) {
+			flags = append(flags, flag)
+		}
+	}
+	for _, flag := range p.ReportedRisks {
+		add(flag)
+	}
+
+	age := yearsBetween(dob, p.LMP)
+	if age < 18 {
+		add(RiskAdolescent)
+	}
+	if age >= 35 {
+		add(RiskAdvancedMaternalAge)
+	}
+	if p.Parity >= 5 {
+		add(RiskGrandMultipara)
+	}
+
+	for _, contact := range contacts {
+		hypertensive := contact.SystolicBP != nil && contact.DiastolicBP != nil &&
+			(*contact.SystolicBP >= 140 || *contact.DiastolicBP >= 90)
+		if hypertensive {
+			add(RiskHypertension)
+			if contact.GestationalAgeDays >= 20*7 && proteinuric(contact.UrineProtein) {
+				add(RiskPreeclampsia)
+			}
+		}
+		if contact.HemoglobinGdL != nil {
+			if *contact.HemoglobinGdL < 7 {
+				add(RiskSevereAnemia)
+			} else if *contact.HemoglobinGdL < 11 {
+				add(RiskAnemia)
+			}
+		}
+		if contact.FetalHeartRate != nil && (*contact.FetalHeartRate < 110 || *contact.FetalHeartRate > 160) {
+			add(RiskAbnormalFetalHeartRate)
+		}
+		if contact.GestationalAgeDays >= 36*7 &&
+			(contact.FetalPresentation == "breech" || contact.FetalPresentation == "transverse") {
+			add(RiskMalpresentationAtTerm)
+		}
+	}
+
+	// Severe anemia supersedes anemia seen at an earlier contact
+	if flags.Has(RiskSevereAnemia) && flags.Has(RiskAnemia) {
+		kept := flags[:0]
+		for _, flag := range flags {
+			if flag != RiskAnemia {
+				kept = append(kept, flag)
+			}
+		}
+		flags = kept
+	}
+	return flags
+}
+
+// Refresh recomputes the pregnancy's EDD, risk flags and next ANC due date
+// from its LMP, the mother's date of birth and the antenatal contacts so far
+func (p *Pregnancy) Refresh(dob time.Time, contacts []AntenatalContact) {
+	p.LMP = dateOnly(p.LMP)
+	p.EDD = EstimatedDueDate(p.LMP)
+	p.RiskFlags = AssessPregnancyRisk(p, dob, contacts)
+	p.HighRisk = len(p.RiskFlags) > 0
+
+	p.NextANCDue = nil
+	if p.Status != PregnancyStatusActive {
+		return
+	}
+	var last *int
+	for _, contact := range contacts {
+		if last == nil || contact.GestationalAgeDays > *last {
+			ga := contact.GestationalAgeDays
+			last = &ga
+		}
+	}
+	p.NextANCDue = NextANCDueDate(p.LMP, last)
+}
+
+func proteinuric(result string) bool {
+	switch result {
+	case "1+", "2+", "3+", "4+":
+		return true
+	}
+	return false
+}
+
+// yearsBetween is a person's age in whole years on a date
+func yearsBetween(dob, on time.Time) int {
+	years := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
+
+// Pregnancy DTOs
+type CreatePregnancyRequest struct {
+	LMP           string   `json:"lmp" validate:"required"` // YYYY-MM-DD
+	Gravida       int      `json:"gravida" validate:"required,min=1"`
+	Parity        int      `json:"parity" validate:"min=0"`
+	ReportedRisks []string `json:"reported_risks,omitempty"`
+	Notes         string   `json:"notes,omitempty" validate:"max=500"`
+}
+
+// UpdatePregnancyRequest corrects an active pregnancy, e.g. redating the LMP
+// after an ultrasound
+type UpdatePregnancyRequest struct {
+	LMP           *string   `json:"lmp,omitempty"`
+	Gravida       *int      `json:"gravida,omitempty"`
+	Parity        *int      `json:"parity,omitempty"`
+	ReportedRisks *[]string `json:"reported_risks,omitempty"`
+	Notes         *string   `json:"notes,omitempty"`
+}
+
+// ValidateObstetricHistory checks gravida and parity are consistent: the
+// current pregnancy counts towards gravida but not parity
+func ValidateObstetricHistory(gravida, parity int) error {
+	if gravida < 1 || gravida > 30 {
+		return fmt.Errorf("gravida must be between 1 and 30")
+	}
+	if parity < 0 || parity >= gravida {
+		return fmt.Errorf("parity must be at least 0 and less than gravida")
+	}
+	return nil
+}
+
+type RecordANCContactRequest struct {
+	ContactDate        string   `json:"contact_date,omitempty"` // YYYY-MM-DD, defaults to today
+	VisitID            *uint    `json:"visit_id,omitempty"`
+	WeightKg           *float64 `json:"weight_kg,omitempty"`
+	SystolicBP         *int     `json:"systolic_bp,omitempty"`
+	DiastolicBP        *int     `json:"diastolic_bp,omitempty"`
+	FundalHeightCm     *float64 `json:"fundal_height_cm,omitempty"`
+	FetalHeartRate     *int     `json:"fetal_heart_rate,omitempty"`
+	FetalPresentation  string   `json:"fetal_presentation,omitempty"`
+	HemoglobinGdL      *float64 `json:"hemoglobin_g_dl,omitempty"`
+	UrineProtein       string   `json:"urine_protein,omitempty"`
+	IronFolicAcidGiven bool     `json:"iron_folic_acid_given"`
+	Notes              string   `json:"notes,omitempty" validate:"max=500"`
+}
+
+// ToContact checks every measurement is plausible and returns the contact.
+// Dates, gestational age and ownership are filled in by the caller.
+func (r RecordANCContactRequest) ToContact() (AntenatalContact, error) {
+	var contact AntenatalContact
+
+	if (r.SystolicBP == nil) != (r.DiastolicBP == nil) {
+		return contact, fmt.Errorf("systolic_bp and diastolic_bp must be recorded together")
+	}
+	if r.SystolicBP != nil {
+		if err := checkIntRange("systolic_bp", *r.SystolicBP, 50, 300); err != nil {
+			return contact, err
+		}
+		if err := checkIntRange("diastolic_bp", *r.DiastolicBP, 20, 200); err != nil {
+			return contact, err
+		}
+		if *r.DiastolicBP >= *r.SystolicBP {
+			return contact, fmt.Errorf("diastolic_bp must be lower than systolic_bp")
+		}
+	}
+	if r.WeightKg != nil {
+		if err := checkFloatRange("weight_kg", *r.WeightKg, 25, 200); err != nil {
+			return contact, err
+		}
+		w := round1(*r.WeightKg)
+		contact.WeightKg = &w
+	}
+	if r.FundalHeightCm != nil {
+		if err := checkFloatRange("fundal_height_cm", *r.FundalHeightCm, 5, 50); err != nil {
+			return contact, err
+		}
+		contact.FundalHeightCm = r.FundalHeightCm
+	}
+	if r.FetalHeartRate != nil {
+		if err := checkIntRange("fetal_heart_rate", *r.FetalHeartRate, 50, 250); err != nil {
+			return contact, err
+		}
+	}
+	if r.HemoglobinGdL != nil {
+		if err := checkFloatRange("hemoglobin_g_dl", *r.HemoglobinGdL, 2, 20); err != nil {
+			return contact, err
+		}
+		hb := round1(*r.HemoglobinGdL)
+		contact.HemoglobinGdL = &hb
+	}
+
+	contact.FetalPresentation = strings.ToLower(strings.TrimSpace(r.FetalPresentation))
+	if contact.FetalPresentation != "" && !slices.Contains(FetalPresentations, contact.FetalPresentation) {
+		return contact, fmt.Errorf("fetal_presentation must be one of %s", strings.Join(FetalPresentations, ", "))
+	}
+	contact.UrineProtein = strings.ToLower(strings.TrimSpace(r.UrineProtein))
+	if contact.UrineProtein != "" && !slices.Contains(UrineProteinResults, contact.UrineProtein) {
+		return contact, fmt.Errorf("urine_protein must be one of %s", strings.Join(UrineProteinResults, ", "))
+	}
+	if len(r.Notes) > 500 {
+		return contact, fmt.Errorf("notes must be at most 500 characters")
+	}
+
+	contact.VisitID = r.VisitID
+	contact.SystolicBP, contact.DiastolicBP = r.SystolicBP, r.DiastolicBP
+	contact.FetalHeartRate = r.FetalHeartRate
+	contact.IronFolicAcidGiven = r.IronFolicAcidGiven
+	contact.Notes = strings.TrimSpace(r.Notes)
+	return contact, nil
+}
+
+type RecordPregnancyOutcomeRequest struct {
+	OutcomeDate           string `json:"outcome_date" validate:"required"` // YYYY-MM-DD
+	Outcome               string `json:"outcome" validate:"required"`
+	DeliveryMode          string `json:"delivery_mode,omitempty"`
+	DeliveryPlace         string `json:"delivery_place,omitempty"`
+	LiveBirths            int    `json:"live_births"`
+	Stillbirths           int    `json:"stillbirths"`
+	BirthWeightGrams      *int   `json:"birth_weight_grams,omitempty"`
+	MaternalComplications string `json:"maternal_complications,omitempty" validate:"max=500"`
+}
+
+// OutcomeStatus checks the outcome is consistent and returns the status the
+// pregnancy moves to
+func (r *RecordPregnancyOutcomeRequest) OutcomeStatus() (string, error) {
+	r.Outcome = strings.ToLower(strings.TrimSpace(r.Outcome))
+	r.DeliveryMode = strings.ToLower(strings.TrimSpace(r.DeliveryMode))
+	r.DeliveryPlace = strings.ToLower(strings.TrimSpace(r.DeliveryPlace))
+
+	if len(r.MaternalComplications) > 500 {
+		return "", fmt.Errorf("maternal_complications must be at most 500 characters")
+	}
+	if r.DeliveryPlace != "" && !slices.Contains(DeliveryPlaces, r.DeliveryPlace) {
+		return "", fmt.Errorf("delivery_place must be one of %s", strings.Join(DeliveryPlaces, ", "))
+	}
+
+	switch r.Outcome {
+	case PregnancyOutcomeLiveBirth, PregnancyOutcomeStillbirth:
+		if !slices.Contains(DeliveryModes, r.DeliveryMode) {
+			return "", fmt.Errorf("delivery_mode must be one of %s", strings.Join(DeliveryModes, ", "))
+		}
+		if r.LiveBirths < 0 || r.Stillbirths < 0 || r.LiveBirths+r.Stillbirths < 1 || r.LiveBirths+r.Stillbirths > 6 {
+			return "", fmt.Errorf("live_births and stillbirths must add up to between 1 and 6")
+		}
+		if r.Outcome == PregnancyOutcomeLiveBirth && r.LiveBirths == 0 {
+			return "", fmt.Errorf("a live birth needs live_births of at least 1")
+		}
+		if r.Outcome == PregnancyOutcomeStillbirth && (r.LiveBirths != 0 || r.Stillbirths == 0) {
+			return "", fmt.Errorf("a stillbirth has no live births and at least 1 stillbirth")
+		}
+		if r.BirthWeightGrams != nil {
+			if err := checkIntRange("birth_weight_grams", *r.BirthWeightGrams, 300, 6500); err != nil {
+				return "", err
+			}
+		}
+		return PregnancyStatusDelivered, nil
+	case PregnancyOutcomeMiscarriage, PregnancyOutcomeAbortion, PregnancyOutcomeEctopic:
+		if r.DeliveryMode != "" || r.LiveBirths != 0 || r.Stillbirths != 0 || r.BirthWeightGrams != nil {
+			return "", fmt.Errorf("delivery details only apply to a live birth or stillbirth")
+		}
+		return PregnancyStatusEnded, nil
+	default:
+		return "", fmt.Errorf("outcome must be one of live_birth, stillbirth, miscarriage, abortion, ectopic")
+	}
+}
+
+type RecordPostnatalVisitRequest struct {
+	VisitDate                string   `json:"visit_date,omitempty"` // YYYY-MM-DD, defaults to today
+	VisitID                  *uint    `json:"visit_id,omitempty"`
+	SystolicBP               *int     `json:"systolic_bp,omitempty"`
+	DiastolicBP              *int     `json:"diastolic_bp,omitempty"`
+	TemperatureC             *float64 `json:"temperature_c,omitempty"`
+	HemoglobinGdL            *float64 `json:"hemoglobin_g_dl,omitempty"`
+	Breastfeeding            *bool    `json:"breastfeeding,omitempty"`
+	BabyWeightGrams          *int     `json:"baby_weight_grams,omitempty"`
+	DangerSigns              []string `json:"danger_signs,omitempty"`
+	FamilyPlanningCounselled bool     `json:"family_planning_counselled"`
+	Notes                    string   `json:"notes,omitempty" validate:"max=500"`
+}
+
+// ToPostnatalVisit checks every measurement is plausible and returns the
+// visit. Dates and ownership are filled in by the caller.
+func (r RecordPostnatalVisitRequest) ToPostnatalVisit() (PostnatalVisit, error) {
+	var visit PostnatalVisit
+
+	if (r.SystolicBP == nil) != (r.DiastolicBP == nil) {
+		return visit, fmt.Errorf("systolic_bp and diastolic_bp must be recorded together")
+	}
+	if r.SystolicBP != nil {
+		if err := checkIntRange("systolic_bp", *r.SystolicBP, 50, 300); err != nil {
+			return visit, err
+		}
+		if err := checkIntRange("diastolic_bp", *r.DiastolicBP, 20, 200); err != nil {
+			return visit, err
+		}
+		if *r.DiastolicBP >= *r.SystolicBP {
+			return visit, fmt.Errorf("diastolic_bp must be lower than systolic_bp")
+		}
+	}
+	if r.TemperatureC != nil {
+		if err := checkFloatRange("temperature_c", *r.TemperatureC, 25, 45); err != nil {
+			return visit, err
+		}
+		temp := round1(*r.TemperatureC)
+		visit.TemperatureC = &temp
+	}
+	if r.HemoglobinGdL != nil {
+		if err := checkFloatRange("hemoglobin_g_dl", *r.HemoglobinGdL, 2, 20); err != nil {
+			return visit, err
+		}
+		hb := round1(*r.HemoglobinGdL)
+		visit.HemoglobinGdL = &hb
+	}
+	if r.BabyWeightGrams != nil {
+		if err := checkIntRange("baby_weight_grams", *r.BabyWeightGrams, 300, 10000); err != nil {
+			return visit, err
+		}
+	}
+	signs, err := NormalizeClinicalFlags(r.DangerSigns, PostnatalDangerSigns)
+	if err != nil {
+		return visit, fmt.Errorf("danger_signs must be from %s", strings.Join(PostnatalDangerSigns, ", "))
+	}
+	if len(r.Notes) > 500 {
+		return visit, fmt.Errorf("notes must be at most 500 characters")
+	}
+
+	visit.VisitID = r.VisitID
+	visit.SystolicBP, visit.DiastolicBP = r.SystolicBP, r.DiastolicBP
+	visit.Breastfeeding = r.Breastfeeding
+	visit.BabyWeightGrams = r.BabyWeightGrams
+	visit.DangerSigns = signs
+	visit.FamilyPlanningCounselled = r.FamilyPlanningCounselled
+	visit.Notes = strings.TrimSpace(r.Notes)
+	return visit, nil
+}
+
+// MaternalWatchEntry is a woman on a clinic's maternal watch list, either
+// high risk or overdue for antenatal care
+type MaternalWatchEntry struct {
+	PregnancyID        uint          `json:"pregnancy_id"`
+	PatientID          uint          `json:"patient_id"`
+	MRN                *string       `json:"mrn,omitempty"`
+	FullName           string        `json:"full_name"`
+	Phone              string        `json:"phone"`
+	EDD                time.Time     `json:"edd"`
+	GestationalAge     string        `json:"gestational_age"`
+	GestationalAgeDays int           `json:"gestational_age_days"`
+	RiskFlags          ClinicalFlags `json:"risk_flags"`
+	HighRisk           bool          `json:"high_risk"`
+	NextANCDue         *time.Time    `json:"next_anc_due,omitempty"`
+	ANCOverdue         bool          `json:"anc_overdue"`
+	ContactCount       int64         `json:"contact_count"`
+}
+
+// SortMaternalWatchList puts overdue women first, most overdue leading, then
+// high-risk women nearest their due date
+func SortMaternalWatchList(entries []MaternalWatchEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ANCOverdue != b.ANCOverdue {
+			return a.ANCOverdue
+		}
+		if a.ANCOverdue {
+			return a.NextANCDue.Before(*b.NextANCDue)
+		}
+		return a.EDD.Before(b.EDD)
+	})
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimatedDueDateAndGestationalAge(t *testing.T) {
+	lmp := time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC)
+
+	if got, want := EstimatedDueDate(lmp), time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("EDD = %s, want %s", got.Format("2006-01-02"), want.Format("2006-01-02"))
+	}
+
+	ga := GestationalAgeDays(lmp, time.Date(2024, 8, 12, 9, 0, 0, 0, time.UTC))
+	if ga != 224 {
+		t.Errorf("gestational age = %d days, want 224", ga)
+	}
+	if got := FormatGestationalAge(ga + 4); got != "32w4d" {
+		t.Errorf("FormatGestationalAge = %s, want 32w4d", got)
+	}
+}
+
+func TestNextANCDueDate(t *testing.T) {
+	lmp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := NextANCDueDate(lmp, nil)
+	if first == nil || !first.Equal(lmp.AddDate(0, 0, 12*7)) {
+		t.Errorf("first contact due %v, want 12 weeks", first)
+	}
+
+	// A contact at 27 weeks is followed by the 30-week contact
+	last := 27 * 7
+	next := NextANCDueDate(lmp, &last)
+	if next == nil || !next.Equal(lmp.AddDate(0, 0, 30*7)) {
+		t.Errorf("next contact due %v, want 30 weeks", next)
+	}
+
+	// A contact on a scheduled week moves on to the next one
+	last = 20 * 7
+	if next := NextANCDueDate(lmp, &last); next == nil || !next.Equal(lmp.AddDate(0, 0, 26*7)) {
+		t.Errorf("next contact due %v, want 26 weeks", next)
+	}
+
+	last = 40 * 7
+	if next := NextANCDueDate(lmp, &last); next != nil {
+		t.Errorf("schedule should be complete after 40 weeks, got %v", next)
+	}
+}
+
+func TestANCOverdue(t *testing.T) {
+	due := time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC)
+	if ANCOverdue(&due, due.AddDate(0, 0, ANCGraceDays)) {
+		t.Error("contact should not be overdue on the last day of the grace period")
+	}
+	if !ANCOverdue(&due, due.AddDate(0, 0, ANCGraceDays+1)) {
+		t.Error("contact should be overdue after the grace period")
+	}
+	if ANCOverdue(nil, due) {
+		t.Error("a completed schedule is never overdue")
+	}
+}
+
+func TestAssessPregnancyRisk(t *testing.T) {
+	lmp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	low := &Pregnancy{LMP: lmp, Gravida: 2, Parity: 1}
+	if flags := AssessPregnancyRisk(low, time.Date(1998, 6, 1, 0, 0, 0, 0, time.UTC), nil); len(flags) != 0 {
+		t.Errorf("expected no risk flags, got %v", flags)
+	}
+
+	// 17 at LMP, turning 18 a day later
+	young := &Pregnancy{LMP: lmp, Gravida: 1}
+	if flags := AssessPregnancyRisk(young, time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC), nil); !flags.Has(RiskAdolescent) {
+		t.Errorf("expected adolescent, got %v", flags)
+	}
+
+	p := &Pregnancy{LMP: lmp, Gravida: 7, Parity: 5, ReportedRisks: ClinicalFlags{RiskPreviousCesarean}}
+	contacts := []AntenatalContact{
+		{GestationalAgeDays: 16 * 7, SystolicBP: intPtr(142), DiastolicBP: intPtr(88), UrineProtein: "2+", HemoglobinGdL: floatPtr(10.2)},
+		{GestationalAgeDays: 26 * 7, SystolicBP: intPtr(128), DiastolicBP: intPtr(92), UrineProtein: "1+", HemoglobinGdL: floatPtr(6.8)},
+		{GestationalAgeDays: 36 * 7, FetalPresentation: "breech", FetalHeartRate: intPtr(172)},
+	}
+	flags := AssessPregnancyRisk(p, time.Date(1986, 3, 1, 0, 0, 0, 0, time.UTC), contacts)
+	for _, want := range []string{
+		RiskPreviousCesarean, RiskAdvancedMaternalAge, RiskGrandMultipara, RiskHypertension,
+		RiskPreeclampsia, RiskSevereAnemia, RiskAbnormalFetalHeartRate, RiskMalpresentationAtTerm,
+	} {
+		if !flags.Has(want) {
+			t.Errorf("expected %s in %v", want, flags)
+		}
+	}
+	if flags.Has(RiskAnemia) {
+		t.Errorf("severe anemia should replace anemia, got %v", flags)
+	}
+
+	// Proteinuria with hypertension before 20 weeks is not preeclampsia
+	early := AssessPregnancyRisk(&Pregnancy{LMP: lmp, Gravida: 1}, time.Date(1998, 6, 1, 0, 0, 0, 0, time.UTC), contacts[:1])
+	if early.Has(RiskPreeclampsia) {
+		t.Errorf("preeclampsia flagged before 20 weeks: %v", early)
+	}
+}
+
+func TestPregnancyRefresh(t *testing.T) {
+	lmp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &Pregnancy{LMP: lmp, Gravida: 1, Status: PregnancyStatusActive}
+	p.Refresh(time.Date(1998, 6, 1, 0, 0, 0, 0, time.UTC), []AntenatalContact{
+		{GestationalAgeDays: 21 * 7, HemoglobinGdL: floatPtr(10.5)},
+		{GestationalAgeDays: 13 * 7},
+	})
+
+	if !p.EDD.Equal(EstimatedDueDate(lmp)) {
+		t.Errorf("EDD = %s", p.EDD)
+	}
+	if !p.HighRisk || !p.RiskFlags.Has(RiskAnemia) {
+		t.Errorf("expected high risk with anemia, got %v", p.RiskFlags)
+	}
+	if p.NextANCDue == nil || !p.NextANCDue.Equal(lmp.AddDate(0, 0, 26*7)) {
+		t.Errorf("next ANC due %v, want 26 weeks after the latest contact", p.NextANCDue)
+	}
+
+	p.Status = PregnancyStatusDelivered
+	p.Refresh(time.Date(1998, 6, 1, 0, 0, 0, 0, time.UTC), nil)
+	if p.NextANCDue != nil || p.HighRisk {
+		t.Errorf("delivered pregnancy should have no ANC due and no measured risks, got %v %v", p.NextANCDue, p.RiskFlags)
+	}
+}
+
+func TestRecordPregnancyOutcomeRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RecordPregnancyOutcomeRequest
+		status  string
+		wantErr bool
+	}{
+		{"live birth", RecordPregnancyOutcomeRequest{Outcome: "Live_Birth", DeliveryMode: "vaginal", LiveBirths: 1}, PregnancyStatusDelivered, false},
+		{"twins with one stillbirth", RecordPregnancyOutcomeRequest{Outcome: "live_birth", DeliveryMode: "cesarean", LiveBirths: 1, Stillbirths: 1}, PregnancyStatusDelivered, false},
+		{"live birth without mode", RecordPregnancyOutcomeRequest{Outcome: "live_birth", LiveBirths: 1}, "", true},
+		{"live birth without babies", RecordPregnancyOutcomeRequest{Outcome: "live_birth", DeliveryMode: "vaginal"}, "", true},
+		{"stillbirth with live baby", RecordPregnancyOutcomeRequest{Outcome: "stillbirth", DeliveryMode: "vaginal", LiveBirths: 1, Stillbirths: 1}, "", true},
+		{"miscarriage", RecordPregnancyOutcomeRequest{Outcome: "miscarriage"}, PregnancyStatusEnded, false},
+		{"miscarriage with delivery details", RecordPregnancyOutcomeRequest{Outcome: "miscarriage", DeliveryMode: "vaginal"}, "", true},
+		{"unknown outcome", RecordPregnancyOutcomeRequest{Outcome: "unknown"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := tt.req.OutcomeStatus()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.status {
+				t.Errorf("status = %q, want %q", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestValidateObstetricHistory(t *testing.T) {
+	if err := ValidateObstetricHistory(1, 0); err != nil {
+		t.Errorf("primigravida: %v", err)
+	}
+	if err := ValidateObstetricHistory(2, 2); err == nil {
+		t.Error("parity must be less than gravida")
+	}
+	if err := ValidateObstetricHistory(0, 0); err == nil {
+		t.Error("gravida must be at least 1")
+	}
+}
+
+func TestRecordANCContactRequest(t *testing.T) {
+	if _, err := (RecordANCContactRequest{SystolicBP: intPtr(120)}).ToContact(); err == nil {
+		t.Error("expected error for systolic without diastolic")
+	}
+	if _, err := (RecordANCContactRequest{UrineProtein: "5+"}).ToContact(); err == nil {
+		t.Error("expected error for unknown urine protein result")
+	}
+	contact, err := (RecordANCContactRequest{FetalPresentation: " Cephalic ", HemoglobinGdL: floatPtr(11.26)}).ToContact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contact.FetalPresentation != "cephalic" || *contact.HemoglobinGdL != 11.3 {
+		t.Errorf("contact = %+v", contact)
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// Referral statuses
+const (
+	ReferralStatusSent      = "sent"
+	ReferralStatusAccepted  = "accepted"
+	ReferralStatusCompleted = "completed"
+	ReferralStatusDeclined  = "declined"
+)
+
+// Referral urgencies
+const (
+	ReferralUrgencyRoutine   = "routine"
+	ReferralUrgencyUrgent    = "urgent"
+	ReferralUrgencyEmergency = "emergency"
+)
+
+// Referral sends a patient from one clinic to another, usually a district
+// hospital. The receiving clinic can read the originating visit through the
+// referral without the patient being registered there.
+type Referral struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	PatientID          uint       `json:"patient_id" gorm:"not null;index"`
+	FromClinicID       uint       `json:"from_clinic_id" gorm:"not null;index"`
+	ToClinicID         uint       `json:"to_clinic_id" gorm:"not null;index"`
+	VisitID            uint       `json:"visit_id" gorm:"not null;index"`
+	ReferredByStaffID  *uint      `json:"referred_by_staff_id,omitempty"`
+	Reason             string     `json:"reason" gorm:"not null;size:1000" validate:"required,min=5,max=1000"`
+	Urgency            string     `json:"urgency" gorm:"not null;size:20;default:routine" validate:"oneof=routine urgent emergency"`
+	Status             string     `json:"status" gorm:"not null;size:20;default:sent;index"`
+	FeedbackNote       string     `json:"feedback_note,omitempty" gorm:"size:2000"`
+	RespondedByStaffID *uint      `json:"responded_by_staff_id,omitempty"`
+	AcceptedAt         *time.Time `json:"accepted_at,omitempty"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	DeclinedAt         *time.Time `json:"declined_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relationships
+	Patient     *Patient `json:"patient,omitempty" gorm:"foreignKey:PatientID;references:ID"`
+	FromClinic  *Clinic  `json:"from_clinic,omitempty" gorm:"foreignKey:FromClinicID;references:ID"`
+	ToClinic    *Clinic  `json:"to_clinic,omitempty" gorm:"foreignKey:ToClinicID;references:ID"`
+	Visit       *Visit   `json:"visit,omitempty" gorm:"foreignKey:VisitID;references:ID"`
+	ReferredBy  *Staff   `json:"referred_by,omitempty" gorm:"foreignKey:ReferredByStaffID;references:ID"`
+	RespondedBy *Staff   `json:"responded_by,omitempty" gorm:"foreignKey:RespondedByStaffID;references:ID"`
+}
+
+// referralTransitions lists the statuses each status can move to
+var referralTransitions = map[string][]string{
+	ReferralStatusSent:     {ReferralStatusAccepted, ReferralStatusDeclined},
+	ReferralStatusAccepted: {ReferralStatusCompleted},
+}
+
+// CanTransitionReferral reports whether a referral may move between statuses
+func CanTransitionReferral(from, to string) bool {
+	for _, next := range referralTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidReferralUrgency reports whether urgency is a known urgency
+func IsValidReferralUrgency(urgency string) bool {
+	switch urgency {
+	case ReferralUrgencyRoutine, ReferralUrgencyUrgent, ReferralUrgencyEmergency:
+		return true
+	}
+	return false
+}
+
+// Referral DTOs
+type CreateReferralRequest struct {
+	VisitID    uint   `json:"visit_id" validate:"required"`
+	ToClinicID uint   `json:"to_clinic_id" validate:"required"`
+	Reason     string `json:"reason" validate:"required,min=5,max=1000"`
+	Urgency    string `json:"urgency" validate:"omitempty,oneof=routine urgent emergency"` // defaults to routine
+}
+
+type UpdateReferralStatusRequest struct {
+	Status       string `json:"status" validate:"required,oneof=accepted completed declined"`
+	FeedbackNote string `json:"feedback_note" validate:"max=2000"` // required when declining
+}
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestCanTransitionReferral(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ReferralStatusSent, ReferralStatusAccepted, true},
+		{ReferralStatusSent, ReferralStatusDeclined, true},
+		{ReferralStatusSent, ReferralStatusCompleted, false},
+		{ReferralStatusAccepted, ReferralStatusCompleted, true},
+		{ReferralStatusAccepted, ReferralStatusDeclined, false},
+		{ReferralStatusCompleted, ReferralStatusAccepted, false},
+		{ReferralStatusDeclined, ReferralStatusAccepted, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransitionReferral(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionReferral(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// Role is a login user type (e.g. "doctor") whose permission grants are stored in the database.
+// The hard-coded RolePermissions map is used to seed the default grants.
+type Role struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;size:50;uniqueIndex"`
+	Description string    `json:"description" gorm:"size:255"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Permissions []RolePermission `json:"permissions,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+// RolePermission is a default permission grant that applies to every clinic
+type RolePermission struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	RoleID     uint       `json:"role_id" gorm:"not null;uniqueIndex:idx_role_permission"`
+	Permission Permission `json:"permission" gorm:"not null;size:50;uniqueIndex:idx_role_permission"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// RoleGrantMigration records that a default permission added after roles were
+// first seeded has been granted to the existing role
+type RoleGrantMigration struct {
+	RoleName   string     `gorm:"primaryKey;size:50"`
+	Permission Permission `gorm:"primaryKey;size:50"`
+	AppliedAt  time.Time
+}
+
+// AddedRolePermissions are default grants added to RolePermissions after roles
+// were first seeded. Existing roles are not reseeded, so each of these is
+// granted to them once at startup; revoking it later through the admin API
+// sticks.
+var AddedRolePermissions = []RoleGrantMigration{
+	{RoleName: "clinic_staff", Permission: PermissionManageInventory}, // Pharmacy inventory
+}
+
+// ClinicPermissionOverride grants or revokes a permission for a role within a single clinic
+type ClinicPermissionOverride struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ClinicID   uint       `json:"clinic_id" gorm:"not null;uniqueIndex:idx_clinic_role_permission"`
+	RoleID     uint       `json:"role_id" gorm:"not null;uniqueIndex:idx_clinic_role_permission"`
+	Permission Permission `json:"permission" gorm:"not null;size:50;uniqueIndex:idx_clinic_role_permission"`
+	Granted    bool       `json:"granted"` // false revokes a default grant
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	Clinic *Clinic `json:"clinic,omitempty" gorm:"foreignKey:ClinicID"`
+	Role   *Role   `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+type UpdateRolePermissionsRequest struct {
+	Description *string      `json:"description,omitempty" validate:"omitempty,max=255"`
+	Permissions []Permission `json:"permissions" validate:"required"`
+}
+
+type SetPermissionOverrideRequest struct {
+	ClinicID   uint       `json:"clinic_id" validate:"required"`
+	Permission Permission `json:"permission" validate:"required"`
+	Granted    bool       `json:"granted"`
+}
+
+// RoleResponse is a role with its effective permissions
+type RoleResponse struct {
+	Role
+	EffectivePermissions []Permission               `json:"effective_permissions"`
+	Overrides            []ClinicPermissionOverride `json:"overrides,omitempty"`
+}
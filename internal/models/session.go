@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// AuthSession represents a login session backed by a rotating refresh token.
+// Access tokens carry the session ID so a revoked session is rejected immediately.
+type AuthSession struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	UserID            uint       `json:"user_id" gorm:"not null;index"`
+	RefreshTokenHash  string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	PreviousTokenHash string     `json:"-" gorm:"size:64;index"` // Last rotated-out token, used to detect reuse
+	ExpiresAt         time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	IPAddress         string     `json:"ip_address" gorm:"size:45"`
+	UserAgent         string     `json:"user_agent" gorm:"size:255"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// IsValid reports whether the session can still be used at the given time
+func (s *AuthSession) IsValid(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	AllSessions bool `json:"all_sessions"`
+}
+
+// PasswordResetToken is a single-use, expiring token for resetting a forgotten password
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RequestIP string     `json:"request_ip" gorm:"size:45"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+type PasswordResetRequest struct {
+	Email   string `json:"email" validate:"required,email"`
+	Channel string `json:"channel,omitempty" validate:"omitempty,oneof=email sms"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
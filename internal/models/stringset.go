@@ -0,0 +1,41 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// StringSet is a small set of codes stored comma-separated in one column
+type StringSet []string
+
+func (s StringSet) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *StringSet) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("unsupported type for StringSet: %T", value)
+	}
+	if raw == "" {
+		*s = StringSet{}
+		return nil
+	}
+	*s = strings.Split(raw, ",")
+	return nil
+}
+
+// Has reports whether item is in the set
+func (s StringSet) Has(item string) bool {
+	return slices.Contains(s, item)
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// PatientTransfer records a patient's home clinic changing. Visits stay with
+// the clinic that recorded them as read-only history; only the new clinic
+// records anything further.
+type PatientTransfer struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	PatientID           uint      `json:"patient_id" gorm:"not null;index"`
+	FromClinicID        uint      `json:"from_clinic_id" gorm:"not null"`
+	ToClinicID          uint      `json:"to_clinic_id" gorm:"not null"`
+	Reason              string    `json:"reason" gorm:"not null;size:500"`
+	TransferredByUserID *uint     `json:"transferred_by_user_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// Relationships
+	FromClinic *Clinic `json:"from_clinic,omitempty" gorm:"foreignKey:FromClinicID;references:ID"`
+	ToClinic   *Clinic `json:"to_clinic,omitempty" gorm:"foreignKey:ToClinicID;references:ID"`
+}
+
+// VisitReadOnly reports whether a clinic may read a visit but not change it:
+// the visit was recorded at another clinic, or its patient has since
+// transferred away. The patient is only checked when it is loaded.
+func VisitReadOnly(visit Visit, clinicID uint) bool {
+	return visit.ClinicID != clinicID || (visit.Patient != nil && visit.Patient.ClinicID != clinicID)
+}
+
+// MarkReadOnlyVisits flags the visits the given clinic may read but not change
+func MarkReadOnlyVisits(visits []Visit, clinicID uint) {
+	for i := range visits {
+		visits[i].ReadOnly = VisitReadOnly(visits[i], clinicID)
+	}
+}
+
+type TransferPatientRequest struct {
+	ToClinicID uint   `json:"to_clinic_id" validate:"required"`
+	Reason     string `json:"reason" validate:"required,min=5,max=500"`
+}
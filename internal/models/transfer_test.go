@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestMarkReadOnlyVisits(t *testing.T) {
+	visits := []Visit{
+		{ID: 1, ClinicID: 3},
+		{ID: 2, ClinicID: 7},
+		{ID: 3, ClinicID: 3},
+	}
+
+	MarkReadOnlyVisits(visits, 3)
+
+	want := []bool{false, true, false}
+	for i, v := range visits {
+		if v.ReadOnly != want[i] {
+			t.Errorf("visit %d read_only = %v, want %v", v.ID, v.ReadOnly, want[i])
+		}
+	}
+}
+
+func TestVisitReadOnlyAfterTransfer(t *testing.T) {
+	// The patient moved from clinic 3 to clinic 7
+	patient := &Patient{ID: 1, ClinicID: 7}
+	oldVisit := Visit{ID: 1, ClinicID: 3, Patient: patient}
+	newVisit := Visit{ID: 2, ClinicID: 7, Patient: patient}
+
+	if !VisitReadOnly(oldVisit, 3) {
+		t.Error("old clinic can still change its visit after the patient transferred away")
+	}
+	if !VisitReadOnly(oldVisit, 7) {
+		t.Error("new clinic can change a visit recorded at the old clinic")
+	}
+	if VisitReadOnly(newVisit, 7) {
+		t.Error("new clinic cannot change its own visit")
+	}
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// VitalSigns is a set of measurements taken during a visit. Values are stored
+// in canonical units: °C, kg, cm, mg/dL and mmHg.
+type VitalSigns struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	VisitID           uint      `json:"visit_id" gorm:"not null;index" validate:"required"`
+	PatientID         uint      `json:"patient_id" gorm:"not null;index:idx_vitals_patient_recorded" validate:"required"`
+	RecordedByStaffID *uint     `json:"recorded_by_staff_id,omitempty"`
+	RecordedAt        time.Time `json:"recorded_at" gorm:"not null;index:idx_vitals_patient_recorded"`
+	SystolicBP        *int      `json:"systolic_bp,omitempty"`         // mmHg
+	DiastolicBP       *int      `json:"diastolic_bp,omitempty"`        // mmHg
+	TemperatureC      *float64  `json:"temperature_c,omitempty"`       // °C
+	PulseBPM          *int      `json:"pulse_bpm,omitempty"`           // beats per minute
+	WeightKg          *float64  `json:"weight_kg,omitempty"`           // kg
+	HeightCm          *float64  `json:"height_cm,omitempty"`           // cm
+	BMI               *float64  `json:"bmi,omitempty"`                 // computed from weight and height
+	SpO2              *int      `json:"spo2,omitempty"`                // %
+	BloodGlucoseMgDL  *float64  `json:"blood_glucose_mg_dl,omitempty"` // mg/dL
+	Notes             string    `json:"notes,omitempty" gorm:"size:500"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Relationships
+	Visit      *Visit `json:"visit,omitempty" gorm:"foreignKey:VisitID;references:ID"`
+	RecordedBy *Staff `json:"recorded_by,omitempty" gorm:"foreignKey:RecordedByStaffID;references:ID"`
+}
+
+// CreateVitalSignsRequest accepts measurements with optional units. Units
+// default to °C, kg, cm and mg/dL.
+type CreateVitalSignsRequest struct {
+	SystolicBP       *int       `json:"systolic_bp"`
+	DiastolicBP      *int       `json:"diastolic_bp"`
+	Temperature      *float64   `json:"temperature"`
+	TemperatureUnit  string     `json:"temperature_unit" validate:"omitempty,oneof=C F"`
+	PulseBPM         *int       `json:"pulse_bpm"`
+	Weight           *float64   `json:"weight"`
+	WeightUnit       string     `json:"weight_unit" validate:"omitempty,oneof=kg lb"`
+	Height           *float64   `json:"height"`
+	HeightUnit       string     `json:"height_unit" validate:"omitempty,oneof=cm in"`
+	SpO2             *int       `json:"spo2"`
+	BloodGlucose     *float64   `json:"blood_glucose"`
+	BloodGlucoseUnit string     `json:"blood_glucose_unit" validate:"omitempty,oneof=mg/dL mmol/L"`
+	RecordedAt       *time.Time `json:"recorded_at"`
+	Notes            string     `json:"notes" validate:"max=500"`
+}
+
+// mmol/L to mg/dL for glucose
+const glucoseMmolToMg = 18.0
+
+// ToVitalSigns converts the request to canonical units, checks every value is
+// physiologically plausible and computes BMI when weight and height are present.
+func (r CreateVitalSignsRequest) ToVitalSigns() (VitalSigns, error) {
+	var v VitalSigns
+
+	if r.SystolicBP == nil && r.DiastolicBP == nil && r.Temperature == nil && r.PulseBPM == nil &&
+		r.Weight == nil && r.Height == nil && r.SpO2 == nil && r.BloodGlucose == nil {
+		return v, fmt.Errorf("at least one measurement is required")
+	}
+
+	if (r.SystolicBP == nil) != (r.DiastolicBP == nil) {
+		return v, fmt.Errorf("systolic_bp and diastolic_bp must be recorded together")
+	}
+	if r.SystolicBP != nil {
+		if err := checkIntRange("systolic_bp", *r.SystolicBP, 50, 300); err != nil {
+			return v, err
+		}
+		if err := checkIntRange("diastolic_bp", *r.DiastolicBP, 20, 200); err != nil {
+			return v, err
+		}
+		if *r.DiastolicBP >= *r.SystolicBP {
+			return v, fmt.Errorf("diastolic_bp must be lower than systolic_bp")
+		}
+		v.SystolicBP, v.DiastolicBP = r.SystolicBP, r.DiastolicBP
+	}
+
+	if r.Temperature != nil {
+		temp := *r.Temperature
+		switch r.TemperatureUnit {
+		case "", "C":
+		case "F":
+			temp = (temp - 32) * 5 / 9
+		default:
+			return v, fmt.Errorf("temperature_unit must be C or F")
+		}
+		if err := checkFloatRange("temperature", temp, 25, 45); err != nil {
+			return v, err
+		}
+		temp = round1(temp)
+		v.TemperatureC = &temp
+	}
+
+	if r.PulseBPM != nil {
+		if err := checkIntRange("pulse_bpm", *r.PulseBPM, 20, 250); err != nil {
+			return v, err
+		}
+		v.PulseBPM = r.PulseBPM
+	}
+
+	if r.Weight != nil {
+		weight := *r.Weight
+		switch r.WeightUnit {
+		case "", "kg":
+		case "lb":
+			weight = weight * 0.45359237
+		default:
+			return v, fmt.Errorf("weight_unit must be kg or lb")
+		}
+		if err := checkFloatRange("weight", weight, 0.3, 400); err != nil {
+			return v, err
+		}
+		weight = round1(weight)
+		v.WeightKg = &weight
+	}
+
+	if r.Height != nil {
+		height := *r.Height
+		switch r.HeightUnit {
+		case "", "cm":
+		case "in":
+			height = height * 2.54
+		default:
+			return v, fmt.Errorf("height_unit must be cm or in")
+		}
+		if err := checkFloatRange("height", height, 20, 250); err != nil {
+			return v, err
+		}
+		height = round1(height)
+		v.HeightCm = &height
+	}
+
+	if r.SpO2 != nil {
+		if err := checkIntRange("spo2", *r.SpO2, 50, 100); err != nil {
+			return v, err
+		}
+		v.SpO2 = r.SpO2
+	}
+
+	if r.BloodGlucose != nil {
+		glucose := *r.BloodGlucose
+		switch r.BloodGlucoseUnit {
+		case "", "mg/dL":
+		case "mmol/L":
+			glucose = glucose * glucoseMmolToMg
+		default:
+			return v, fmt.Errorf("blood_glucose_unit must be mg/dL or mmol/L")
+		}
+		if err := checkFloatRange("blood_glucose", glucose, 10, 1000); err != nil {
+			return v, err
+		}
+		glucose = round1(glucose)
+		v.BloodGlucoseMgDL = &glucose
+	}
+
+	if v.WeightKg != nil && v.HeightCm != nil {
+		v.BMI = ComputeBMI(*v.WeightKg, *v.HeightCm)
+	}
+
+	v.Notes = r.Notes
+	return v, nil
+}
+
+// ComputeBMI returns weight / height² rounded to one decimal place
+func ComputeBMI(weightKg, heightCm float64) *float64 {
+	if weightKg <= 0 || heightCm <= 0 {
+		return nil
+	}
+	heightM := heightCm / 100
+	bmi := round1(weightKg / (heightM * heightM))
+	return &bmi
+}
+
+func checkIntRange(field string, value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s must be between %d and %d", field, min, max)
+	}
+	return nil
+}
+
+func checkFloatRange(field string, value, min, max float64) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s is outside the plausible range", field)
+	}
+	return nil
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+)
+
+func intPtr(v int) *int           { return &v }
+func floatPtr(v float64) *float64 { return &v }
+
+func TestToVitalSignsConvertsUnits(t *testing.T) {
+	req := CreateVitalSignsRequest{
+		Temperature:      floatPtr(98.6),
+		TemperatureUnit:  "F",
+		Weight:           floatPtr(154),
+		WeightUnit:       "lb",
+		Height:           floatPtr(67),
+		HeightUnit:       "in",
+		BloodGlucose:     floatPtr(5.5),
+		BloodGlucoseUnit: "mmol/L",
+	}
+
+	v, err := req.ToVitalSigns()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *v.TemperatureC != 37.0 {
+		t.Errorf("temperature = %v, want 37.0", *v.TemperatureC)
+	}
+	if *v.WeightKg != 69.9 {
+		t.Errorf("weight = %v, want 69.9", *v.WeightKg)
+	}
+	if *v.HeightCm != 170.2 {
+		t.Errorf("height = %v, want 170.2", *v.HeightCm)
+	}
+	if *v.BloodGlucoseMgDL != 99.0 {
+		t.Errorf("glucose = %v, want 99.0", *v.BloodGlucoseMgDL)
+	}
+	if v.BMI == nil || *v.BMI != 24.1 {
+		t.Errorf("bmi = %v, want 24.1", v.BMI)
+	}
+}
+
+func TestToVitalSignsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CreateVitalSignsRequest
+	}{
+		{"empty", CreateVitalSignsRequest{}},
+		{"systolic without diastolic", CreateVitalSignsRequest{SystolicBP: intPtr(120)}},
+		{"diastolic above systolic", CreateVitalSignsRequest{SystolicBP: intPtr(80), DiastolicBP: intPtr(120)}},
+		{"implausible temperature", CreateVitalSignsRequest{Temperature: floatPtr(98.6)}},
+		{"unknown unit", CreateVitalSignsRequest{Weight: floatPtr(60), WeightUnit: "stone"}},
+		{"spo2 over 100", CreateVitalSignsRequest{SpO2: intPtr(101)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.req.ToVitalSigns(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestComputeBMI(t *testing.T) {
+	if bmi := ComputeBMI(70, 175); bmi == nil || *bmi != 22.9 {
+		t.Errorf("ComputeBMI(70, 175) = %v, want 22.9", bmi)
+	}
+	if bmi := ComputeBMI(70, 0); bmi != nil {
+		t.Errorf("ComputeBMI with zero height = %v, want nil", *bmi)
+	}
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Channel identifies how a message is delivered
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+)
+
+// Message is an outbound notification to a single recipient
+type Message struct {
+	Channel Channel   `json:"channel"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject,omitempty"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Notifier delivers outbound messages (email, SMS)
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// LogNotifier records that a message was sent in the application log instead
+// of delivering it. Bodies carry reset tokens and claim codes, so only the
+// channel, recipient and subject are logged.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Send(msg Message) error {
+	log.Printf("[notify] %s to %s: %s (body withheld)", msg.Channel, msg.To, msg.Subject)
+	return nil
+}
+
+// FileNotifier appends messages as JSON lines to a file, for development and tests
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Send(msg Message) error {
+	if msg.SentAt.IsZero() {
+		msg.SentAt = time.Now()
+	}
+
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
+
+// New returns the notifier for the configured backend ("log" or "file")
+func New(backend, filePath string) Notifier {
+	switch backend {
+	case "file":
+		return NewFileNotifier(filePath)
+	default:
+		return NewLogNotifier()
+	}
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileNotifierAppendsMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	n := NewFileNotifier(path)
+
+	messages := []Message{
+		{Channel: ChannelEmail, To: "patient@example.com", Subject: "Reset", Body: "first"},
+		{Channel: ChannelSMS, To: "9800000000", Body: "second"},
+	}
+	for _, msg := range messages {
+		if err := n.Send(msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open notification file: %v", err)
+	}
+	defer f.Close()
+
+	var got []Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, msg)
+	}
+
+	if len(got) != len(messages) {
+		t.Fatalf("got %d messages, want %d", len(got), len(messages))
+	}
+	for i, msg := range got {
+		if msg.To != messages[i].To || msg.Body != messages[i].Body || msg.Channel != messages[i].Channel {
+			t.Errorf("message %d = %+v, want %+v", i, msg, messages[i])
+		}
+		if msg.SentAt.IsZero() {
+			t.Errorf("message %d has no sent_at timestamp", i)
+		}
+	}
+}
+
+func TestNewSelectsBackend(t *testing.T) {
+	if _, ok := New("file", "x.log").(*FileNotifier); !ok {
+		t.Error("New(\"file\") should return a FileNotifier")
+	}
+	if _, ok := New("log", "").(*LogNotifier); !ok {
+		t.Error("New(\"log\") should return a LogNotifier")
+	}
+	if _, ok := New("", "").(*LogNotifier); !ok {
+		t.Error("New(\"\") should default to a LogNotifier")
+	}
+}
+
+func TestLogNotifierWithholdsBody(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	msg := Message{Channel: ChannelEmail, To: "patient@example.com", Subject: "Password reset", Body: "Use this code: 123456"}
+	if err := NewLogNotifier().Send(msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, "123456") || !strings.Contains(out, "patient@example.com") {
+		t.Errorf("log output = %q, want the recipient without the body", out)
+	}
+}
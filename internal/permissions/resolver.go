@@ -0,0 +1,141 @@
+package permissions
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"rural_health_management_system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Resolver answers permission checks from the roles tables, caching the
+// effective permission set per role and clinic. Call Invalidate after any
+// change to roles or overrides; entries also expire after the TTL so that
+// other server instances pick up changes.
+type Resolver struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	permissions map[models.Permission]bool
+	loadedAt    time.Time
+}
+
+func NewResolver(db *gorm.DB, ttl time.Duration) *Resolver {
+	return &Resolver{
+		db:    db,
+		ttl:   ttl,
+		cache: make(map[string]cacheEntry),
+	}
+}
+
+// HasPermission reports whether a user type holds a permission, taking the
+// clinic's overrides into account when clinicID is set
+func (r *Resolver) HasPermission(userType string, clinicID *uint, permission models.Permission) bool {
+	perms, err := r.permissionSet(userType, clinicID)
+	if err != nil {
+		// Fall back to the built-in defaults if the roles tables are unavailable
+		log.Printf("Permission lookup failed for %s, using defaults: %v", userType, err)
+		return models.HasPermission(userType, nil, permission)
+	}
+	return perms[permission]
+}
+
+// Permissions returns the effective permissions of a user type in a clinic
+func (r *Resolver) Permissions(userType string, clinicID *uint) ([]models.Permission, error) {
+	perms, err := r.permissionSet(userType, clinicID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep a stable order for API responses
+	var result []models.Permission
+	for _, p := range models.AllPermissions {
+		if perms[p] {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// Invalidate drops all cached permission sets
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	r.cache = make(map[string]cacheEntry)
+	r.mu.Unlock()
+}
+
+func (r *Resolver) permissionSet(userType string, clinicID *uint) (map[models.Permission]bool, error) {
+	key := userType
+	if clinicID != nil {
+		key = fmt.Sprintf("%s:%d", userType, *clinicID)
+	}
+
+	r.mu.RLock()
+	entry, ok := r.cache[key]
+	r.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < r.ttl {
+		return entry.permissions, nil
+	}
+
+	perms, err := r.load(userType, clinicID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[key] = cacheEntry{permissions: perms, loadedAt: time.Now()}
+	r.mu.Unlock()
+
+	return perms, nil
+}
+
+func (r *Resolver) load(userType string, clinicID *uint) (map[models.Permission]bool, error) {
+	var role models.Role
+	if err := r.db.Preload("Permissions").Where("name = ?", userType).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// Unknown roles (patient, admin) hold no staff permissions
+			return map[models.Permission]bool{}, nil
+		}
+		return nil, err
+	}
+
+	var overrides []models.ClinicPermissionOverride
+	if clinicID != nil {
+		if err := r.db.Where("clinic_id = ? AND role_id = ?", *clinicID, role.ID).Find(&overrides).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	defaults := make([]models.Permission, 0, len(role.Permissions))
+	for _, rp := range role.Permissions {
+		defaults = append(defaults, rp.Permission)
+	}
+
+	return EffectivePermissions(defaults, overrides), nil
+}
+
+// EffectivePermissions applies clinic overrides on top of a role's default grants
+func EffectivePermissions(defaults []models.Permission, overrides []models.ClinicPermissionOverride) map[models.Permission]bool {
+	perms := make(map[models.Permission]bool, len(defaults))
+	for _, p := range defaults {
+		perms[p] = true
+	}
+
+	for _, o := range overrides {
+		if o.Granted {
+			perms[o.Permission] = true
+		} else {
+			delete(perms, o.Permission)
+		}
+	}
+
+	return perms
+}
@@ -0,0 +1,60 @@
+package permissions
+
+import (
+	"testing"
+
+	"rural_health_management_system/internal/models"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	defaults := []models.Permission{models.PermissionViewPatient, models.PermissionCreateVisit}
+
+	tests := []struct {
+		name      string
+		overrides []models.ClinicPermissionOverride
+		expected  map[models.Permission]bool
+	}{
+		{
+			name:      "No overrides keeps defaults",
+			overrides: nil,
+			expected: map[models.Permission]bool{
+				models.PermissionViewPatient: true,
+				models.PermissionCreateVisit: true,
+			},
+		},
+		{
+			name: "Grant adds a permission",
+			overrides: []models.ClinicPermissionOverride{
+				{Permission: models.PermissionCreatePrescription, Granted: true},
+			},
+			expected: map[models.Permission]bool{
+				models.PermissionViewPatient:        true,
+				models.PermissionCreateVisit:        true,
+				models.PermissionCreatePrescription: true,
+			},
+		},
+		{
+			name: "Revoke removes a default",
+			overrides: []models.ClinicPermissionOverride{
+				{Permission: models.PermissionCreateVisit, Granted: false},
+			},
+			expected: map[models.Permission]bool{
+				models.PermissionViewPatient: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EffectivePermissions(defaults, tt.overrides)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("EffectivePermissions() = %v, want %v", result, tt.expected)
+			}
+			for p := range tt.expected {
+				if !result[p] {
+					t.Errorf("expected permission %s to be granted", p)
+				}
+			}
+		})
+	}
+}
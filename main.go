@@ -8,6 +8,8 @@ import (
 	"rural_health_management_system/internal/database"
 	"rural_health_management_system/internal/handlers"
 	"rural_health_management_system/internal/models"
+	"rural_health_management_system/internal/notify"
+	"rural_health_management_system/internal/permissions"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -22,8 +24,23 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer db.Close()
+
+	// Outbound email/SMS notifications
+	notifier := notify.New(cfg.Notifier, cfg.NotifierFilePath)
+	if _, ok := notifier.(*notify.LogNotifier); ok && cfg.Environment == "production" {
+		log.Println("Warning: the log notifier does not deliver messages; password reset and claim codes will not reach users")
+	}
+
+	// Database-backed role permissions
+	permissionResolver := permissions.NewResolver(db.DB, cfg.PermissionCacheTTL)
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db.DB, cfg.JWTSecret)
+	authHandler := handlers.NewAuthHandler(db.DB, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.MFAChallengeTTL, cfg.MFAIssuer,
+		models.LockoutPolicy{MaxAttempts: cfg.LoginMaxAttempts, BaseDuration: cfg.LoginLockout, MaxDuration: cfg.LoginLockoutMax},
+		notifier, permissionResolver)
+	roleHandler := handlers.NewRoleHandler(db.DB, permissionResolver)
+	passwordResetHandler := handlers.NewPasswordResetHandler(db.DB, notifier, cfg.PasswordResetTTL)
+	claimHandler := handlers.NewClaimHandler(db.DB, authHandler, notifier, cfg.ClaimCodeTTL)
 	clinicHandler := handlers.NewClinicHandler(db.DB)
 	patientHandler := handlers.NewPatientHandler(db.DB)
 	staffHandler := handlers.NewStaffHandler(db.DB)
@@ -36,11 +53,67 @@ func main() {
 	// New separate portal handlers
 	staffPortalHandler := handlers.NewStaffPortalHandler(db.DB)
 	medicalPortalHandler := handlers.NewMedicalPortalHandler(db.DB)
+	pharmacyPortalHandler := handlers.NewPharmacyPortalHandler(db.DB)
+	auditHandler := handlers.NewAuditHandler(db.DB)
+	appointmentHandler := handlers.NewAppointmentHandler(db.DB)
+	formularyHandler := handlers.NewFormularyHandler(db.DB)
+	interactionHandler := handlers.NewInteractionHandler(db.DB, cfg.DrugInteractionsFile)
+	inventoryHandler := handlers.NewInventoryHandler(db.DB)
+	dispensingHandler := handlers.NewDispensingHandler(db.DB)
+	referralHandler := handlers.NewReferralHandler(db.DB)
+	transferHandler := handlers.NewTransferHandler(db.DB)
+	duplicateHandler := handlers.NewDuplicateHandler(db.DB)
+	patientLookupHandler := handlers.NewPatientLookupHandler(db.DB)
+	delegationHandler := handlers.NewDelegationHandler(db.DB)
+	immunizationHandler := handlers.NewImmunizationHandler(db.DB, cfg.ImmunizationScheduleFile)
+	maternalHandler := handlers.NewMaternalHandler(db.DB)
+	growthHandler := handlers.NewGrowthHandler(db.DB, cfg.GrowthStandardsFile)
+	labHandler := handlers.NewLabHandler(db.DB, cfg.LabCatalogFile)
+	icd10Handler := handlers.NewICD10Handler(db.DB, cfg.ICD10CodesFile)
 	// Dashboard analytics handler
 	dashboardAnalyticsHandler := handlers.NewDashboardAnalyticsHandler(db.DB)
 
+	// Drug-drug interaction rules from the bundled or configured file
+	if count, err := interactionHandler.Import(); err != nil {
+		log.Printf("Warning: failed to import drug interactions from %s: %v", cfg.DrugInteractionsFile, err)
+	} else {
+		log.Printf("Imported %d drug interaction rules from %s", count, cfg.DrugInteractionsFile)
+	}
+
+	// Vaccine schedule from the bundled or configured file
+	if count, err := immunizationHandler.Import(); err != nil {
+		log.Printf("Warning: failed to import immunization schedule from %s: %v", cfg.ImmunizationScheduleFile, err)
+	} else {
+		log.Printf("Imported %d vaccines from %s", count, cfg.ImmunizationScheduleFile)
+	}
+
+	// WHO growth standards for under-5 z-scores
+	if err := growthHandler.Load(); err != nil {
+		log.Printf("Warning: failed to load growth standards from %s: %v", cfg.GrowthStandardsFile, err)
+	}
+
+	// Lab test catalog from the bundled or configured file
+	if count, err := labHandler.Import(); err != nil {
+		log.Printf("Warning: failed to import lab catalog from %s: %v", cfg.LabCatalogFile, err)
+	} else {
+		log.Printf("Imported %d lab tests from %s", count, cfg.LabCatalogFile)
+	}
+
+	// ICD-10 catalog that diagnosis codes are validated against
+	if count, err := icd10Handler.Import(); err != nil {
+		log.Printf("Warning: failed to import ICD-10 codes from %s: %v", cfg.ICD10CodesFile, err)
+	} else {
+		log.Printf("Imported %d ICD-10 codes from %s", count, cfg.ICD10CodesFile)
+	}
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
+		// The client IP (rate limits, audit and auth logs) is read from
+		// ProxyHeader only on requests arriving through a trusted proxy
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableIPValidation:      true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -61,12 +134,21 @@ func main() {
 	// API version 1 routes
 	v1 := app.Group("/api/v1")
 
+	// Per-IP rate limit shared by registration and the endpoints that check a
+	// password, code or token
+	authLimiter := handlers.AuthRateLimiter(cfg.AuthRateLimit, cfg.AuthRateLimitWindow)
+
 	// Authentication routes (public)
 	auth := v1.Group("/auth")
-	auth.Post("/register/patient", authHandler.RegisterPatient)
-	auth.Post("/register/clinic", authHandler.RegisterClinic)
-	auth.Post("/login", authHandler.Login)
-	auth.Post("/clinic-login", authHandler.ClinicLogin) // New clinic-specific login
+	auth.Post("/register/patient", authLimiter, authHandler.RegisterPatient)
+	auth.Post("/register/clinic", authLimiter, authHandler.RegisterClinic)
+	auth.Post("/login", authLimiter, authHandler.Login)
+	auth.Post("/clinic-login", authLimiter, authHandler.ClinicLogin)           // New clinic-specific login
+	auth.Post("/mfa/challenge", authLimiter, authHandler.CompleteMFAChallenge) // Second login step for users with MFA
+	auth.Post("/refresh", authLimiter, authHandler.RefreshToken)
+	auth.Post("/password-reset/request", authLimiter, passwordResetHandler.RequestReset)
+	auth.Post("/password-reset/confirm", authLimiter, passwordResetHandler.ConfirmReset)
+	auth.Post("/claim", authLimiter, claimHandler.ClaimAccount) // Link a new login to a staff-registered patient
 	auth.Get("/clinics", clinicHandler.GetClinics)
 
 	// Public Dashboard Analytics (not protected)
@@ -75,8 +157,14 @@ func main() {
 
 	// Protected routes - require authentication
 	protected := v1.Group("/", authHandler.AuthMiddleware)
-	protected.Post("/auth/change-password", authHandler.ChangePassword)
+	protected.Post("/auth/change-password", authLimiter, authHandler.ChangePassword)
+	protected.Post("/auth/logout", authHandler.Logout)
 	protected.Get("/auth/profile", authHandler.GetProfile)
+	protected.Get("/auth/mfa/status", authHandler.GetMFAStatus)
+	protected.Post("/auth/mfa/enroll", authHandler.EnrollMFA)
+	protected.Post("/auth/mfa/verify", authHandler.VerifyMFA)
+	protected.Post("/auth/mfa/disable", authHandler.DisableMFA)
+	protected.Post("/auth/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
 	protected.Post("/auth/register/staff", authHandler.RequireUserType("clinic_staff"), authHandler.RegisterStaff) // Only clinic staff can register staff
 	// Patient Portal routes (patient access only)
 	patientPortal := v1.Group("/portal/patient", authHandler.AuthMiddleware, authHandler.RequireUserType("patient"))
@@ -86,6 +174,15 @@ func main() {
 	patientPortal.Get("/visits/:id", patientPortalHandler.GetMyVisit)
 	patientPortal.Get("/diagnoses", patientPortalHandler.GetMyDiagnoses)
 	patientPortal.Get("/prescriptions", patientPortalHandler.GetMyPrescriptions)
+	patientPortal.Get("/access-log", auditHandler.GetMyAccessLog)
+	patientPortal.Get("/appointments", appointmentHandler.GetMyAppointments)
+	patientPortal.Post("/appointments", appointmentHandler.RequestAppointment)
+	patientPortal.Put("/appointments/:id/cancel", appointmentHandler.CancelMyAppointment)
+	patientPortal.Get("/availability", appointmentHandler.GetAvailability)
+	patientPortal.Get("/delegations", delegationHandler.GetMyDelegations)
+	patientPortal.Delete("/delegations/:id", delegationHandler.RevokeMyDelegation)
+	patientPortal.Get("/immunizations", immunizationHandler.GetMyImmunizations)
+	patientPortal.Get("/immunizations/upcoming", immunizationHandler.GetMyUpcomingImmunizations)
 
 	// Clinic Portal routes (clinic access only) - DEPRECATED, use staff or medical portals
 	clinicPortal := v1.Group("/portal/clinic", authHandler.AuthMiddleware, authHandler.RequireUserType("clinic_staff"))
@@ -94,6 +191,7 @@ func main() {
 	clinicPortal.Get("/dashboard", clinicPortalHandler.GetDashboardStats)
 	clinicPortal.Get("/dashboard/content", dashboardAnalyticsHandler.GetClinicDashboard) // Comprehensive analytics
 	clinicPortal.Get("/patients", clinicPortalHandler.GetMyPatients)
+	clinicPortal.Get("/patients/mrn/:mrn", patientLookupHandler.GetPatientByMRN)
 	clinicPortal.Get("/patients/:id", clinicPortalHandler.GetMyPatient)
 	clinicPortal.Get("/staff", clinicPortalHandler.GetMyStaff)
 	clinicPortal.Post("/staff", clinicPortalHandler.CreateStaff)
@@ -103,10 +201,11 @@ func main() {
 	clinicPortal.Post("/diagnoses", clinicPortalHandler.CreateDiagnosis)
 	clinicPortal.Post("/prescriptions", clinicPortalHandler.CreatePrescription)
 
-	// Staff Portal routes (clinic staff only) - NEW
-	staffPortal := v1.Group("/portal/staff", authHandler.AuthMiddleware, authHandler.RequireUserType("clinic_staff"), authHandler.ValidateClinicOwnership())
+	// Staff Portal routes (clinic owner and clinic administrators) - NEW
+	staffPortal := v1.Group("/portal/staff", authHandler.AuthMiddleware, authHandler.RequireUserType("clinic_staff", "clinic_admin"), authHandler.ValidateClinicOwnership())
 	staffPortal.Get("/profile", staffPortalHandler.GetMyProfile)
-	staffPortal.Put("/profile", staffPortalHandler.UpdateMyProfile)
+	staffPortal.Put("/profile", authHandler.RequirePermission(models.PermissionManageClinic), staffPortalHandler.UpdateMyProfile)
+	staffPortal.Put("/mfa-policy", authHandler.RequirePermission(models.PermissionManageClinic), authHandler.SetClinicMFAPolicy)
 	staffPortal.Get("/dashboard", staffPortalHandler.GetDashboardStats)
 	staffPortal.Get("/dashboard/analytics", dashboardAnalyticsHandler.GetClinicDashboard)
 	staffPortal.Get("/dashboard/content", dashboardAnalyticsHandler.GetClinicDashboard) // Alternative route name
@@ -114,17 +213,74 @@ func main() {
 	// Patient management (staff only)
 	staffPortal.Post("/patients", authHandler.RequirePermission(models.PermissionCreatePatient), staffPortalHandler.CreatePatient)
 	staffPortal.Get("/patients", authHandler.RequirePermission(models.PermissionViewPatient), staffPortalHandler.GetMyPatients)
+	staffPortal.Get("/patients/duplicates", authHandler.RequirePermission(models.PermissionViewPatient), duplicateHandler.GetClinicDuplicates)
+	staffPortal.Get("/patients/mrn/:mrn", authHandler.RequirePermission(models.PermissionViewPatient), patientLookupHandler.GetPatientByMRN)
 	staffPortal.Get("/patients/:id", authHandler.RequirePermission(models.PermissionViewPatient), staffPortalHandler.GetMyPatient)
+	staffPortal.Post("/patients/:id/transfer", authHandler.RequirePermission(models.PermissionUpdatePatient), transferHandler.TransferPatient)
+	staffPortal.Get("/patients/:id/transfers", authHandler.RequirePermission(models.PermissionViewPatient), transferHandler.GetPatientTransfers)
+	staffPortal.Get("/patients/:id/duplicates", authHandler.RequirePermission(models.PermissionViewPatient), duplicateHandler.GetPatientDuplicates)
+	staffPortal.Post("/patients/:id/merge", authHandler.RequirePermission(models.PermissionDeletePatient), duplicateHandler.MergePatients)
+	staffPortal.Post("/patients/:id/claim-code", authHandler.RequirePermission(models.PermissionUpdatePatient), claimHandler.IssueClaimCode)
+	staffPortal.Get("/patients/:id/delegations", authHandler.RequirePermission(models.PermissionViewPatient), delegationHandler.GetPatientDelegations)
+	staffPortal.Post("/patients/:id/delegations", authHandler.RequirePermission(models.PermissionUpdatePatient), delegationHandler.CreateDelegation)
+	staffPortal.Delete("/patients/:id/delegations/:delegationId", authHandler.RequirePermission(models.PermissionUpdatePatient), delegationHandler.RevokeDelegation)
 
 	// Staff management (staff only)
 	staffPortal.Post("/staff", authHandler.RequirePermission(models.PermissionCreateStaff), staffPortalHandler.CreateStaff)
 	staffPortal.Get("/staff", authHandler.RequirePermission(models.PermissionViewStaff), staffPortalHandler.GetMyStaff)
+	staffPortal.Get("/roles", authHandler.RequirePermission(models.PermissionViewStaff), roleHandler.GetMyClinicRoles)
+	staffPortal.Get("/staff/:id/working-hours", authHandler.RequirePermission(models.PermissionViewStaff), appointmentHandler.GetStaffWorkingHours)
+	staffPortal.Put("/staff/:id/working-hours", authHandler.RequirePermission(models.PermissionUpdateStaff), appointmentHandler.SetStaffWorkingHours)
 
 	// Visit management (staff can create, view all)
 	staffPortal.Post("/visits", authHandler.RequirePermission(models.PermissionCreateVisit), staffPortalHandler.CreateVisit)
 	staffPortal.Get("/visits", authHandler.RequirePermission(models.PermissionViewVisit), staffPortalHandler.GetMyVisits)
 	staffPortal.Get("/visits/:id", authHandler.RequirePermission(models.PermissionViewVisit), staffPortalHandler.GetMyVisit)
 
+	// Appointment scheduling (staff manage the whole clinic's schedule)
+	staffPortal.Get("/schedule", authHandler.RequirePermission(models.PermissionViewVisit), appointmentHandler.GetSchedule)
+	staffPortal.Get("/availability", authHandler.RequirePermission(models.PermissionViewVisit), appointmentHandler.GetAvailability)
+	staffPortal.Post("/appointments", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.CreateAppointment)
+	staffPortal.Put("/appointments/:id/check-in", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.CheckInAppointment)
+	staffPortal.Put("/appointments/:id/cancel", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.CancelAppointment)
+	staffPortal.Put("/appointments/:id/no-show", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.MarkNoShow)
+
+	// Clinic formulary (clinic managers maintain the clinic's own items)
+	staffPortal.Get("/formulary", authHandler.RequirePermission(models.PermissionViewPrescription), formularyHandler.GetFormulary)
+	staffPortal.Get("/formulary/frequencies", authHandler.RequirePermission(models.PermissionViewPrescription), formularyHandler.GetFrequencies)
+	staffPortal.Post("/formulary", authHandler.RequirePermission(models.PermissionManageClinic), formularyHandler.CreateFormularyItem)
+	staffPortal.Put("/formulary/:id", authHandler.RequirePermission(models.PermissionManageClinic), formularyHandler.UpdateFormularyItem)
+	staffPortal.Delete("/formulary/:id", authHandler.RequirePermission(models.PermissionManageClinic), formularyHandler.DeleteFormularyItem)
+
+	// Inter-clinic referrals (sent and received)
+	staffPortal.Post("/referrals", authHandler.RequirePermission(models.PermissionCreateVisit), referralHandler.CreateReferral)
+	staffPortal.Get("/referrals/incoming", authHandler.RequirePermission(models.PermissionViewVisit), referralHandler.GetIncomingReferrals)
+	staffPortal.Get("/referrals/outgoing", authHandler.RequirePermission(models.PermissionViewVisit), referralHandler.GetOutgoingReferrals)
+	staffPortal.Get("/referrals/:id", authHandler.RequirePermission(models.PermissionViewVisit), referralHandler.GetReferral)
+	staffPortal.Put("/referrals/:id/status", authHandler.RequirePermission(models.PermissionUpdateVisit), referralHandler.UpdateReferralStatus)
+
+	// Pharmacy inventory (stock items, batches, receipts, adjustments and ledger)
+	inventory := staffPortal.Group("/inventory", authHandler.RequirePermission(models.PermissionManageInventory))
+	inventory.Get("/items", inventoryHandler.GetStockItems)
+	inventory.Post("/items", inventoryHandler.CreateStockItem)
+	inventory.Get("/items/:id", inventoryHandler.GetStockItem)
+	inventory.Put("/items/:id", inventoryHandler.UpdateStockItem)
+	inventory.Get("/receipts", inventoryHandler.GetStockReceipts)
+	inventory.Post("/receipts", inventoryHandler.ReceiveStock)
+	inventory.Post("/adjustments", inventoryHandler.AdjustStock)
+	inventory.Get("/ledger", inventoryHandler.GetStockLedger)
+	inventory.Get("/low-stock", inventoryHandler.GetLowStock)
+	inventory.Get("/near-expiry", inventoryHandler.GetNearExpiry)
+
+	// Immunizations (outreach due list and vaccination card entry)
+	staffPortal.Get("/immunizations/schedule", authHandler.RequirePermission(models.PermissionViewPatient), immunizationHandler.GetSchedule)
+	staffPortal.Get("/immunizations/due", authHandler.RequirePermission(models.PermissionViewPatient), immunizationHandler.GetDueList)
+	staffPortal.Get("/patients/:id/immunizations", authHandler.RequirePermission(models.PermissionViewPatient), immunizationHandler.GetPatientImmunizations)
+	staffPortal.Post("/patients/:id/immunizations", authHandler.RequirePermission(models.PermissionCreateVisit), immunizationHandler.RecordImmunization)
+
+	// Audit trail of patient record access within the clinic
+	staffPortal.Get("/audit-logs", authHandler.RequirePermission(models.PermissionViewReports), auditHandler.GetClinicAuditLogs)
+
 	// Medical Portal routes (doctors and nurses only) - NEW
 	medicalPortal := v1.Group("/portal/medical", authHandler.AuthMiddleware, authHandler.RequireUserType("doctor", "nurse"), authHandler.ValidateClinicOwnership())
 	medicalPortal.Get("/profile", medicalPortalHandler.GetMyProfile)
@@ -135,6 +291,7 @@ func main() {
 
 	// Patient access (medical staff can view)
 	medicalPortal.Get("/patients", authHandler.RequirePermission(models.PermissionViewPatient), medicalPortalHandler.GetMyPatients)
+	medicalPortal.Get("/patients/mrn/:mrn", authHandler.RequirePermission(models.PermissionViewPatient), patientLookupHandler.GetPatientByMRN)
 	medicalPortal.Get("/patients/:id", authHandler.RequirePermission(models.PermissionViewPatient), medicalPortalHandler.GetMyPatient)
 
 	// Staff access (medical staff can view)
@@ -145,16 +302,112 @@ func main() {
 	medicalPortal.Get("/visits", authHandler.RequirePermission(models.PermissionViewVisit), medicalPortalHandler.GetMyVisits)
 	medicalPortal.Get("/visits/:id", authHandler.RequirePermission(models.PermissionViewVisit), medicalPortalHandler.GetMyVisit)
 
+	// Vital signs (doctors and nurses record, view trends)
+	medicalPortal.Post("/visits/:id/vitals", authHandler.RequirePermission(models.PermissionUpdateVisit), medicalPortalHandler.CreateVitalSigns)
+	medicalPortal.Get("/patients/:id/vitals", authHandler.RequirePermission(models.PermissionViewPatient), medicalPortalHandler.GetPatientVitals)
+
+	// Allergies and problem list (doctors and nurses)
+	medicalPortal.Get("/patients/:id/allergies", authHandler.RequirePermission(models.PermissionViewPatient), medicalPortalHandler.GetPatientAllergies)
+	medicalPortal.Post("/patients/:id/allergies", authHandler.RequirePermission(models.PermissionUpdateVisit), medicalPortalHandler.CreatePatientAllergy)
+	medicalPortal.Put("/allergies/:id", authHandler.RequirePermission(models.PermissionUpdateVisit), medicalPortalHandler.UpdatePatientAllergy)
+	medicalPortal.Get("/patients/:id/problems", authHandler.RequirePermission(models.PermissionViewPatient), medicalPortalHandler.GetPatientProblems)
+	medicalPortal.Post("/patients/:id/problems", authHandler.RequirePermission(models.PermissionUpdateVisit), medicalPortalHandler.CreatePatientProblem)
+	medicalPortal.Put("/problems/:id", authHandler.RequirePermission(models.PermissionUpdateVisit), medicalPortalHandler.UpdatePatientProblem)
+
+	// Immunizations (doctors and nurses record doses given)
+	medicalPortal.Get("/immunizations/schedule", authHandler.RequirePermission(models.PermissionViewPatient), immunizationHandler.GetSchedule)
+	medicalPortal.Get("/immunizations/due", authHandler.RequirePermission(models.PermissionViewPatient), immunizationHandler.GetDueList)
+	medicalPortal.Get("/patients/:id/immunizations", authHandler.RequirePermission(models.PermissionViewPatient), immunizationHandler.GetPatientImmunizations)
+	medicalPortal.Post("/patients/:id/immunizations", authHandler.RequirePermission(models.PermissionUpdateVisit), immunizationHandler.RecordImmunization)
+
+	// Maternal care (pregnancy episodes, antenatal contacts, outcome and postnatal visits)
+	medicalPortal.Get("/maternal/watchlist", authHandler.RequirePermission(models.PermissionViewPatient), maternalHandler.GetWatchList)
+	medicalPortal.Get("/patients/:id/pregnancies", authHandler.RequirePermission(models.PermissionViewPatient), maternalHandler.GetPatientPregnancies)
+	medicalPortal.Post("/patients/:id/pregnancies", authHandler.RequirePermission(models.PermissionUpdateVisit), maternalHandler.RegisterPregnancy)
+	medicalPortal.Get("/pregnancies/:id", authHandler.RequirePermission(models.PermissionViewPatient), maternalHandler.GetPregnancy)
+	medicalPortal.Put("/pregnancies/:id", authHandler.RequirePermission(models.PermissionUpdateVisit), maternalHandler.UpdatePregnancy)
+	medicalPortal.Post("/pregnancies/:id/anc-contacts", authHandler.RequirePermission(models.PermissionUpdateVisit), maternalHandler.RecordANCContact)
+	medicalPortal.Put("/pregnancies/:id/outcome", authHandler.RequirePermission(models.PermissionUpdateVisit), maternalHandler.RecordOutcome)
+	medicalPortal.Post("/pregnancies/:id/postnatal-visits", authHandler.RequirePermission(models.PermissionUpdateVisit), maternalHandler.RecordPostnatalVisit)
+
+	// Child growth monitoring (under-5 z-scores and malnutrition flags)
+	medicalPortal.Get("/patients/:id/growth", authHandler.RequirePermission(models.PermissionViewPatient), growthHandler.GetGrowthSeries)
+	medicalPortal.Post("/patients/:id/growth", authHandler.RequirePermission(models.PermissionUpdateVisit), growthHandler.RecordGrowth)
+
+	// Laboratory (catalog, orders per visit, clinic worklist and results)
+	medicalPortal.Get("/lab/tests", authHandler.RequirePermission(models.PermissionViewVisit), labHandler.GetCatalog)
+	medicalPortal.Get("/lab/worklist", authHandler.RequirePermission(models.PermissionViewVisit), labHandler.GetWorklist)
+	medicalPortal.Get("/visits/:id/lab-orders", authHandler.RequirePermission(models.PermissionViewVisit), labHandler.GetVisitLabOrders)
+	medicalPortal.Post("/visits/:id/lab-orders", authHandler.RequirePermission(models.PermissionUpdateVisit), labHandler.CreateLabOrders)
+	medicalPortal.Get("/lab-orders/:id", authHandler.RequirePermission(models.PermissionViewVisit), labHandler.GetLabOrder)
+	medicalPortal.Put("/lab-orders/:id/collect", authHandler.RequirePermission(models.PermissionUpdateVisit), labHandler.CollectSpecimen)
+	medicalPortal.Put("/lab-orders/:id/results", authHandler.RequirePermission(models.PermissionUpdateVisit), labHandler.RecordResults)
+	medicalPortal.Put("/lab-orders/:id/cancel", authHandler.RequirePermission(models.PermissionUpdateVisit), labHandler.CancelLabOrder)
+
+	// ICD-10 code search for diagnosis entry
+	medicalPortal.Get("/icd10/search", authHandler.RequirePermission(models.PermissionViewDiagnosis), icd10Handler.SearchCodes)
+	medicalPortal.Get("/icd10/chapters", authHandler.RequirePermission(models.PermissionViewDiagnosis), icd10Handler.GetChapters)
+
+	// Inter-clinic referrals (sent and received)
+	medicalPortal.Post("/referrals", authHandler.RequirePermission(models.PermissionCreateVisit), referralHandler.CreateReferral)
+	medicalPortal.Get("/referrals/incoming", authHandler.RequirePermission(models.PermissionViewVisit), referralHandler.GetIncomingReferrals)
+	medicalPortal.Get("/referrals/outgoing", authHandler.RequirePermission(models.PermissionViewVisit), referralHandler.GetOutgoingReferrals)
+	medicalPortal.Get("/referrals/:id", authHandler.RequirePermission(models.PermissionViewVisit), referralHandler.GetReferral)
+	medicalPortal.Put("/referrals/:id/status", authHandler.RequirePermission(models.PermissionUpdateVisit), referralHandler.UpdateReferralStatus)
+
+	// Own appointment schedule (doctors and nurses)
+	medicalPortal.Get("/schedule", authHandler.RequirePermission(models.PermissionViewVisit), appointmentHandler.GetSchedule)
+	medicalPortal.Get("/availability", authHandler.RequirePermission(models.PermissionViewVisit), appointmentHandler.GetAvailability)
+	medicalPortal.Get("/working-hours", authHandler.RequirePermission(models.PermissionViewVisit), appointmentHandler.GetStaffWorkingHours)
+	medicalPortal.Post("/appointments", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.CreateAppointment)
+	medicalPortal.Put("/appointments/:id/check-in", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.CheckInAppointment)
+	medicalPortal.Put("/appointments/:id/cancel", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.CancelAppointment)
+	medicalPortal.Put("/appointments/:id/no-show", authHandler.RequirePermission(models.PermissionCreateVisit), appointmentHandler.MarkNoShow)
+
 	// Medical actions (doctors only)
 	medicalPortal.Post("/diagnoses", authHandler.RequireDoctorAccess(), authHandler.RequirePermission(models.PermissionCreateDiagnosis), medicalPortalHandler.CreateDiagnosis)
 	medicalPortal.Post("/prescriptions", authHandler.RequireDoctorAccess(), authHandler.RequirePermission(models.PermissionCreatePrescription), medicalPortalHandler.CreatePrescription)
 
+	// Active medications (the list new prescriptions are checked against for interactions)
+	medicalPortal.Get("/patients/:id/medications", authHandler.RequirePermission(models.PermissionViewPrescription), medicalPortalHandler.GetPatientMedications)
+
+	// Dispensing (nurses issue medicine where there is no pharmacist). It draws
+	// down stock, so the clinic must grant its nurses manage_inventory.
+	medicalPortal.Post("/prescriptions/:id/dispense", authHandler.RequireUserType("nurse"), authHandler.RequirePermission(models.PermissionManageInventory), dispensingHandler.DispensePrescription)
+	medicalPortal.Get("/prescriptions/:id/dispensations", authHandler.RequirePermission(models.PermissionViewPrescription), dispensingHandler.GetPrescriptionDispensations)
+
+	// Formulary lookup for structured prescribing
+	medicalPortal.Get("/formulary", authHandler.RequirePermission(models.PermissionViewPrescription), formularyHandler.GetFormulary)
+	medicalPortal.Get("/formulary/frequencies", authHandler.RequirePermission(models.PermissionViewPrescription), formularyHandler.GetFrequencies)
+
 	// Medical data access (doctors and nurses can view their own patients' data)
 	medicalPortal.Get("/diagnoses", authHandler.RequirePermission(models.PermissionViewDiagnosis), medicalPortalHandler.GetMyDiagnoses)
 	medicalPortal.Get("/diagnoses/:id", authHandler.RequirePermission(models.PermissionViewDiagnosis), medicalPortalHandler.GetMyDiagnosis)
 	medicalPortal.Get("/prescriptions", authHandler.RequirePermission(models.PermissionViewPrescription), medicalPortalHandler.GetMyPrescriptions)
 	medicalPortal.Get("/prescriptions/:id", authHandler.RequirePermission(models.PermissionViewPrescription), medicalPortalHandler.GetMyPrescription)
 
+	// Pharmacy Portal routes (pharmacists only)
+	pharmacyPortal := v1.Group("/portal/pharmacy", authHandler.AuthMiddleware, authHandler.RequireUserType("pharmacist"), authHandler.ValidateClinicOwnership())
+	pharmacyPortal.Get("/profile", pharmacyPortalHandler.GetMyProfile)
+	pharmacyPortal.Get("/prescriptions", authHandler.RequirePermission(models.PermissionViewPrescription), pharmacyPortalHandler.GetPrescriptions)
+	pharmacyPortal.Get("/prescriptions/:id", authHandler.RequirePermission(models.PermissionViewPrescription), pharmacyPortalHandler.GetPrescription)
+	pharmacyPortal.Post("/prescriptions/:id/dispense", authHandler.RequirePermission(models.PermissionManageInventory), dispensingHandler.DispensePrescription)
+	pharmacyPortal.Get("/prescriptions/:id/dispensations", authHandler.RequirePermission(models.PermissionViewPrescription), dispensingHandler.GetPrescriptionDispensations)
+	pharmacyPortal.Get("/formulary", authHandler.RequirePermission(models.PermissionViewPrescription), formularyHandler.GetFormulary)
+
+	// Pharmacists manage the clinic's stock
+	pharmacyInventory := pharmacyPortal.Group("/inventory", authHandler.RequirePermission(models.PermissionManageInventory))
+	pharmacyInventory.Get("/items", inventoryHandler.GetStockItems)
+	pharmacyInventory.Post("/items", inventoryHandler.CreateStockItem)
+	pharmacyInventory.Get("/items/:id", inventoryHandler.GetStockItem)
+	pharmacyInventory.Put("/items/:id", inventoryHandler.UpdateStockItem)
+	pharmacyInventory.Get("/receipts", inventoryHandler.GetStockReceipts)
+	pharmacyInventory.Post("/receipts", inventoryHandler.ReceiveStock)
+	pharmacyInventory.Post("/adjustments", inventoryHandler.AdjustStock)
+	pharmacyInventory.Get("/ledger", inventoryHandler.GetStockLedger)
+	pharmacyInventory.Get("/low-stock", inventoryHandler.GetLowStock)
+	pharmacyInventory.Get("/near-expiry", inventoryHandler.GetNearExpiry)
+
 	// Admin/System routes - require authentication and admin permissions
 	admin := v1.Group("/", authHandler.AuthMiddleware, authHandler.RequireUserType("admin"))
 
@@ -163,15 +416,25 @@ func main() {
 	clinics.Get("/:id", clinicHandler.GetClinic)
 	clinics.Post("/", clinicHandler.CreateClinic)
 	clinics.Put("/:id", clinicHandler.UpdateClinic)
+	clinics.Put("/:id/mfa-policy", authHandler.SetClinicMFAPolicy)
 	clinics.Delete("/:id", clinicHandler.DeleteClinic)
 
 	// Patient routes (admin only for system management)
 	patients := admin.Group("/patients")
 	patients.Get("/", patientHandler.GetPatients)
+	patients.Get("/mrn/:mrn", patientLookupHandler.GetPatientByMRN)
 	patients.Get("/:id", patientHandler.GetPatient)
 	patients.Post("/", patientHandler.CreatePatient)
 	patients.Put("/:id", patientHandler.UpdatePatient)
 	patients.Delete("/:id", patientHandler.DeletePatient)
+	patients.Post("/:id/transfer", transferHandler.TransferPatient)
+	patients.Get("/:id/transfers", transferHandler.GetPatientTransfers)
+	patients.Get("/:id/duplicates", duplicateHandler.GetPatientDuplicates)
+	patients.Post("/:id/merge", duplicateHandler.MergePatients)
+	patients.Post("/:id/claim-code", claimHandler.IssueClaimCode)
+	patients.Get("/:id/delegations", delegationHandler.GetPatientDelegations)
+	patients.Post("/:id/delegations", delegationHandler.CreateDelegation)
+	patients.Delete("/:id/delegations/:delegationId", delegationHandler.RevokeDelegation)
 
 	// Staff routes (admin only for system management)
 	staff := admin.Group("/staff")
@@ -197,6 +460,15 @@ func main() {
 	diagnoses.Put("/:id", diagnosisHandler.UpdateDiagnosis)
 	diagnoses.Delete("/:id", diagnosisHandler.DeleteDiagnosis)
 
+	// Role and permission routes (admin only for system management)
+	admin.Get("/permissions", roleHandler.GetPermissions)
+	roles := admin.Group("/roles")
+	roles.Get("/", roleHandler.GetRoles)
+	roles.Get("/:id", roleHandler.GetRole)
+	roles.Put("/:id/permissions", roleHandler.UpdateRolePermissions)
+	roles.Put("/:id/overrides", roleHandler.SetPermissionOverride)
+	roles.Delete("/:id/overrides/:override_id", roleHandler.DeletePermissionOverride)
+
 	// Prescription routes (admin only for system management)
 	prescriptions := admin.Group("/prescriptions")
 	prescriptions.Get("/", prescriptionHandler.GetPrescriptions)
@@ -205,6 +477,31 @@ func main() {
 	prescriptions.Put("/:id", prescriptionHandler.UpdatePrescription)
 	prescriptions.Delete("/:id", prescriptionHandler.DeletePrescription)
 
+	// Formulary routes (admin manages the system formulary)
+	formulary := admin.Group("/formulary")
+	formulary.Get("/", formularyHandler.GetFormulary)
+	formulary.Get("/frequencies", formularyHandler.GetFrequencies)
+	formulary.Post("/", formularyHandler.CreateFormularyItem)
+	formulary.Put("/:id", formularyHandler.UpdateFormularyItem)
+	formulary.Delete("/:id", formularyHandler.DeleteFormularyItem)
+
+	// Drug interaction rules (admin only)
+	admin.Get("/drug-interactions", interactionHandler.GetInteractions)
+	admin.Post("/drug-interactions/reload", interactionHandler.ReloadInteractions)
+
+	// Vaccine schedule and duplicate dose reconciliation (admin only)
+	admin.Get("/vaccines", immunizationHandler.GetVaccines)
+	admin.Post("/vaccines/reload", immunizationHandler.ReloadSchedule)
+	admin.Get("/immunizations/duplicates", immunizationHandler.GetDuplicateDoses)
+	admin.Delete("/immunizations/:id", immunizationHandler.DeleteDuplicateDose)
+
+	// Audit log routes (admin only)
+	admin.Get("/audit-logs", auditHandler.GetAuditLogs)
+
+	// Sign-in attempts and account lockouts (admin only)
+	admin.Get("/auth-events", authHandler.GetAuthEvents)
+	admin.Post("/users/:id/unlock", authHandler.UnlockAccount)
+
 	// 404 handler
 	app.Use(handlers.NotFound)
 
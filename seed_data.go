@@ -270,8 +270,10 @@ func createStaff(db *gorm.DB, clinics []models.Clinic) ([]models.Staff, error) {
 				userType = "doctor"
 			case "Nurse":
 				userType = "nurse"
+			case "Pharmacist":
+				userType = "pharmacist"
 			default:
-				userType = "clinic_staff"
+				userType = "clinic_admin"
 			}
 
 			user := models.User{